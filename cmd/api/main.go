@@ -15,6 +15,7 @@ import (
 	"umrah-backend/internal/worker"
 	"umrah-backend/pkg/database"
 	"umrah-backend/pkg/notification"
+	"umrah-backend/pkg/payment"
 	"umrah-backend/pkg/queue"
 
 	jwtware "github.com/gofiber/contrib/jwt"
@@ -32,6 +33,10 @@ func main() {
 	if err := os.MkdirAll("./uploads", 0755); err != nil {
 		log.Fatal("Failed to create upload directory:", err)
 	}
+	// Issued invoices/receipts: private, served only through the download endpoint
+	if err := os.MkdirAll("./storage/documents", 0755); err != nil {
+		log.Fatal("Failed to create document storage directory:", err)
+	}
 
 	// 1. Connect DB, Redis & RabbitMQ
 	db := database.ConnectPostgres()
@@ -44,17 +49,65 @@ func main() {
 	db.AutoMigrate(
 		&entity.User{},
 		&entity.TravelPackage{},
+		&entity.PackageItineraryDay{},
+		&entity.PackageItineraryItem{},
+		&entity.PackageInclusion{},
+		&entity.PackageFlight{},
+		&entity.PackageHotel{},
+		&entity.PackageVersion{},
+		&entity.WaitlistEntry{},
 		&entity.Booking{},
+		&entity.BookingPassenger{},
+		&entity.BookingInstallment{},
+		&entity.Room{},
+		&entity.RoomAssignment{},
 		&entity.Group{},
 		&entity.GroupMember{},
 		&entity.Message{},
 		&entity.Itinerary{},
 		&entity.Attendance{},
+		&entity.ProductCategory{},
 		&entity.Product{},
+		&entity.ProductImage{},
+		&entity.ProductVariant{},
 		&entity.Order{},
+		&entity.OrderItem{},
+		&entity.OrderProof{},
+		&entity.OrderEvent{},
+		&entity.RefundRequest{},
+		&entity.CartItem{},
 		&entity.Manasik{},
+		&entity.PaymentTransaction{},
+		&entity.PaymentWebhookEvent{},
+		&entity.ExchangeRate{},
+		&entity.Document{},
+		&entity.DocumentSequence{},
+		&entity.PromoCode{},
+		&entity.PromoRestriction{},
+		&entity.PromoRedemption{},
+		&entity.Agent{},
+		&entity.CommissionRule{},
+		&entity.Commission{},
+		&entity.CommissionPayout{},
 	)
 
+	// Backfill integer money columns from the old float columns (one-off)
+	if err := database.MigrateLegacyMoneyColumns(db); err != nil {
+		log.Fatalf("❌ Failed to migrate money columns: %v", err)
+	}
+	if err := database.MigrateLegacyOrderItems(db); err != nil {
+		log.Fatalf("❌ Failed to migrate order items: %v", err)
+	}
+	if err := database.MigrateLegacyOrderProofs(db); err != nil {
+		log.Fatalf("❌ Failed to migrate order proofs: %v", err)
+	}
+	if err := database.DropLegacyCartIndex(db); err != nil {
+		log.Fatalf("❌ Failed to drop legacy cart index: %v", err)
+	}
+	if err := database.CreateWaitlistActiveIndex(db); err != nil {
+		log.Fatalf("❌ Failed to create waitlist index: %v", err)
+	}
+
 	// 3. Initialize Repositories
 	userRepo := repository.NewUserRepository(db)
 	groupRepo := repository.NewGroupRepository(db)
@@ -63,23 +116,60 @@ func main() {
 	commerceRepo := repository.NewCommerceRepository(db)
 	pkgRepo := repository.NewPackageRepository(db)
 	manasikRepo := repository.NewManasikRepository(db)
+	roomingRepo := repository.NewRoomingRepository(db)
+	paymentRepo := repository.NewPaymentRepository(db)
+	currencyRepo := repository.NewCurrencyRepository(db)
+	documentRepo := repository.NewDocumentRepository(db)
+	reportRepo := repository.NewReportRepository(db)
+	promoRepo := repository.NewPromoRepository(db)
+	agentRepo := repository.NewAgentRepository(db)
 
 	// 4. [FIXED] Initialize FCM Service FIRST (Needed for Worker)
 	fcmSvc := notification.NewFCMService("firebase-credentials.json")
 
 	// 5. [FIXED] Setup Worker (Now fcmSvc exists)
-	worker := worker.NewChatWorker(rabbit, chatRepo, groupRepo, fcmSvc)
-	worker.Start()
+	chatWorker := worker.NewChatWorker(rabbit, chatRepo, groupRepo, fcmSvc)
+	chatWorker.Start()
 
 	// 6. Initialize Services
 	authSvc := service.NewAuthService(userRepo, redisClient)
-	groupSvc := service.NewGroupService(groupRepo)
+	branding := loadBranding()
+	itinerarySvc := service.NewItineraryService(itineraryRepo, pkgRepo, branding.Location)
+	groupSvc := service.NewGroupService(groupRepo, pkgRepo, itinerarySvc)
 	trackingSvc := service.NewTrackingService(redisClient, userRepo)
 	chatSvc := service.NewChatService(chatRepo, redisClient, rabbit)
-	itinerarySvc := service.NewItineraryService(itineraryRepo)
-	commerceSvc := service.NewCommerceService(commerceRepo)
-	pkgSvc := service.NewPackageService(pkgRepo)
+	currencySvc := service.NewCurrencyService(currencyRepo)
+	documentSvc := service.NewDocumentService(documentRepo, commerceRepo, pkgRepo, userRepo, branding, "./storage/documents")
+	promoSvc := service.NewPromoService(promoRepo)
+	commerceSvc := service.NewCommerceService(commerceRepo, currencySvc, documentSvc, promoSvc, fcmSvc)
+	agentSvc := service.NewAgentService(agentRepo, pkgRepo, userRepo)
+	pkgSvc := service.NewPackageService(pkgRepo, groupSvc, currencySvc, documentSvc, promoSvc, agentSvc, fcmSvc)
 	manasikSvc := service.NewManasikService(manasikRepo)
+	roomingSvc := service.NewRoomingService(roomingRepo, pkgRepo)
+	reportSvc := service.NewReportService(reportRepo, branding.Location)
+
+	// Payment Gateway: providers implement payment.Provider. The local
+	// simulator is opt-in, it lets anyone with admin access fake payments.
+	webhookSecret := os.Getenv("PAYMENT_WEBHOOK_SECRET")
+	if webhookSecret == "" {
+		log.Fatal("❌ PAYMENT_WEBHOOK_SECRET is not set, webhook callbacks could be forged")
+	}
+	var paymentProviders []payment.Provider
+	var paymentSimulator *payment.Simulator
+	if os.Getenv("PAYMENT_SIMULATOR_ENABLED") == "true" {
+		paymentSimulator = payment.NewSimulator(webhookSecret)
+		paymentProviders = append(paymentProviders, paymentSimulator)
+		log.Println("⚠️ Payment simulator enabled, do not use in production")
+	}
+	paymentProvider := os.Getenv("PAYMENT_PROVIDER")
+	if paymentProvider == "" && paymentSimulator != nil {
+		paymentProvider = paymentSimulator.Name()
+	}
+	paymentSvc := service.NewPaymentService(paymentRepo, commerceRepo, pkgRepo, commerceSvc, pkgSvc, paymentProvider, paymentProviders...)
+
+	// 6b. Background sweeper: release seats of unpaid bookings, stock of unpaid orders and orphaned promo uses
+	expiryWorker := worker.NewExpiryWorker(pkgSvc, commerceSvc, promoSvc, time.Minute)
+	expiryWorker.Start()
 
 	// 7. Initialize Handlers
 	authHandler := handler.NewAuthHandler(authSvc)
@@ -90,6 +180,14 @@ func main() {
 	commerceHandler := handler.NewCommerceHandler(commerceSvc)
 	pkgHandler := handler.NewPackageHandler(pkgSvc)
 	manasikHandler := handler.NewManasikHandler(manasikSvc)
+	roomingHandler := handler.NewRoomingHandler(roomingSvc)
+	currencyHandler := handler.NewCurrencyHandler(currencySvc)
+	documentHandler := handler.NewDocumentHandler(documentSvc)
+	reportHandler := handler.NewReportHandler(reportSvc)
+	promoHandler := handler.NewPromoHandler(promoSvc)
+	agentHandler := handler.NewAgentHandler(agentSvc)
+
+	paymentHandler := handler.NewPaymentHandler(paymentSvc, paymentSimulator)
 
 	// 8. Setup Fiber
 	app := fiber.New(fiber.Config{
@@ -123,7 +221,9 @@ func main() {
 	api.Post("/register", authHandler.Register)
 	api.Post("/login", authHandler.Login)
 	api.Get("/packages", pkgHandler.GetList)
+	api.Get("/packages/:id", pkgHandler.Get)
 	api.Get("/manasik", manasikHandler.GetList)
+	api.Post("/payments/webhook/:provider", paymentHandler.Webhook)
 
 	// B. PROTECTED ROUTES (User Logged In)
 	api.Use(middleware.Protected())                     // Check JWT Signature
@@ -146,21 +246,147 @@ func main() {
 
 	// 5. Commerce
 	api.Get("/products", commerceHandler.GetCatalog)
+	api.Get("/products/:id", commerceHandler.GetProduct)
+	api.Get("/product-categories", commerceHandler.GetCategories)
 	api.Post("/orders", commerceHandler.CreateOrder)
+	api.Get("/cart", commerceHandler.GetCart)
+	api.Post("/cart/items", commerceHandler.AddToCart)
+	api.Put("/cart/items/:product_id", commerceHandler.UpdateCartItem)
+	api.Delete("/cart/items/:product_id", commerceHandler.RemoveFromCart)
+	api.Post("/cart/checkout", commerceHandler.Checkout)
+	api.Post("/cart/promo", commerceHandler.QuoteCart)
 	api.Get("/orders/my", commerceHandler.GetMyOrders)
 	api.Post("/orders/:id/proof", commerceHandler.UploadProof)
+	api.Get("/orders/:id/proofs", commerceHandler.GetProofs)
+	api.Post("/orders/:id/cancel", commerceHandler.CancelOrder)
+	api.Post("/orders/:id/refund", commerceHandler.RequestRefund)
+	api.Get("/orders/:id/documents", documentHandler.GetOrderDocuments)
+	api.Post("/orders/:id/pay", paymentHandler.PayOrder)
 	api.Post("/bookings", pkgHandler.Book)
+	api.Post("/bookings/quote", pkgHandler.Quote)
+	api.Post("/bookings/:id/cancel", pkgHandler.Cancel)
+	api.Get("/bookings/:id/passengers", pkgHandler.GetPassengers)
+	api.Put("/bookings/:id/passengers", pkgHandler.SetPassengers)
+	api.Post("/bookings/:id/payment-plan", pkgHandler.CreatePaymentPlan)
+	api.Get("/bookings/:id/balance", pkgHandler.GetBalance)
+	api.Get("/bookings/:id/terms", pkgHandler.BookingTerms)
+	api.Get("/bookings/:id/documents", documentHandler.GetBookingDocuments)
+	api.Post("/bookings/:id/installments/:installment_id/proof", pkgHandler.UploadInstallmentProof)
+	api.Post("/bookings/:id/installments/:installment_id/pay", paymentHandler.PayInstallment)
+	api.Post("/waitlist", pkgHandler.JoinWaitlist)
+	api.Get("/waitlist/my", pkgHandler.MyWaitlist)
+	api.Post("/waitlist/:id/cancel", pkgHandler.LeaveWaitlist)
+	api.Post("/waitlist/:id/claim", pkgHandler.ClaimWaitlist)
+
+	// 6. Payments
+	api.Get("/payments/:id", paymentHandler.Get)
+
+	// 7. Invoices & Receipts
+	api.Get("/documents/:id/download", documentHandler.Download)
 
 	// C. ADMIN / MUTAWWIF ROUTES (RBAC)
 	admin := api.Group("/admin", middleware.AuthorizeRole("ADMIN", "MUTAWWIF"))
 
 	admin.Post("/groups", groupHandler.Create)
+	admin.Post("/packages/:id/group", groupHandler.CreateFromPackage)
+	admin.Post("/packages/:id/group/sync", groupHandler.SyncPackageMembers)
+	admin.Post("/packages/:id/group/rundown", groupHandler.GeneratePackageRundown)
 	admin.Post("/packages", pkgHandler.Create)
+	admin.Get("/packages", pkgHandler.AdminList)
+	admin.Get("/packages/:id", pkgHandler.AdminGet)
+	admin.Put("/packages/:id", middleware.AuthorizeRole("ADMIN"), pkgHandler.Update)
+	admin.Post("/packages/:id/clone", middleware.AuthorizeRole("ADMIN"), pkgHandler.Clone)
+	admin.Post("/packages/:id/archive", middleware.AuthorizeRole("ADMIN"), pkgHandler.Archive)
+	admin.Put("/packages/:id/itinerary", middleware.AuthorizeRole("ADMIN"), pkgHandler.SetItinerary)
+	admin.Put("/packages/:id/inclusions", middleware.AuthorizeRole("ADMIN"), pkgHandler.SetInclusions)
+	admin.Put("/packages/:id/flights", middleware.AuthorizeRole("ADMIN"), pkgHandler.SetFlights)
+	admin.Put("/packages/:id/hotels", middleware.AuthorizeRole("ADMIN"), pkgHandler.SetHotels)
 	admin.Post("/products", commerceHandler.CreateProduct)
+	admin.Get("/products", commerceHandler.ListAllProducts)
+	admin.Put("/products/:id", commerceHandler.UpdateProduct)
+	admin.Delete("/products/:id", commerceHandler.DeleteProduct)
+	admin.Put("/products/:id/variants/:variant_id", commerceHandler.UpdateVariant)
+	admin.Post("/products/:id/images", commerceHandler.AddProductImage)
+	admin.Delete("/products/:id/images/:image_id", commerceHandler.DeleteProductImage)
+	admin.Post("/product-categories", commerceHandler.CreateCategory)
+	admin.Put("/product-categories/:id", commerceHandler.UpdateCategory)
+	admin.Delete("/product-categories/:id", commerceHandler.DeleteCategory)
 	admin.Post("/manasik", manasikHandler.Create)
 	admin.Post("/itineraries", itineraryHandler.Create)
 	admin.Get("/itineraries/:id/attendance", itineraryHandler.GetReport)
+	admin.Get("/orders", commerceHandler.ListOrders)
+	admin.Post("/orders/verify", commerceHandler.BulkVerify)
 	admin.Patch("/orders/:id/verify", commerceHandler.VerifyOrder)
+	admin.Patch("/orders/:id/reject", commerceHandler.RejectOrder)
+	admin.Get("/orders/:id/events", commerceHandler.GetEvents)
+
+	// Order Refunds
+	admin.Get("/refund-requests", commerceHandler.ListRefunds)
+	admin.Patch("/refund-requests/:id/approve", middleware.AuthorizeRole("ADMIN"), commerceHandler.ApproveRefund)
+	admin.Patch("/refund-requests/:id/decline", middleware.AuthorizeRole("ADMIN"), commerceHandler.DeclineRefund)
+	admin.Post("/refund-requests/:id/proof", middleware.AuthorizeRole("ADMIN"), commerceHandler.UploadRefundProof)
+
+	// Booking Management
+	admin.Get("/bookings", pkgHandler.ListBookings)
+	admin.Get("/bookings/export", pkgHandler.ExportBookings)
+	admin.Patch("/bookings/:id/approve", pkgHandler.ApproveBooking)
+	admin.Patch("/bookings/:id/cancel", pkgHandler.AdminCancelBooking)
+	admin.Patch("/installments/:id/verify", pkgHandler.VerifyInstallment)
+	admin.Get("/packages/:id/manifest", pkgHandler.Manifest)
+	admin.Get("/packages/:id/waitlist", pkgHandler.Waitlist)
+	admin.Get("/packages/:id/versions", pkgHandler.Versions)
+
+	// Payments (gateway refunds, simulator)
+	adminPayments := admin.Group("/payments", middleware.AuthorizeRole("ADMIN"))
+	adminPayments.Get("/", paymentHandler.List)
+	adminPayments.Post("/:id/refund", paymentHandler.Refund)
+	adminPayments.Post("/simulator/:external_id/:status", paymentHandler.Simulate)
+
+	// Rooming List
+	admin.Post("/packages/:id/rooming/generate", roomingHandler.Generate)
+	admin.Get("/packages/:id/rooming", roomingHandler.Get)
+	admin.Post("/packages/:id/rooms", roomingHandler.CreateRoom)
+	admin.Put("/rooming/assignments", roomingHandler.Assign)
+	admin.Delete("/rooming/assignments/:passenger_id", roomingHandler.Unassign)
+
+	// Exchange Rates (display conversion only)
+	admin.Get("/exchange-rates", currencyHandler.GetRates)
+	admin.Put("/exchange-rates", middleware.AuthorizeRole("ADMIN"), currencyHandler.SetRate)
+
+	// Sales Reports (Admin only, ?format=csv for export)
+	reports := admin.Group("/reports", middleware.AuthorizeRole("ADMIN"))
+	reports.Get("/revenue", reportHandler.Revenue)
+	reports.Get("/revenue/products", reportHandler.RevenueByProduct)
+	reports.Get("/revenue/categories", reportHandler.RevenueByCategory)
+	reports.Get("/occupancy", reportHandler.Occupancy)
+	reports.Get("/outstanding", reportHandler.Outstanding)
+
+	// Promo Codes (Admin only)
+	promos := admin.Group("/promo-codes", middleware.AuthorizeRole("ADMIN"))
+	promos.Get("/", promoHandler.List)
+	promos.Post("/", promoHandler.Create)
+	promos.Get("/:id", promoHandler.Get)
+	promos.Put("/:id", promoHandler.Update)
+	promos.Get("/:id/redemptions", promoHandler.Redemptions)
+
+	// Agents & Commissions (Admin only)
+	agents := admin.Group("/agents", middleware.AuthorizeRole("ADMIN"))
+	agents.Get("/", agentHandler.List)
+	agents.Post("/", agentHandler.Create)
+	agents.Get("/:id", agentHandler.Get)
+	agents.Put("/:id", agentHandler.Update)
+	agents.Get("/:id/statement", agentHandler.Statement)
+	agents.Post("/:id/payouts", agentHandler.CreatePayout)
+	admin.Get("/commission-rules", middleware.AuthorizeRole("ADMIN"), agentHandler.GetRules)
+	admin.Put("/commission-rules", middleware.AuthorizeRole("ADMIN"), agentHandler.SetRules)
+	admin.Get("/packages/:id/commission-rules", middleware.AuthorizeRole("ADMIN"), agentHandler.GetRules)
+	admin.Put("/packages/:id/commission-rules", middleware.AuthorizeRole("ADMIN"), agentHandler.SetRules)
+
+	// D. AGENT ROUTES
+	agentAPI := api.Group("/agent", middleware.AuthorizeRole("AGENT"))
+	agentAPI.Get("/me", agentHandler.Me)
+	agentAPI.Get("/bookings", agentHandler.MyBookings)
+	agentAPI.Get("/statement", agentHandler.MyStatement)
 
 	// --- WEBSOCKET ROUTE ---
 	app.Use("/ws", func(c *fiber.Ctx) error {
@@ -196,3 +422,27 @@ func main() {
 		log.Panic(err)
 	}
 }
+
+// loadBranding reads the agency details printed on invoices and receipts.
+func loadBranding() service.Branding {
+	tz := os.Getenv("AGENCY_TIMEZONE")
+	if tz == "" {
+		tz = "Asia/Jakarta"
+	}
+	location, err := time.LoadLocation(tz)
+	if err != nil {
+		log.Printf("⚠️ Warning: unknown AGENCY_TIMEZONE %q, using UTC+7", tz)
+		location = time.FixedZone("WIB", 7*60*60)
+	}
+
+	return service.Branding{
+		Name:     os.Getenv("AGENCY_NAME"),
+		Address:  os.Getenv("AGENCY_ADDRESS"),
+		Phone:    os.Getenv("AGENCY_PHONE"),
+		Email:    os.Getenv("AGENCY_EMAIL"),
+		Website:  os.Getenv("AGENCY_WEBSITE"),
+		License:  os.Getenv("AGENCY_LICENSE"),
+		Color:    os.Getenv("AGENCY_COLOR"),
+		Location: location,
+	}
+}
@@ -0,0 +1,158 @@
+package entity
+
+import (
+	"time"
+	"umrah-backend/pkg/money"
+
+	"github.com/google/uuid"
+)
+
+type CommissionType string
+type CommissionStatus string
+
+const (
+	CommissionPercent CommissionType = "PERCENT" // PercentBP of the booking total (after discount)
+	CommissionPerPax  CommissionType = "PER_PAX" // Amount x pax
+
+	CommissionEarned    CommissionStatus = "EARNED"    // Booking confirmed, not paid out yet
+	CommissionPaid      CommissionStatus = "PAID"      // Included in a payout
+	CommissionCancelled CommissionStatus = "CANCELLED" // Booking cancelled before payout
+)
+
+// Commission levels: the agent who referred the booking, and the master
+// agent above it (override commission).
+const (
+	LevelAgent  = 1
+	LevelMaster = 2
+)
+
+// Agent is the sales profile of a user with role AGENT. Agents form a
+// two-tier hierarchy: a sub-agent may sell under one master agent.
+type Agent struct {
+	ID     uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex" json:"user_id"`
+	User   *User     `gorm:"foreignKey:UserID" json:"user,omitempty"`
+
+	ReferralCode string     `gorm:"type:varchar(20);uniqueIndex;not null" json:"referral_code"` // Given to customers at booking
+	ParentID     *uuid.UUID `gorm:"type:uuid;index" json:"parent_id,omitempty"`                 // Master agent
+	Parent       *Agent     `gorm:"foreignKey:ParentID" json:"parent,omitempty"`
+	IsActive     bool       `json:"is_active"` // Inactive agents cannot be referred and earn nothing
+
+	// Where commission payouts are transferred to
+	BankName          string `gorm:"type:varchar(50)" json:"bank_name"`
+	BankAccountNumber string `gorm:"type:varchar(30)" json:"bank_account_number"`
+	BankAccountName   string `gorm:"type:varchar(100)" json:"bank_account_name"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CommissionRule: what one level earns on bookings of a package. Rules
+// without PackageID are the defaults for packages without their own rule.
+type CommissionRule struct {
+	ID        uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	PackageID *uuid.UUID     `gorm:"type:uuid;index" json:"package_id,omitempty"`
+	Level     int            `gorm:"not null" json:"level"`
+	Type      CommissionType `gorm:"type:varchar(10);not null" json:"type"`
+	PercentBP int64          `json:"percent_bp,omitempty"`                          // PERCENT, basis points: 250 = 2.5%
+	Amount    money.Money    `gorm:"embedded;embeddedPrefix:amount_" json:"amount"` // PER_PAX
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Commission is earned by one agent on one confirmed booking. Booking
+// details are snapshots for the agent statement.
+type Commission struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	AgentID   uuid.UUID `gorm:"type:uuid;not null;index" json:"agent_id"`
+	BookingID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_commission_booking_level" json:"booking_id"`
+	Level     int       `gorm:"not null;uniqueIndex:idx_commission_booking_level" json:"level"`
+
+	PackageName  string `gorm:"type:varchar(255)" json:"package_name"`
+	CustomerName string `gorm:"type:varchar(100)" json:"customer_name"`
+	PaxCount     int    `json:"pax_count"`
+
+	Basis  money.Money      `gorm:"embedded;embeddedPrefix:basis_" json:"basis"` // Booking total it was computed on
+	Amount money.Money      `gorm:"embedded;embeddedPrefix:amount_" json:"amount"`
+	Status CommissionStatus `gorm:"type:varchar(20);default:'EARNED';index" json:"status"`
+
+	EarnedAt    time.Time  `gorm:"index" json:"earned_at"`
+	PayoutID    *uuid.UUID `gorm:"type:uuid;index" json:"payout_id,omitempty"`
+	PaidAt      *time.Time `json:"paid_at,omitempty"`
+	CancelledAt *time.Time `json:"cancelled_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CommissionPayout: one transfer to an agent covering its earned commissions.
+type CommissionPayout struct {
+	ID        uuid.UUID   `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	AgentID   uuid.UUID   `gorm:"type:uuid;not null;index" json:"agent_id"`
+	Amount    money.Money `gorm:"embedded;embeddedPrefix:amount_" json:"amount"`
+	Count     int         `json:"count"`                              // Commissions covered
+	Reference string      `gorm:"type:varchar(100)" json:"reference"` // Bank transfer reference
+	Note      string      `gorm:"type:text" json:"note,omitempty"`
+	PaidBy    uuid.UUID   `gorm:"type:uuid" json:"paid_by"`
+	PaidAt    time.Time   `gorm:"index" json:"paid_at"`
+}
+
+// CommissionTotal: all-time figures of one agent in one currency (not stored).
+type CommissionTotal struct {
+	Earned  money.Money `json:"earned"` // Earned + paid, cancelled left out
+	Paid    money.Money `json:"paid"`
+	Balance money.Money `json:"balance"` // Earned, not paid out yet
+}
+
+// AgentStatement is the response of the statement endpoints (not stored).
+// Totals are all-time; the lists cover From..To.
+type AgentStatement struct {
+	Agent       *Agent             `json:"agent"`
+	From        *time.Time         `json:"from,omitempty"`
+	To          *time.Time         `json:"to,omitempty"`
+	Totals      []CommissionTotal  `json:"totals"` // One per currency
+	Commissions []Commission       `json:"commissions"`
+	Payouts     []CommissionPayout `json:"payouts"`
+}
+
+// --- REQUEST DTOs ---
+
+// CreateAgentDTO turns an existing user into an agent.
+type CreateAgentDTO struct {
+	UserID       string `json:"user_id" validate:"required,uuid"`
+	ReferralCode string `json:"referral_code" validate:"omitempty,alphanum,min=4,max=20"` // Generated when empty
+	ParentID     string `json:"parent_id" validate:"omitempty,uuid"`
+
+	BankName          string `json:"bank_name" validate:"max=50"`
+	BankAccountNumber string `json:"bank_account_number" validate:"max=30"`
+	BankAccountName   string `json:"bank_account_name" validate:"max=100"`
+}
+
+// UpdateAgentDTO: nil fields are left unchanged.
+type UpdateAgentDTO struct {
+	ParentID    *string `json:"parent_id" validate:"omitempty,uuid"`
+	ClearParent bool    `json:"clear_parent"`
+	IsActive    *bool   `json:"is_active"`
+
+	BankName          *string `json:"bank_name" validate:"omitempty,max=50"`
+	BankAccountNumber *string `json:"bank_account_number" validate:"omitempty,max=30"`
+	BankAccountName   *string `json:"bank_account_name" validate:"omitempty,max=100"`
+}
+
+type CommissionRuleDTO struct {
+	Level     int            `json:"level" validate:"required,oneof=1 2"`
+	Type      CommissionType `json:"type" validate:"required,oneof=PERCENT PER_PAX"`
+	PercentBP int64          `json:"percent_bp" validate:"required_if=Type PERCENT,omitempty,min=1,max=10000"`
+	Amount    *money.Money   `json:"amount" validate:"required_if=Type PER_PAX"`
+}
+
+// SetCommissionRulesDTO replaces every rule of a package (or the defaults).
+type SetCommissionRulesDTO struct {
+	Rules []CommissionRuleDTO `json:"rules" validate:"max=2,dive"`
+}
+
+type CreatePayoutDTO struct {
+	Currency  string `json:"currency" validate:"omitempty,len=3"` // Default IDR
+	Reference string `json:"reference" validate:"required,max=100"`
+	Note      string `json:"note" validate:"max=500"`
+}
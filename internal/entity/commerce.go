@@ -2,17 +2,73 @@ package entity
 
 import (
 	"time"
+	"umrah-backend/pkg/money"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// ProductCategory groups the catalog, e.g. "SIM & Data", "Zamzam", "Perlengkapan".
+type ProductCategory struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Name      string    `gorm:"type:varchar(100);not null" json:"name"`
+	Slug      string    `gorm:"type:varchar(100);uniqueIndex;not null" json:"slug"` // Used in ?category=
+	SortOrder int       `gorm:"default:0" json:"sort_order"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
 type Product struct {
-	ID          uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	Name        string    `gorm:"type:varchar(255);not null" json:"name"` // "Roaming Telkomsel 10GB"
-	Description string    `gorm:"type:text" json:"description"`
-	Price       float64   `gorm:"type:decimal(15,2);not null" json:"price"`
-	IsActive    bool      `gorm:"default:true" json:"is_active"`
+	ID          uuid.UUID   `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Name        string      `gorm:"type:varchar(255);not null" json:"name"` // "Roaming Telkomsel 10GB"
+	Description string      `gorm:"type:text" json:"description"`
+	Price       money.Money `gorm:"embedded;embeddedPrefix:price_" json:"price"`
+	IsActive    bool        `gorm:"default:true" json:"is_active"`
+
+	CategoryID *uuid.UUID       `gorm:"type:uuid;index" json:"category_id,omitempty"`
+	Category   *ProductCategory `gorm:"foreignKey:CategoryID" json:"category,omitempty"`
+	SortOrder  int              `gorm:"default:0" json:"sort_order"` // Lower comes first
+	Images     []ProductImage   `gorm:"foreignKey:ProductID" json:"images,omitempty"`
+
+	// Sales window, e.g. hide the SIM card bundle after the group departs.
+	// Nil = no limit on that side.
+	AvailableFrom  *time.Time `json:"available_from,omitempty"`
+	AvailableUntil *time.Time `json:"available_until,omitempty"`
+
+	// Units left to sell; nil = unlimited (e.g. e-SIM vouchers).
+	// Ignored when the product has variants: stock is kept per variant.
+	Stock    *int             `json:"stock"`
+	Variants []ProductVariant `gorm:"foreignKey:ProductID" json:"variants,omitempty"`
+
+	// Price converted to the requester's currency (?currency=USD), not stored
+	DisplayPrice *money.Money `gorm:"-" json:"display_price,omitempty"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// IsAvailable reports whether the product can be bought at time now.
+func (p *Product) IsAvailable(now time.Time) bool {
+	if !p.IsActive {
+		return false
+	}
+	if p.AvailableFrom != nil && now.Before(*p.AvailableFrom) {
+		return false
+	}
+	if p.AvailableUntil != nil && !now.Before(*p.AvailableUntil) {
+		return false
+	}
+	return true
+}
+
+// ProductImage: one photo of a product, stored via the uploads folder.
+type ProductImage struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	ProductID uuid.UUID `gorm:"type:uuid;not null;index" json:"product_id"`
+	URL       string    `gorm:"type:text;not null" json:"url"`
+	SortOrder int       `gorm:"default:0" json:"sort_order"` // 0 = cover image
 
 	CreatedAt time.Time `json:"created_at"`
 }
@@ -23,24 +79,319 @@ const (
 	OrderPending   OrderStatus = "PENDING"   // User created order, hasn't paid
 	OrderPaid      OrderStatus = "PAID"      // User uploaded proof
 	OrderCompleted OrderStatus = "COMPLETED" // Admin verified proof
+	OrderRejected  OrderStatus = "REJECTED"  // Admin rejected proof, user may upload a new one
 	OrderCancelled OrderStatus = "CANCELLED"
+	OrderExpired   OrderStatus = "EXPIRED" // Not paid in time, stock released
+
+	OrderRefundRequested OrderStatus = "REFUND_REQUESTED" // Buyer asked for the money back
+	OrderRefunded        OrderStatus = "REFUNDED"         // Admin transferred the money back
+)
+
+// orderTransitions lists every legal move of the order state machine.
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderPending:   {OrderPaid, OrderCompleted, OrderCancelled, OrderExpired},
+	OrderPaid:      {OrderPaid, OrderCompleted, OrderRejected}, // PAID -> PAID: new proof replaces the one under review
+	OrderRejected:  {OrderPaid, OrderCompleted, OrderCancelled, OrderExpired},
+	OrderCompleted: {OrderRefundRequested, OrderRefunded}, // COMPLETED -> REFUNDED: gateway payment refunded by an admin
+	OrderCancelled: {},
+	OrderExpired:   {},
+
+	// Only the refund flow moves it on: a declined request restores the
+	// saved status directly, so proofs and gateway callbacks cannot
+	OrderRefundRequested: {OrderRefunded},
+	OrderRefunded:        {},
+}
+
+// Valid reports whether s is a known order status.
+func (s OrderStatus) Valid() bool {
+	_, ok := orderTransitions[s]
+	return ok
+}
+
+// CanTransitionTo reports whether an order in status s may move to next.
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range orderTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+type OrderActorType string
+
+const (
+	ActorUser    OrderActorType = "USER"
+	ActorAdmin   OrderActorType = "ADMIN"
+	ActorGateway OrderActorType = "GATEWAY" // Payment gateway webhook
+	ActorSystem  OrderActorType = "SYSTEM"  // Background workers
 )
 
+// OrderEvent is the audit trail of an order: one row per status change.
+type OrderEvent struct {
+	ID         uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	OrderID    uuid.UUID      `gorm:"type:uuid;not null;index" json:"order_id"`
+	FromStatus OrderStatus    `gorm:"type:varchar(20)" json:"from_status"` // Empty for the creation event
+	ToStatus   OrderStatus    `gorm:"type:varchar(20);not null" json:"to_status"`
+	ActorType  OrderActorType `gorm:"type:varchar(20);not null" json:"actor_type"`
+	ActorID    *uuid.UUID     `gorm:"type:uuid;index" json:"actor_id,omitempty"` // Nil for SYSTEM / GATEWAY
+	Actor      *User          `gorm:"foreignKey:ActorID" json:"actor,omitempty"`
+	Note       string         `gorm:"type:text" json:"note,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ProductVariant: a sellable option of a product, e.g. "10GB" or "XL".
+type ProductVariant struct {
+	ID        uuid.UUID   `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	ProductID uuid.UUID   `gorm:"type:uuid;not null;index" json:"product_id"`
+	Name      string      `gorm:"type:varchar(100);not null" json:"name"`
+	SKU       string      `gorm:"type:varchar(64)" json:"sku,omitempty"`
+	Price     money.Money `gorm:"embedded;embeddedPrefix:price_" json:"price"` // Defaults to the product price
+	Stock     *int        `json:"stock"`                                       // nil = unlimited
+	IsActive  bool        `gorm:"default:true" json:"is_active"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type Order struct {
 	ID     uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
 	UserID uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
 	User   *User     `gorm:"foreignKey:UserID" json:"user,omitempty"`
 
-	ProductID uuid.UUID `gorm:"type:uuid;not null" json:"product_id"`
-	Product   *Product  `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	// Order lines; Amount is the sum of the line subtotals minus Discount
+	Items []OrderItem `gorm:"foreignKey:OrderID" json:"items,omitempty"`
+
+	Amount money.Money `gorm:"embedded;embeddedPrefix:amount_" json:"amount"` // Snapshot of total at purchase time
+
+	// Promo code applied at purchase time; Discount is already taken off Amount
+	PromoCode string      `gorm:"type:varchar(40)" json:"promo_code,omitempty"`
+	Discount  money.Money `gorm:"embedded;embeddedPrefix:discount_" json:"discount"`
 
-	Amount float64     `json:"amount"` // Snapshot of price at purchase time
 	Status OrderStatus `gorm:"type:varchar(20);default:'PENDING'" json:"status"`
 
-	// URL to the uploaded transfer proof image
-	ProofImage string `gorm:"type:text" json:"proof_image"`
+	// URL to the latest uploaded transfer proof image (history in Proofs)
+	ProofImage      string       `gorm:"type:text" json:"proof_image"`
+	ProofImageURL   string       `gorm:"-" json:"proof_image_url,omitempty"`          // Absolute preview URL (admin queue)
+	RejectionReason string       `gorm:"type:text" json:"rejection_reason,omitempty"` // Set while REJECTED
+	Proofs          []OrderProof `gorm:"foreignKey:OrderID" json:"proofs,omitempty"`
+
+	// Unpaid (PENDING) orders give their reserved stock back after this time
+	ExpiresAt *time.Time `gorm:"index" json:"expires_at,omitempty"`
 
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 }
+
+type ProofStatus string
+
+const (
+	ProofSubmitted  ProofStatus = "SUBMITTED"  // Waiting for admin review
+	ProofAccepted   ProofStatus = "ACCEPTED"   // Order verified with this proof
+	ProofRejected   ProofStatus = "REJECTED"   // Fake / blurry / wrong amount
+	ProofSuperseded ProofStatus = "SUPERSEDED" // Replaced by a newer upload before review
+)
+
+// OrderProof keeps every transfer proof ever uploaded for an order.
+type OrderProof struct {
+	ID       uuid.UUID   `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	OrderID  uuid.UUID   `gorm:"type:uuid;not null;index" json:"order_id"`
+	ImageURL string      `gorm:"type:text;not null" json:"image_url"`
+	Status   ProofStatus `gorm:"type:varchar(20);default:'SUBMITTED'" json:"status"`
+	Reason   string      `gorm:"type:text" json:"reason,omitempty"` // Rejection reason
+
+	ReviewedBy *uuid.UUID `gorm:"type:uuid" json:"reviewed_by,omitempty"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type RefundStatus string
+
+const (
+	RefundRequested RefundStatus = "REQUESTED" // Waiting for admin review
+	RefundApproved  RefundStatus = "APPROVED"  // Approved, transfer not yet made
+	RefundDeclined  RefundStatus = "DECLINED"  // Admin declined, order restored
+	RefundCompleted RefundStatus = "REFUNDED"  // Money sent back, proof uploaded
+)
+
+// RefundRequest: a buyer's request to get the money of a paid order back.
+type RefundRequest struct {
+	ID      uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	OrderID uuid.UUID `gorm:"type:uuid;not null;index" json:"order_id"`
+	Order   *Order    `gorm:"foreignKey:OrderID" json:"order,omitempty"`
+	UserID  uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+
+	Reason         string       `gorm:"type:text;not null" json:"reason"`
+	Amount         money.Money  `gorm:"embedded;embeddedPrefix:amount_" json:"amount"`
+	Status         RefundStatus `gorm:"type:varchar(20);default:'REQUESTED';index" json:"status"`
+	PreviousStatus OrderStatus  `gorm:"type:varchar(20)" json:"previous_status"` // Order status when the refund was requested
+
+	// Admin side
+	AdminNote   string     `gorm:"type:text" json:"admin_note,omitempty"`
+	ReviewedBy  *uuid.UUID `gorm:"type:uuid" json:"reviewed_by,omitempty"`
+	ReviewedAt  *time.Time `json:"reviewed_at,omitempty"`
+	RefundProof string     `gorm:"type:text" json:"refund_proof,omitempty"` // Admin's transfer receipt
+	RefundedAt  *time.Time `json:"refunded_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// OrderItem is one line of an order. Name and price are snapshots, so later
+// product edits do not change what the pilgrim paid.
+type OrderItem struct {
+	ID      uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	OrderID uuid.UUID `gorm:"type:uuid;not null;index" json:"order_id"`
+
+	ProductID   uuid.UUID  `gorm:"type:uuid;not null;index" json:"product_id"`
+	Product     *Product   `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	ProductName string     `gorm:"type:varchar(255);not null" json:"product_name"`
+	VariantID   *uuid.UUID `gorm:"type:uuid" json:"variant_id,omitempty"`
+	VariantName string     `gorm:"type:varchar(100)" json:"variant_name,omitempty"`
+
+	Quantity  int         `gorm:"not null" json:"quantity"`
+	UnitPrice money.Money `gorm:"embedded;embeddedPrefix:unit_price_" json:"unit_price"` // Snapshot of product price
+	Subtotal  money.Money `gorm:"embedded;embeddedPrefix:subtotal_" json:"subtotal"`     // UnitPrice x Quantity
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CartItem: a product waiting in the user's cart (one row per product).
+type CartItem struct {
+	ID        uuid.UUID       `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID    uuid.UUID       `gorm:"type:uuid;not null;uniqueIndex:idx_cart_user_item" json:"user_id"`
+	ProductID uuid.UUID       `gorm:"type:uuid;not null;uniqueIndex:idx_cart_user_item" json:"product_id"`
+	Product   *Product        `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	VariantID *uuid.UUID      `gorm:"type:uuid;uniqueIndex:idx_cart_user_item" json:"variant_id,omitempty"`
+	Variant   *ProductVariant `gorm:"foreignKey:VariantID" json:"variant,omitempty"`
+	Quantity  int             `gorm:"not null" json:"quantity"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Cart is the response for GET /cart (not stored)
+type Cart struct {
+	Items []CartItem   `json:"items"`
+	Total *money.Money `json:"total,omitempty"` // Nil when the cart is empty
+}
+
+// OrderFilter is used by the admin order queue (keyset pagination).
+type OrderFilter struct {
+	Statuses    []OrderStatus // Empty = any status
+	UserID      string
+	ProductID   string // Orders containing this product
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time // Exclusive (the day after the last day asked for)
+
+	// Cursor: continue after this (created_at, id); nil = first page
+	AfterTime *time.Time
+	AfterID   string
+	Limit     int
+}
+
+// OrderPage is one page of the admin order queue.
+type OrderPage struct {
+	Items      []Order `json:"items"`
+	NextCursor string  `json:"next_cursor,omitempty"` // Empty on the last page
+}
+
+// BulkVerifyResult reports the outcome for one order of a bulk verify.
+type BulkVerifyResult struct {
+	OrderID  string `json:"order_id"`
+	Verified bool   `json:"verified"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ProductFilter is used by the catalog (GET /products) and the admin list.
+type ProductFilter struct {
+	Category      string // Category slug
+	Search        string // Name contains (case-insensitive)
+	Sort          string // "" (sort_order), "newest", "price_asc", "price_desc"
+	IncludeHidden bool   // Admin: also inactive / out-of-window products
+}
+
+// --- REQUEST DTOs ---
+
+// UpdateProductDTO: nil fields are left unchanged.
+type UpdateProductDTO struct {
+	Name           *string      `json:"name" validate:"omitempty,min=3,max=255"`
+	Description    *string      `json:"description"`
+	Price          *money.Money `json:"price"`
+	IsActive       *bool        `json:"is_active"`
+	Stock          *int         `json:"stock" validate:"omitempty,min=0"`
+	UnlimitedStock bool         `json:"unlimited_stock"` // true sets stock to unlimited (nil)
+	CategoryID     *string      `json:"category_id" validate:"omitempty,uuid"`
+	ClearCategory  bool         `json:"clear_category"`
+	SortOrder      *int         `json:"sort_order"`
+
+	// RFC3339, e.g. "2026-12-01T00:00:00+07:00"; "" clears the limit
+	AvailableFrom  *string `json:"available_from"`
+	AvailableUntil *string `json:"available_until"`
+}
+
+// UpdateVariantDTO: nil fields are left unchanged.
+type UpdateVariantDTO struct {
+	Name           *string      `json:"name" validate:"omitempty,min=1,max=100"`
+	SKU            *string      `json:"sku" validate:"omitempty,max=64"`
+	Price          *money.Money `json:"price"`
+	Stock          *int         `json:"stock" validate:"omitempty,min=0"`
+	UnlimitedStock bool         `json:"unlimited_stock"`
+	IsActive       *bool        `json:"is_active"`
+}
+
+type ProductCategoryDTO struct {
+	Name      string `json:"name" validate:"required,min=2,max=100"`
+	Slug      string `json:"slug" validate:"omitempty,max=100"` // Generated from name when empty
+	SortOrder int    `json:"sort_order"`
+}
+
+type BulkVerifyOrdersDTO struct {
+	OrderIDs []string `json:"order_ids" validate:"required,min=1,max=100,dive,uuid"`
+}
+
+type OrderItemDTO struct {
+	ProductID string `json:"product_id" validate:"required,uuid"`
+	VariantID string `json:"variant_id" validate:"omitempty,uuid"` // Required if the product has variants
+	Quantity  int    `json:"quantity" validate:"required,min=1,max=100"`
+}
+
+type CreateOrderDTO struct {
+	Items []OrderItemDTO `json:"items" validate:"omitempty,dive"`
+
+	// Deprecated: single-product order, kept for older app versions
+	ProductID string `json:"product_id" validate:"omitempty,uuid"`
+
+	PromoCode string `json:"promo_code" validate:"max=40"` // Optional
+}
+
+type CheckoutDTO struct {
+	PromoCode string `json:"promo_code" validate:"max=40"` // Optional
+}
+
+type AddCartItemDTO struct {
+	ProductID string `json:"product_id" validate:"required,uuid"`
+	VariantID string `json:"variant_id" validate:"omitempty,uuid"`
+	Quantity  int    `json:"quantity" validate:"required,min=1,max=100"`
+}
+
+type CancelOrderDTO struct {
+	Reason string `json:"reason" validate:"max=500"` // Optional
+}
+
+type RequestRefundDTO struct {
+	Reason string `json:"reason" validate:"required,min=3,max=500"`
+}
+
+type ReviewRefundDTO struct {
+	Note string `json:"note" validate:"max=500"` // Shown to the buyer when declined
+}
+
+type RejectOrderDTO struct {
+	Reason string `json:"reason" validate:"required,min=3,max=500"` // Shown to the buyer
+}
+
+type UpdateCartItemDTO struct {
+	Quantity int `json:"quantity" validate:"min=0,max=100"` // 0 removes the item
+}
@@ -0,0 +1,62 @@
+package entity
+
+import "testing"
+
+var allOrderStatuses = []OrderStatus{
+	OrderPending,
+	OrderPaid,
+	OrderCompleted,
+	OrderRejected,
+	OrderCancelled,
+	OrderExpired,
+	OrderRefundRequested,
+	OrderRefunded,
+}
+
+// TestOrderCanTransitionTo checks every (from, to) pair: the moves listed
+// here are allowed, every other one is forbidden.
+func TestOrderCanTransitionTo(t *testing.T) {
+	allowed := map[OrderStatus][]OrderStatus{
+		OrderPending:         {OrderPaid, OrderCompleted, OrderCancelled, OrderExpired},
+		OrderPaid:            {OrderPaid, OrderCompleted, OrderRejected},
+		OrderRejected:        {OrderPaid, OrderCompleted, OrderCancelled, OrderExpired},
+		OrderCompleted:       {OrderRefundRequested, OrderRefunded},
+		OrderCancelled:       {},
+		OrderExpired:         {},
+		OrderRefundRequested: {OrderRefunded},
+		OrderRefunded:        {},
+	}
+
+	for _, from := range allOrderStatuses {
+		want := make(map[OrderStatus]bool)
+		for _, to := range allowed[from] {
+			want[to] = true
+		}
+		for _, to := range allOrderStatuses {
+			t.Run(string(from)+"->"+string(to), func(t *testing.T) {
+				if got := from.CanTransitionTo(to); got != want[to] {
+					t.Errorf("%s.CanTransitionTo(%s) = %v, want %v", from, to, got, want[to])
+				}
+			})
+		}
+	}
+}
+
+// Every status must appear in the table, even terminal ones, so a new
+// status cannot be added without deciding where it may go.
+func TestOrderTransitionsCoverEveryStatus(t *testing.T) {
+	if len(orderTransitions) != len(allOrderStatuses) {
+		t.Errorf("orderTransitions has %d statuses, want %d", len(orderTransitions), len(allOrderStatuses))
+	}
+	for _, s := range allOrderStatuses {
+		if !s.Valid() {
+			t.Errorf("%s is not Valid", s)
+		}
+	}
+	if OrderStatus("UNKNOWN").Valid() {
+		t.Error("an unknown status must not be Valid")
+	}
+	if OrderStatus("UNKNOWN").CanTransitionTo(OrderPaid) {
+		t.Error("an unknown status must not transition")
+	}
+}
@@ -0,0 +1,28 @@
+package entity
+
+import (
+	"time"
+	"umrah-backend/pkg/money"
+
+	"github.com/google/uuid"
+)
+
+// ExchangeRate: 1 Base = Rate Quote (e.g. 1 USD = 16250.00 IDR).
+// Managed by admins; used to display prices in the requester's currency.
+type ExchangeRate struct {
+	ID    uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Base  money.Currency `gorm:"type:varchar(3);not null;uniqueIndex:idx_rate_pair" json:"base"`
+	Quote money.Currency `gorm:"type:varchar(3);not null;uniqueIndex:idx_rate_pair" json:"quote"`
+	Rate  string         `gorm:"type:numeric(24,10);not null" json:"rate"` // Decimal string, never float
+
+	UpdatedBy *uuid.UUID `gorm:"type:uuid" json:"updated_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// --- REQUEST DTOs ---
+type SetExchangeRateDTO struct {
+	Base  string `json:"base" validate:"required,len=3"`
+	Quote string `json:"quote" validate:"required,len=3"`
+	Rate  string `json:"rate" validate:"required"` // e.g. "16250.00"
+}
@@ -0,0 +1,47 @@
+package entity
+
+import (
+	"time"
+	"umrah-backend/pkg/money"
+
+	"github.com/google/uuid"
+)
+
+type DocumentType string
+type DocumentReference string
+
+const (
+	DocInvoice DocumentType = "INVOICE"
+	DocReceipt DocumentType = "RECEIPT"
+
+	DocRefOrder       DocumentReference = "ORDER"
+	DocRefBooking     DocumentReference = "BOOKING"
+	DocRefInstallment DocumentReference = "INSTALLMENT"
+)
+
+// Document is an issued invoice or receipt. The PDF is rendered once and
+// kept on disk; the number is never reused, even if the order is cancelled.
+type Document struct {
+	ID     uuid.UUID    `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Type   DocumentType `gorm:"type:varchar(10);not null;uniqueIndex:idx_document_ref" json:"type"`
+	Number string       `gorm:"type:varchar(30);not null;uniqueIndex" json:"number"` // e.g. INV-2026-000042
+
+	// What the document is for. One invoice/receipt per reference.
+	ReferenceType DocumentReference `gorm:"type:varchar(20);not null;uniqueIndex:idx_document_ref" json:"reference_type"`
+	ReferenceID   uuid.UUID         `gorm:"type:uuid;not null;uniqueIndex:idx_document_ref" json:"reference_id"`
+	// Installment receipts also point at their booking, so they list with it
+	BookingID *uuid.UUID `gorm:"type:uuid;index" json:"booking_id,omitempty"`
+
+	UserID   uuid.UUID   `gorm:"type:uuid;not null;index" json:"user_id"`
+	Amount   money.Money `gorm:"embedded;embeddedPrefix:amount_" json:"amount"`
+	FilePath string      `gorm:"type:text;not null" json:"-"`
+	IssuedAt time.Time   `json:"issued_at"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DocumentSequence hands out gap-free numbers per prefix ("INV-2026").
+type DocumentSequence struct {
+	Prefix    string `gorm:"type:varchar(20);primaryKey"`
+	LastValue int64  `gorm:"not null;default:0"`
+}
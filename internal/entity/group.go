@@ -16,6 +16,7 @@ type Group struct {
 	JoinCode   string         `gorm:"size:10;uniqueIndex;not null" json:"join_code"` // e.g. "UMROH-2025"
 	StartDate  time.Time      `json:"start_date"`
 	EndDate    time.Time      `json:"end_date"`
+	PackageID  *uuid.UUID     `gorm:"type:uuid;uniqueIndex" json:"package_id,omitempty"` // Set when the group was created from a TravelPackage
 	CreatedAt  time.Time      `json:"created_at"`
 	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
 }
@@ -40,3 +41,8 @@ type CreateGroupDTO struct {
 type JoinGroupDTO struct {
 	JoinCode string `json:"join_code" validate:"required"`
 }
+
+type CreatePackageGroupDTO struct {
+	Name       string `json:"name"`                                  // Optional, defaults to the package name
+	MutawwifID string `json:"mutawwif_id" validate:"omitempty,uuid"` // Optional, defaults to the caller
+}
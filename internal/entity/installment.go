@@ -0,0 +1,62 @@
+package entity
+
+import (
+	"time"
+	"umrah-backend/pkg/money"
+
+	"github.com/google/uuid"
+)
+
+type InstallmentKind string
+type InstallmentStatus string
+
+const (
+	KindFullPayment InstallmentKind = "FULL_PAYMENT" // Plan without cicilan
+	KindDownPayment InstallmentKind = "DOWN_PAYMENT" // DP, secures the seat
+	KindInstallment InstallmentKind = "INSTALLMENT"  // Cicilan ke-N
+
+	InstallmentUnpaid    InstallmentStatus = "UNPAID"
+	InstallmentSubmitted InstallmentStatus = "SUBMITTED" // Proof uploaded, waiting verification
+	InstallmentPaid      InstallmentStatus = "PAID"      // Admin verified proof
+	InstallmentRefunded  InstallmentStatus = "REFUNDED"  // Gateway payment returned to the customer
+)
+
+// BookingInstallment is one line of a booking's payment schedule.
+type BookingInstallment struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	BookingID uuid.UUID `gorm:"type:uuid;not null;index" json:"booking_id"`
+	Sequence  int       `json:"sequence"` // 1 = DP / full payment
+
+	Kind    InstallmentKind   `gorm:"type:varchar(20);not null" json:"kind"`
+	Amount  money.Money       `gorm:"embedded;embeddedPrefix:amount_" json:"amount"`
+	DueDate time.Time         `json:"due_date"`
+	Status  InstallmentStatus `gorm:"type:varchar(20);default:'UNPAID'" json:"status"`
+
+	// URL to the uploaded transfer proof image
+	ProofImage string     `gorm:"type:text" json:"proof_image"`
+	PaidAt     *time.Time `json:"paid_at,omitempty"`
+	VerifiedBy *uuid.UUID `gorm:"type:uuid" json:"verified_by,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// BookingBalance is the payment summary of one booking.
+type BookingBalance struct {
+	BookingID    uuid.UUID            `json:"booking_id"`
+	Status       BookingStatus        `json:"status"`
+	TotalPrice   money.Money          `json:"total_price"`
+	Paid         money.Money          `json:"paid"`
+	Outstanding  money.Money          `json:"outstanding"`
+	NextDue      *BookingInstallment  `json:"next_due,omitempty"`
+	Installments []BookingInstallment `json:"installments"`
+}
+
+// --- REQUEST DTOs ---
+
+// CreatePaymentPlanDTO: Installments = 0 means a single full payment.
+// DownPayment is in minor units of the booking currency.
+type CreatePaymentPlanDTO struct {
+	DownPayment  int64 `json:"down_payment" validate:"gte=0"`
+	Installments int   `json:"installments" validate:"gte=0,lte=24"`
+}
@@ -22,6 +22,9 @@ type Itinerary struct {
 	StartTime time.Time `json:"start_time"`
 	EndTime   time.Time `json:"end_time"`
 
+	// Set when generated from the package's template rundown
+	TemplateItemID *uuid.UUID `gorm:"type:uuid;index" json:"template_item_id,omitempty"`
+
 	CreatedAt time.Time      `json:"created_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 }
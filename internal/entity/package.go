@@ -2,6 +2,7 @@ package entity
 
 import (
 	"time"
+	"umrah-backend/pkg/money"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -10,6 +11,8 @@ import (
 // --- ENUMS ---
 type PackageCategory string
 type AirlineClass string
+type BookingStatus string
+type Gender string
 
 const (
 	// Categories
@@ -23,8 +26,47 @@ const (
 	ClassEconomy  AirlineClass = "ECONOMY"
 	ClassBusiness AirlineClass = "BUSINESS"
 	ClassFirst    AirlineClass = "FIRST"
+
+	// Booking Lifecycle
+	BookingPending   BookingStatus = "PENDING"   // Seats held, waiting for payment
+	BookingPaid      BookingStatus = "PAID"      // Payment received, waiting for admin review
+	BookingConfirmed BookingStatus = "CONFIRMED" // Admin approved, seat is final
+	BookingCancelled BookingStatus = "CANCELLED" // Seats returned to the package
+	BookingRefunded  BookingStatus = "REFUNDED"  // Money returned to the customer
+	BookingExpired   BookingStatus = "EXPIRED"   // Payment hold ran out, seats returned
 )
 
+// Passenger Gender (as written in the passport)
+const (
+	GenderMale   Gender = "MALE"
+	GenderFemale Gender = "FEMALE"
+)
+
+// bookingTransitions lists every legal move of the booking state machine.
+var bookingTransitions = map[BookingStatus][]BookingStatus{
+	BookingPending:   {BookingPaid, BookingCancelled, BookingExpired},
+	BookingPaid:      {BookingConfirmed, BookingCancelled, BookingRefunded},
+	BookingConfirmed: {BookingCancelled, BookingRefunded},
+	BookingCancelled: {BookingRefunded},
+	BookingRefunded:  {},
+	BookingExpired:   {},
+}
+
+// CanTransitionTo reports whether a booking in status s may move to next.
+func (s BookingStatus) CanTransitionTo(next BookingStatus) bool {
+	for _, allowed := range bookingTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// HoldsSeats reports whether a booking in status s still occupies package quota.
+func (s BookingStatus) HoldsSeats() bool {
+	return s == BookingPending || s == BookingPaid || s == BookingConfirmed
+}
+
 // --- TRAVEL PACKAGES ---
 type TravelPackage struct {
 	ID          uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
@@ -46,10 +88,13 @@ type TravelPackage struct {
 	AirlineName  string       `gorm:"type:varchar(100)" json:"airline_name"`
 	AirlineClass AirlineClass `gorm:"type:varchar(20);default:'ECONOMY'" json:"airline_class"`
 
-	// 4. PRICING TIERS (Per Pax)
-	PriceQuad   float64 `json:"price_quad"` // Cheapest (4 pax/room)
-	PriceTriple float64 `json:"price_triple"`
-	PriceDouble float64 `json:"price_double"` // Most Expensive (2 pax/room)
+	// 4. PRICING TIERS (Per Pax, all tiers in the same currency)
+	PriceQuad   money.Money `gorm:"embedded;embeddedPrefix:price_quad_" json:"price_quad"` // Cheapest (4 pax/room)
+	PriceTriple money.Money `gorm:"embedded;embeddedPrefix:price_triple_" json:"price_triple"`
+	PriceDouble money.Money `gorm:"embedded;embeddedPrefix:price_double_" json:"price_double"` // Most Expensive (2 pax/room)
+
+	// Prices converted to the requester's currency (?currency=USD), not stored
+	DisplayPrices map[string]money.Money `gorm:"-" json:"display_prices,omitempty"`
 
 	// 5. SCHEDULE
 	DurationDays  int       `json:"duration_days"`
@@ -60,7 +105,26 @@ type TravelPackage struct {
 	// 6. INVENTORY
 	Quota     int  `json:"quota"`
 	Available int  `json:"available"`
-	IsActive  bool `gorm:"default:true" json:"is_active"`
+	IsActive  bool `gorm:"default:true" json:"is_active"` // Listed and bookable
+
+	// Scheduled listing changes, applied (and cleared) by the sweeper
+	PublishAt   *time.Time `gorm:"index" json:"publish_at,omitempty"`
+	UnpublishAt *time.Time `gorm:"index" json:"unpublish_at,omitempty"`
+
+	// Archived packages are retired for good: unlisted and no longer editable
+	ArchivedAt *time.Time `gorm:"index" json:"archived_at,omitempty"`
+
+	// How long a PENDING booking keeps its seats before it expires unpaid
+	PaymentHoldHours int `gorm:"default:24" json:"payment_hold_hours"`
+
+	// How long a waitlist offer holds the freed seats before passing them on
+	WaitlistClaimHours int `gorm:"default:12" json:"waitlist_claim_hours"`
+
+	// 7. CONTENT (loaded by the package detail only)
+	ItineraryDays []PackageItineraryDay `gorm:"foreignKey:PackageID" json:"itinerary_days,omitempty"`
+	Inclusions    []PackageInclusion    `gorm:"foreignKey:PackageID" json:"inclusions,omitempty"`
+	Flights       []PackageFlight       `gorm:"foreignKey:PackageID" json:"flights,omitempty"`
+	Hotels        []PackageHotel        `gorm:"foreignKey:PackageID" json:"hotels,omitempty"`
 
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
@@ -76,13 +140,211 @@ type Booking struct {
 	PackageID uuid.UUID      `gorm:"type:uuid;not null;index" json:"package_id"`
 	Package   *TravelPackage `gorm:"foreignKey:PackageID" json:"package,omitempty"`
 
-	PaxCount   int     `json:"pax_count"`
-	RoomType   string  `json:"room_type"` // "QUAD", "TRIPLE", "DOUBLE"
-	TotalPrice float64 `json:"total_price"`
+	// Terms the booking was sold under (nil for bookings older than versioning)
+	PackageVersionID *uuid.UUID      `gorm:"type:uuid;index" json:"package_version_id,omitempty"`
+	PackageVersion   *PackageVersion `gorm:"foreignKey:PackageVersionID" json:"package_version,omitempty"`
+
+	PaxCount   int         `json:"pax_count"`
+	RoomType   string      `json:"room_type"` // "QUAD", "TRIPLE", "DOUBLE"
+	TotalPrice money.Money `gorm:"embedded;embeddedPrefix:total_price_" json:"total_price"`
 
-	Status string `gorm:"type:varchar(20);default:'PENDING'" json:"status"` // PENDING, PAID, CONFIRMED
-	Notes  string `gorm:"type:text" json:"notes"`
+	// Promo code applied at booking time; Discount is already taken off TotalPrice
+	PromoCode string      `gorm:"type:varchar(40)" json:"promo_code,omitempty"`
+	Discount  money.Money `gorm:"embedded;embeddedPrefix:discount_" json:"discount"`
+
+	// Agent who referred the booking (via its referral code), earns commission
+	AgentID      *uuid.UUID `gorm:"type:uuid;index" json:"agent_id,omitempty"`
+	ReferralCode string     `gorm:"type:varchar(20)" json:"referral_code,omitempty"`
+
+	Status BookingStatus `gorm:"type:varchar(20);default:'PENDING'" json:"status"`
+	Notes  string        `gorm:"type:text" json:"notes"`
+
+	// Unpaid PENDING bookings are expired by the sweeper after this time
+	ExpiresAt *time.Time `gorm:"index" json:"expires_at,omitempty"`
+	// First move to PAID; revenue date of a booking without a payment plan
+	PaidAt *time.Time `json:"paid_at,omitempty"`
+
+	// Pilgrim identities, required for visa processing
+	Passengers []BookingPassenger `gorm:"foreignKey:BookingID" json:"passengers,omitempty"`
+
+	// Payment schedule (DP + cicilan, or a single full payment)
+	Installments []BookingInstallment `gorm:"foreignKey:BookingID" json:"installments,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// --- BOOKING PASSENGER (Manifest) ---
+type BookingPassenger struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	BookingID uuid.UUID `gorm:"type:uuid;not null;index" json:"booking_id"`
+	Sequence  int       `json:"sequence"` // 1 = lead passenger
+
+	FullName       string    `gorm:"type:varchar(100);not null" json:"full_name"` // As written in passport
+	PassportNumber string    `gorm:"type:varchar(20);not null" json:"passport_number"`
+	PassportExpiry time.Time `json:"passport_expiry"`
+	BirthDate      time.Time `json:"birth_date"`
+	Gender         Gender    `gorm:"type:varchar(10);not null" json:"gender"`
+
+	// Mahram: the male relative travelling with this pilgrim (if any)
+	MahramRelation string `gorm:"type:varchar(20)" json:"mahram_relation,omitempty"` // HUSBAND, FATHER, SON, BROTHER, ...
+	MahramName     string `gorm:"type:varchar(100)" json:"mahram_name,omitempty"`
 
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
+
+// PackageSearch is the filter of the public package list (GET /packages).
+// Zero values mean "no filter".
+type PackageSearch struct {
+	Query    string // Every word must appear in the name, description, tags, sub category, hotels or airline
+	Category string
+
+	// Per-pax QUAD price (the cheapest tier) in minor units of PriceCurrency;
+	// packages priced in another currency are left out when a bound is set.
+	MinPrice      *int64
+	MaxPrice      *int64
+	PriceCurrency money.Currency
+
+	DepartureFrom    *time.Time // Inclusive
+	DepartureTo      *time.Time // Exclusive
+	DepartureCity    string     // Case-insensitive
+	MinDuration      int        // Days
+	MaxDuration      int
+	MinRatingMakkah  int // Stars
+	MinRatingMadinah int
+
+	// Admin only: "" (listed packages), "inactive", "scheduled", "archived" or "all"
+	Status string
+
+	Sort  string // "" (departure, best match first when searching), "price_asc", "price_desc", "duration_asc", "duration_desc", "newest"
+	Page  int
+	Limit int
+}
+
+// FacetCount: how many packages of the search have this value.
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// PriceRange: cheapest and most expensive QUAD price in one currency.
+type PriceRange struct {
+	Min money.Money `json:"min"`
+	Max money.Money `json:"max"`
+}
+
+// PackageFacets are counted over the search with every filter applied
+// except the facet's own, so clients can offer the other values too.
+type PackageFacets struct {
+	Categories      []FacetCount `json:"categories"`
+	DepartureCities []FacetCount `json:"departure_cities"`
+	DepartureMonths []FacetCount `json:"departure_months"` // YYYY-MM
+	Durations       []FacetCount `json:"durations"`        // Days
+	RatingsMakkah   []FacetCount `json:"ratings_makkah"`
+	RatingsMadinah  []FacetCount `json:"ratings_madinah"`
+	Airlines        []FacetCount `json:"airlines"`
+	PriceRanges     []PriceRange `json:"price_ranges"` // One per currency
+}
+
+// PackageSearchResult is the response of GET /packages (not stored).
+type PackageSearchResult struct {
+	Data   []TravelPackage `json:"data"`
+	Total  int64           `json:"total"`
+	Page   int             `json:"page"`
+	Limit  int             `json:"limit"`
+	Facets *PackageFacets  `json:"facets"`
+}
+
+// --- REQUEST DTOs ---
+
+// BookingFilter is used by the admin booking list, export and manifest screens.
+type BookingFilter struct {
+	PackageID     string
+	UserID        string
+	AgentID       string // Bookings referred by this agent
+	Status        BookingStatus
+	DepartureFrom *time.Time
+	DepartureTo   *time.Time
+
+	Page  int
+	Limit int // 0 = no limit (export)
+}
+
+type BookPackageDTO struct {
+	PackageID    string `json:"package_id"`
+	RoomType     string `json:"room_type"`
+	PaxCount     int    `json:"pax_count"`
+	PromoCode    string `json:"promo_code"`    // Optional
+	ReferralCode string `json:"referral_code"` // Optional, the agent's code
+}
+
+// UpdatePackageDTO: nil fields are left unchanged. Content (itinerary,
+// inclusions, flights, hotels) has its own endpoints.
+type UpdatePackageDTO struct {
+	Name        *string          `json:"name" validate:"omitempty,min=3,max=255"`
+	Description *string          `json:"description"`
+	Category    *PackageCategory `json:"category" validate:"omitempty,oneof=UMRAH_REGULER UMRAH_PLUS HAJJ_FURODA HAJJ_PLUS UMRAH_RAMADHAN"`
+	SubCategory *string          `json:"sub_category" validate:"omitempty,max=100"`
+	Tags        *string          `json:"tags" validate:"omitempty,max=255"`
+
+	HotelMakkah   *string       `json:"hotel_makkah" validate:"omitempty,max=100"`
+	RatingMakkah  *int          `json:"rating_makkah" validate:"omitempty,min=1,max=5"`
+	HotelMadinah  *string       `json:"hotel_madinah" validate:"omitempty,max=100"`
+	RatingMadinah *int          `json:"rating_madinah" validate:"omitempty,min=1,max=5"`
+	AirlineName   *string       `json:"airline_name" validate:"omitempty,max=100"`
+	AirlineClass  *AirlineClass `json:"airline_class" validate:"omitempty,oneof=ECONOMY BUSINESS FIRST"`
+
+	PriceQuad   *PriceUpdateDTO `json:"price_quad"`
+	PriceTriple *PriceUpdateDTO `json:"price_triple"`
+	PriceDouble *PriceUpdateDTO `json:"price_double"`
+
+	DurationDays  *int       `json:"duration_days" validate:"omitempty,min=1"`
+	DepartureDate *time.Time `json:"departure_date"`
+	ReturnDate    *time.Time `json:"return_date"`
+	DepartureCity *string    `json:"departure_city" validate:"omitempty,max=50"`
+
+	// Total seats; the booked seats are kept, so Available moves by the same amount
+	Quota              *int `json:"quota" validate:"omitempty,min=0"`
+	PaymentHoldHours   *int `json:"payment_hold_hours" validate:"omitempty,min=1"`
+	WaitlistClaimHours *int `json:"waitlist_claim_hours" validate:"omitempty,min=1"`
+
+	IsActive *bool `json:"is_active"` // Publish (true) or unpublish (false) now
+
+	// RFC3339, e.g. "2026-12-01T00:00:00+07:00"; "" clears the schedule
+	PublishAt   *string `json:"publish_at"`
+	UnpublishAt *string `json:"unpublish_at"`
+}
+
+// PriceUpdateDTO is a tier price in UpdatePackageDTO. Unlike money.Money it
+// keeps a missing currency empty, so the tier keeps the package currency.
+type PriceUpdateDTO struct {
+	Amount   int64          `json:"amount"`
+	Currency money.Currency `json:"currency"`
+}
+
+// ClonePackageDTO copies a package, its prices and content to a new
+// departure. Flight and hotel dates move with the departure date.
+type ClonePackageDTO struct {
+	DepartureDate time.Time `json:"departure_date" validate:"required"`
+	Name          *string   `json:"name" validate:"omitempty,min=3,max=255"` // Default: the source's name
+	Quota         *int      `json:"quota" validate:"omitempty,min=0"`        // Default: the source's quota
+
+	// The clone stays unlisted until published; RFC3339, optional
+	PublishAt   string `json:"publish_at"`
+	UnpublishAt string `json:"unpublish_at"`
+}
+
+type PassengerDTO struct {
+	FullName       string `json:"full_name" validate:"required,min=3,max=100"`
+	PassportNumber string `json:"passport_number" validate:"required,alphanum,min=6,max=20"`
+	PassportExpiry string `json:"passport_expiry" validate:"required"` // Format: YYYY-MM-DD
+	BirthDate      string `json:"birth_date" validate:"required"`      // Format: YYYY-MM-DD
+	Gender         Gender `json:"gender" validate:"required,oneof=MALE FEMALE"`
+	MahramRelation string `json:"mahram_relation" validate:"omitempty,oneof=HUSBAND FATHER SON BROTHER GRANDFATHER GRANDSON UNCLE NEPHEW FATHER_IN_LAW SON_IN_LAW"`
+	MahramName     string `json:"mahram_name" validate:"required_with=MahramRelation,max=100"`
+}
+
+type UpdatePassengersDTO struct {
+	Passengers []PassengerDTO `json:"passengers" validate:"required,min=1,dive"`
+}
@@ -0,0 +1,145 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type InclusionKind string
+type FlightDirection string
+
+const (
+	Included InclusionKind = "INCLUDED"
+	Excluded InclusionKind = "EXCLUDED"
+
+	FlightDeparture FlightDirection = "DEPARTURE" // Home -> Saudi Arabia
+	FlightInternal  FlightDirection = "INTERNAL"  // e.g. Madinah -> Jeddah, or a transit leg
+	FlightReturn    FlightDirection = "RETURN"    // Saudi Arabia -> home
+
+	// DefaultItineraryTimezone: rundown clock times are local Saudi time
+	// unless the day says otherwise (e.g. day 1 at the home airport).
+	DefaultItineraryTimezone = "Asia/Riyadh"
+)
+
+// PackageItineraryDay is one day of the package's template rundown. The
+// rundown of the package's group is generated from it.
+type PackageItineraryDay struct {
+	ID          uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	PackageID   uuid.UUID `gorm:"type:uuid;not null;index" json:"package_id"`
+	DayNumber   int       `gorm:"not null" json:"day_number"` // 1 = departure day
+	Title       string    `gorm:"type:varchar(255);not null" json:"title"`
+	Description string    `gorm:"type:text" json:"description"`
+	City        string    `gorm:"type:varchar(50)" json:"city"`     // e.g. "Madinah"
+	Timezone    string    `gorm:"type:varchar(50)" json:"timezone"` // IANA name, of the item clock times
+
+	Items []PackageItineraryItem `gorm:"foreignKey:DayID" json:"items"`
+}
+
+type PackageItineraryItem struct {
+	ID          uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	DayID       uuid.UUID `gorm:"type:uuid;not null;index" json:"-"`
+	Sequence    int       `json:"sequence"`
+	StartTime   string    `gorm:"type:varchar(5);not null" json:"start_time"` // "HH:MM"
+	EndTime     string    `gorm:"type:varchar(5)" json:"end_time"`            // "HH:MM", empty = same as start
+	Title       string    `gorm:"type:varchar(255);not null" json:"title"`
+	Description string    `gorm:"type:text" json:"description"`
+	Location    string    `gorm:"type:varchar(255)" json:"location"`
+	Latitude    float64   `json:"latitude"`
+	Longitude   float64   `json:"longitude"`
+}
+
+// PackageInclusion: one line of the "what's included / not included" list.
+type PackageInclusion struct {
+	ID        uuid.UUID     `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	PackageID uuid.UUID     `gorm:"type:uuid;not null;index" json:"package_id"`
+	Kind      InclusionKind `gorm:"type:varchar(10);not null" json:"kind"`
+	Text      string        `gorm:"type:varchar(255);not null" json:"text"` // e.g. "Visa umrah", "Vaksin meningitis"
+	Sequence  int           `json:"sequence"`
+}
+
+type PackageFlight struct {
+	ID           uuid.UUID       `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	PackageID    uuid.UUID       `gorm:"type:uuid;not null;index" json:"package_id"`
+	Sequence     int             `json:"sequence"`
+	Direction    FlightDirection `gorm:"type:varchar(10);not null" json:"direction"`
+	Airline      string          `gorm:"type:varchar(100)" json:"airline"`
+	FlightNumber string          `gorm:"type:varchar(10)" json:"flight_number"`        // e.g. "SV817"
+	FromAirport  string          `gorm:"type:varchar(3);not null" json:"from_airport"` // IATA, e.g. "CGK"
+	ToAirport    string          `gorm:"type:varchar(3);not null" json:"to_airport"`
+	DepartAt     time.Time       `json:"depart_at"`
+	ArriveAt     time.Time       `json:"arrive_at"`
+}
+
+type PackageHotel struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	PackageID uuid.UUID `gorm:"type:uuid;not null;index" json:"package_id"`
+	Sequence  int       `json:"sequence"`
+	City      string    `gorm:"type:varchar(50);not null" json:"city"`
+	Name      string    `gorm:"type:varchar(100);not null" json:"name"`
+	Rating    int       `json:"rating"` // 1-5 Star
+	CheckIn   time.Time `gorm:"type:date" json:"check_in"`
+	CheckOut  time.Time `gorm:"type:date" json:"check_out"`
+	Nights    int       `json:"nights"`
+}
+
+// --- REQUEST DTOs ---
+
+type ItineraryItemDTO struct {
+	StartTime   string  `json:"start_time" validate:"required,len=5"`
+	EndTime     string  `json:"end_time" validate:"omitempty,len=5"`
+	Title       string  `json:"title" validate:"required,max=255"`
+	Description string  `json:"description"`
+	Location    string  `json:"location" validate:"max=255"`
+	Latitude    float64 `json:"latitude" validate:"min=-90,max=90"`
+	Longitude   float64 `json:"longitude" validate:"min=-180,max=180"`
+}
+
+type ItineraryDayDTO struct {
+	DayNumber   int                `json:"day_number" validate:"required,min=1"`
+	Title       string             `json:"title" validate:"required,max=255"`
+	Description string             `json:"description"`
+	City        string             `json:"city" validate:"max=50"`
+	Timezone    string             `json:"timezone" validate:"max=50"` // Default Asia/Riyadh
+	Items       []ItineraryItemDTO `json:"items" validate:"dive"`
+}
+
+// SetItineraryDTO replaces the whole template rundown of a package.
+type SetItineraryDTO struct {
+	Days []ItineraryDayDTO `json:"days" validate:"dive"`
+}
+
+type InclusionDTO struct {
+	Kind InclusionKind `json:"kind" validate:"required,oneof=INCLUDED EXCLUDED"`
+	Text string        `json:"text" validate:"required,max=255"`
+}
+
+type SetInclusionsDTO struct {
+	Items []InclusionDTO `json:"items" validate:"dive"`
+}
+
+type FlightDTO struct {
+	Direction    FlightDirection `json:"direction" validate:"required,oneof=DEPARTURE INTERNAL RETURN"`
+	Airline      string          `json:"airline" validate:"max=100"`
+	FlightNumber string          `json:"flight_number" validate:"max=10"`
+	FromAirport  string          `json:"from_airport" validate:"required,len=3,alpha"`
+	ToAirport    string          `json:"to_airport" validate:"required,len=3,alpha"`
+	DepartAt     string          `json:"depart_at" validate:"required"` // RFC3339 with the local offset
+	ArriveAt     string          `json:"arrive_at" validate:"required"`
+}
+
+type SetFlightsDTO struct {
+	Flights []FlightDTO `json:"flights" validate:"dive"`
+}
+
+type HotelDTO struct {
+	City     string `json:"city" validate:"required,max=50"`
+	Name     string `json:"name" validate:"required,max=100"`
+	Rating   int    `json:"rating" validate:"omitempty,min=1,max=5"`
+	CheckIn  string `json:"check_in" validate:"required"` // YYYY-MM-DD
+	CheckOut string `json:"check_out" validate:"required"`
+}
+
+type SetHotelsDTO struct {
+	Hotels []HotelDTO `json:"hotels" validate:"dive"`
+}
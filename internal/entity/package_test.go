@@ -0,0 +1,86 @@
+package entity
+
+import "testing"
+
+var allBookingStatuses = []BookingStatus{
+	BookingPending,
+	BookingPaid,
+	BookingConfirmed,
+	BookingCancelled,
+	BookingRefunded,
+	BookingExpired,
+}
+
+// TestBookingCanTransitionTo checks every (from, to) pair: the moves listed
+// here are allowed, every other one is forbidden.
+func TestBookingCanTransitionTo(t *testing.T) {
+	allowed := map[BookingStatus][]BookingStatus{
+		BookingPending:   {BookingPaid, BookingCancelled, BookingExpired},
+		BookingPaid:      {BookingConfirmed, BookingCancelled, BookingRefunded},
+		BookingConfirmed: {BookingCancelled, BookingRefunded},
+		BookingCancelled: {BookingRefunded},
+		BookingRefunded:  {},
+		BookingExpired:   {},
+	}
+
+	for _, from := range allBookingStatuses {
+		want := make(map[BookingStatus]bool)
+		for _, to := range allowed[from] {
+			want[to] = true
+		}
+		for _, to := range allBookingStatuses {
+			t.Run(string(from)+"->"+string(to), func(t *testing.T) {
+				if got := from.CanTransitionTo(to); got != want[to] {
+					t.Errorf("%s.CanTransitionTo(%s) = %v, want %v", from, to, got, want[to])
+				}
+			})
+		}
+	}
+}
+
+// Every status must appear in the table, even terminal ones, so a new
+// status cannot be added without deciding where it may go.
+func TestBookingTransitionsCoverEveryStatus(t *testing.T) {
+	if len(bookingTransitions) != len(allBookingStatuses) {
+		t.Errorf("bookingTransitions has %d statuses, want %d", len(bookingTransitions), len(allBookingStatuses))
+	}
+	for _, s := range allBookingStatuses {
+		if _, ok := bookingTransitions[s]; !ok {
+			t.Errorf("%s is missing from bookingTransitions", s)
+		}
+	}
+	if BookingStatus("UNKNOWN").CanTransitionTo(BookingPaid) {
+		t.Error("an unknown status must not transition")
+	}
+}
+
+func TestBookingHoldsSeats(t *testing.T) {
+	holds := map[BookingStatus]bool{
+		BookingPending:   true,
+		BookingPaid:      true,
+		BookingConfirmed: true,
+		BookingCancelled: false,
+		BookingRefunded:  false,
+		BookingExpired:   false,
+	}
+	for _, s := range allBookingStatuses {
+		if got := s.HoldsSeats(); got != holds[s] {
+			t.Errorf("%s.HoldsSeats() = %v, want %v", s, got, holds[s])
+		}
+	}
+	if BookingStatus("UNKNOWN").HoldsSeats() {
+		t.Error("an unknown status must not hold seats")
+	}
+}
+
+// A move that releases seats must come from a status that holds them, or
+// the quota would be returned twice.
+func TestBookingTransitionsReleaseSeatsOnce(t *testing.T) {
+	for from, next := range bookingTransitions {
+		for _, to := range next {
+			if !from.HoldsSeats() && to.HoldsSeats() {
+				t.Errorf("%s -> %s takes seats back after they were released", from, to)
+			}
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+	"umrah-backend/pkg/money"
+
+	"github.com/google/uuid"
+)
+
+// PackageVersion is an immutable snapshot of the terms a package was sold
+// under. A new version is recorded whenever the terms change; every booking
+// points at the version in force when it was placed.
+type PackageVersion struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	PackageID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_package_version" json:"package_id"`
+	Version   int       `gorm:"not null;uniqueIndex:idx_package_version" json:"version"` // 1, 2, 3, ...
+
+	PackageTerms `gorm:"embedded"`
+
+	// SHA-256 of the terms, to tell whether they changed since the last version
+	TermsHash string `gorm:"type:varchar(64);not null" json:"-"`
+	Reason    string `gorm:"type:varchar(50)" json:"reason"` // What recorded the version, e.g. "hotels updated"
+
+	// Differences to the previous version (history only, not stored)
+	Changes []string `gorm:"-" json:"changes,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PackageTerms: what the customer is promised when booking a package.
+type PackageTerms struct {
+	Name        string          `gorm:"type:varchar(255);not null" json:"name"`
+	Category    PackageCategory `gorm:"type:varchar(50)" json:"category"`
+	SubCategory string          `gorm:"type:varchar(100)" json:"sub_category"`
+
+	HotelMakkah   string `gorm:"type:varchar(100)" json:"hotel_makkah"`
+	RatingMakkah  int    `json:"rating_makkah"`
+	HotelMadinah  string `gorm:"type:varchar(100)" json:"hotel_madinah"`
+	RatingMadinah int    `json:"rating_madinah"`
+
+	AirlineName  string       `gorm:"type:varchar(100)" json:"airline_name"`
+	AirlineClass AirlineClass `gorm:"type:varchar(20)" json:"airline_class"`
+
+	PriceQuad   money.Money `gorm:"embedded;embeddedPrefix:price_quad_" json:"price_quad"`
+	PriceTriple money.Money `gorm:"embedded;embeddedPrefix:price_triple_" json:"price_triple"`
+	PriceDouble money.Money `gorm:"embedded;embeddedPrefix:price_double_" json:"price_double"`
+
+	DurationDays  int       `json:"duration_days"`
+	DepartureDate time.Time `json:"departure_date"`
+	ReturnDate    time.Time `json:"return_date"`
+	DepartureCity string    `gorm:"type:varchar(50)" json:"departure_city"`
+
+	Hotels  VersionHotels  `gorm:"type:jsonb" json:"hotels"`
+	Flights VersionFlights `gorm:"type:jsonb" json:"flights"`
+}
+
+type VersionHotel struct {
+	City     string    `json:"city"`
+	Name     string    `json:"name"`
+	Rating   int       `json:"rating"`
+	CheckIn  time.Time `json:"check_in"`
+	CheckOut time.Time `json:"check_out"`
+	Nights   int       `json:"nights"`
+}
+
+type VersionFlight struct {
+	Direction    FlightDirection `json:"direction"`
+	Airline      string          `json:"airline"`
+	FlightNumber string          `json:"flight_number"`
+	FromAirport  string          `json:"from_airport"`
+	ToAirport    string          `json:"to_airport"`
+	DepartAt     time.Time       `json:"depart_at"`
+	ArriveAt     time.Time       `json:"arrive_at"`
+}
+
+// VersionHotels and VersionFlights are stored as JSON: a version is
+// always read whole and never queried by hotel or flight.
+type VersionHotels []VersionHotel
+type VersionFlights []VersionFlight
+
+func (h VersionHotels) Value() (driver.Value, error)  { return jsonValue(h) }
+func (h *VersionHotels) Scan(src interface{}) error   { return jsonScan(src, h) }
+func (f VersionFlights) Value() (driver.Value, error) { return jsonValue(f) }
+func (f *VersionFlights) Scan(src interface{}) error  { return jsonScan(src, f) }
+
+func jsonValue(v interface{}) (driver.Value, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func jsonScan(src interface{}, dst interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, dst)
+	case string:
+		return json.Unmarshal([]byte(v), dst)
+	default:
+		return fmt.Errorf("cannot scan %T into %T", src, dst)
+	}
+}
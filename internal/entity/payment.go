@@ -0,0 +1,73 @@
+package entity
+
+import (
+	"time"
+	"umrah-backend/pkg/money"
+
+	"github.com/google/uuid"
+)
+
+type PaymentStatus string
+type PaymentReference string
+
+const (
+	PaymentPending   PaymentStatus = "PENDING" // Charge created, waiting for the customer
+	PaymentPaid      PaymentStatus = "PAID"
+	PaymentExpired   PaymentStatus = "EXPIRED"
+	PaymentFailed    PaymentStatus = "FAILED"
+	PaymentRefunded  PaymentStatus = "REFUNDED"
+	PaymentCancelled PaymentStatus = "CANCELLED" // Replaced by a newer charge before it was paid
+	// Money received but not applied (already paid by another charge, or the
+	// order/booking closed meanwhile). An admin has to refund it.
+	PaymentNeedsRefund PaymentStatus = "NEEDS_REFUND"
+
+	// What a gateway payment settles
+	RefOrder       PaymentReference = "ORDER"
+	RefInstallment PaymentReference = "INSTALLMENT"
+)
+
+// PaymentTransaction is one charge opened at a payment gateway.
+type PaymentTransaction struct {
+	ID         uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID     uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	Provider   string    `gorm:"type:varchar(30);not null" json:"provider"`
+	ExternalID string    `gorm:"type:varchar(100);uniqueIndex" json:"external_id"`
+
+	ReferenceType PaymentReference `gorm:"type:varchar(20);not null;index:idx_payment_reference" json:"reference_type"`
+	ReferenceID   uuid.UUID        `gorm:"type:uuid;not null;index:idx_payment_reference" json:"reference_id"`
+
+	Method   string        `gorm:"type:varchar(10);not null" json:"method"` // VA, QRIS
+	Amount   money.Money   `gorm:"embedded;embeddedPrefix:amount_" json:"amount"`
+	Status   PaymentStatus `gorm:"type:varchar(20);default:'PENDING'" json:"status"`
+	Bank     string        `gorm:"type:varchar(20)" json:"bank,omitempty"`
+	VANumber string        `gorm:"type:varchar(50)" json:"va_number,omitempty"`
+	QRString string        `gorm:"type:text" json:"qr_string,omitempty"`
+
+	ExpiresAt  time.Time  `json:"expires_at"`
+	PaidAt     *time.Time `json:"paid_at,omitempty"`
+	RefundID   string     `gorm:"type:varchar(100)" json:"refund_id,omitempty"`
+	RefundedAt *time.Time `json:"refunded_at,omitempty"`
+	ReviewNote string     `gorm:"type:text" json:"review_note,omitempty"` // Why the payment (or its refund) was not applied, for an admin to follow up
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PaymentWebhookEvent logs every processed callback; the unique
+// (provider, event_id) pair makes redelivered callbacks a no-op.
+type PaymentWebhookEvent struct {
+	ID         uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Provider   string    `gorm:"type:varchar(30);not null;uniqueIndex:idx_webhook_event" json:"provider"`
+	EventID    string    `gorm:"type:varchar(100);not null;uniqueIndex:idx_webhook_event" json:"event_id"`
+	ExternalID string    `gorm:"type:varchar(100);index" json:"external_id"`
+	Status     string    `gorm:"type:varchar(20)" json:"status"`
+	Payload    string    `gorm:"type:text" json:"payload"`
+	Rejection  string    `gorm:"type:text" json:"rejection,omitempty"` // Why the callback was not applied, e.g. amount mismatch
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+// --- REQUEST DTOs ---
+type CreateChargeDTO struct {
+	Method string `json:"method" validate:"required,oneof=VA QRIS"`
+	Bank   string `json:"bank" validate:"omitempty,alphanum,max=20"`
+}
@@ -0,0 +1,162 @@
+package entity
+
+import (
+	"time"
+	"umrah-backend/pkg/money"
+
+	"github.com/google/uuid"
+)
+
+type PromoType string
+type PromoTarget string
+type PromoRestrictionKind string
+type RedemptionStatus string
+
+const (
+	PromoPercent PromoType = "PERCENT" // PercentBP of the eligible amount, optionally capped
+	PromoFixed   PromoType = "FIXED"   // Amount off, at most the eligible amount
+
+	PromoForAll      PromoTarget = "ALL"
+	PromoForOrders   PromoTarget = "ORDERS"
+	PromoForBookings PromoTarget = "BOOKINGS"
+
+	RestrictProduct         PromoRestrictionKind = "PRODUCT"          // Value: product ID
+	RestrictProductCategory PromoRestrictionKind = "PRODUCT_CATEGORY" // Value: product category ID
+	RestrictPackage         PromoRestrictionKind = "PACKAGE"          // Value: package ID
+	RestrictPackageCategory PromoRestrictionKind = "PACKAGE_CATEGORY" // Value: e.g. UMRAH_REGULER
+
+	RedemptionReserved RedemptionStatus = "RESERVED" // Counted, order/booking being placed
+	RedemptionApplied  RedemptionStatus = "APPLIED"  // Attached to an order/booking
+	RedemptionReleased RedemptionStatus = "RELEASED" // Order/booking died unpaid, use given back
+)
+
+// PromoCode is a discount campaign, e.g. "EARLYBIRD26" for early-bird
+// bookings of the next season.
+type PromoCode struct {
+	ID          uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Code        string    `gorm:"type:varchar(40);uniqueIndex;not null" json:"code"` // Stored upper case
+	Description string    `gorm:"type:text" json:"description"`
+
+	Type        PromoType   `gorm:"type:varchar(10);not null" json:"type"`
+	PercentBP   int64       `json:"percent_bp,omitempty"`                                      // PERCENT, basis points: 1000 = 10%
+	Amount      money.Money `gorm:"embedded;embeddedPrefix:amount_" json:"amount"`             // FIXED
+	MaxDiscount money.Money `gorm:"embedded;embeddedPrefix:max_discount_" json:"max_discount"` // PERCENT cap, 0 = no cap
+
+	// What the code can be used on. Without restrictions every product or
+	// package of the target counts; otherwise only the matching lines do.
+	Target       PromoTarget        `gorm:"type:varchar(10);default:'ALL'" json:"target"`
+	Restrictions []PromoRestriction `gorm:"foreignKey:PromoID" json:"restrictions"`
+
+	// Validity window; nil = no limit on that side
+	StartsAt *time.Time `json:"starts_at,omitempty"`
+	EndsAt   *time.Time `json:"ends_at,omitempty"`
+
+	// Usage limits, 0 = unlimited. UsedCount counts reserved and applied uses.
+	MaxUses        int  `gorm:"default:0" json:"max_uses"`
+	MaxUsesPerUser int  `gorm:"default:0" json:"max_uses_per_user"`
+	UsedCount      int  `gorm:"default:0" json:"used_count"`
+	IsActive       bool `json:"is_active"` // No column default, so inactive codes are saved as false
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// IsRunning reports whether the code is switched on and inside its window.
+func (p *PromoCode) IsRunning(now time.Time) bool {
+	if !p.IsActive {
+		return false
+	}
+	if p.StartsAt != nil && now.Before(*p.StartsAt) {
+		return false
+	}
+	if p.EndsAt != nil && !now.Before(*p.EndsAt) {
+		return false
+	}
+	return true
+}
+
+// AppliesTo reports whether the code may be used on orders or bookings.
+func (p *PromoCode) AppliesTo(target PromoTarget) bool {
+	return p.Target == PromoForAll || p.Target == target
+}
+
+type PromoRestriction struct {
+	ID      uuid.UUID            `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	PromoID uuid.UUID            `gorm:"type:uuid;not null;index" json:"-"`
+	Kind    PromoRestrictionKind `gorm:"type:varchar(20);not null" json:"kind"`
+	Value   string               `gorm:"type:varchar(100);not null" json:"value"`
+}
+
+// PromoRedemption is one use of a code by a user.
+type PromoRedemption struct {
+	ID      uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	PromoID uuid.UUID `gorm:"type:uuid;not null;index" json:"promo_id"`
+	UserID  uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+
+	// ORDER or BOOKING, set once the order/booking exists
+	ReferenceType DocumentReference `gorm:"type:varchar(20)" json:"reference_type,omitempty"`
+	ReferenceID   *uuid.UUID        `gorm:"type:uuid;index" json:"reference_id,omitempty"`
+
+	Discount money.Money      `gorm:"embedded;embeddedPrefix:discount_" json:"discount"`
+	Status   RedemptionStatus `gorm:"type:varchar(20);default:'RESERVED';index" json:"status"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PromoLine is one priced line a code is checked against (not stored).
+// Only the fields matching the line's kind are set.
+type PromoLine struct {
+	ProductID         *uuid.UUID
+	ProductCategoryID *uuid.UUID
+	PackageID         *uuid.UUID
+	PackageCategory   PackageCategory
+	Amount            money.Money
+}
+
+// PromoQuote previews the effect of a code (not stored).
+type PromoQuote struct {
+	Code     string      `json:"code"`
+	Subtotal money.Money `json:"subtotal"`
+	Discount money.Money `json:"discount"`
+	Total    money.Money `json:"total"`
+}
+
+// --- REQUEST DTOs ---
+
+type PromoRestrictionDTO struct {
+	Kind  PromoRestrictionKind `json:"kind" validate:"required,oneof=PRODUCT PRODUCT_CATEGORY PACKAGE PACKAGE_CATEGORY"`
+	Value string               `json:"value" validate:"required,max=100"`
+}
+
+// PromoCodeDTO creates or fully replaces a promo code.
+type PromoCodeDTO struct {
+	Code        string       `json:"code" validate:"required,alphanum,min=3,max=40"`
+	Description string       `json:"description" validate:"max=500"`
+	Type        PromoType    `json:"type" validate:"required,oneof=PERCENT FIXED"`
+	PercentBP   int64        `json:"percent_bp" validate:"required_if=Type PERCENT,omitempty,min=1,max=10000"`
+	Amount      *money.Money `json:"amount" validate:"required_if=Type FIXED"`
+	MaxDiscount *money.Money `json:"max_discount"`
+	Target      PromoTarget  `json:"target" validate:"omitempty,oneof=ALL ORDERS BOOKINGS"` // Default ALL
+
+	Restrictions []PromoRestrictionDTO `json:"restrictions" validate:"omitempty,dive"`
+
+	// RFC3339, e.g. "2026-11-01T00:00:00+07:00"; empty = no limit
+	StartsAt string `json:"starts_at"`
+	EndsAt   string `json:"ends_at"`
+
+	MaxUses        int   `json:"max_uses" validate:"min=0"`
+	MaxUsesPerUser int   `json:"max_uses_per_user" validate:"min=0"`
+	IsActive       *bool `json:"is_active"` // Default true
+}
+
+type ApplyPromoDTO struct {
+	PromoCode string `json:"promo_code" validate:"required,max=40"`
+}
+
+type BookingQuoteDTO struct {
+	PackageID string `json:"package_id" validate:"required,uuid"`
+	RoomType  string `json:"room_type" validate:"required,oneof=QUAD TRIPLE DOUBLE"`
+	PaxCount  int    `json:"pax_count" validate:"required,min=1"`
+	PromoCode string `json:"promo_code" validate:"required,max=40"`
+}
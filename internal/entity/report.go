@@ -0,0 +1,104 @@
+package entity
+
+import (
+	"time"
+	"umrah-backend/pkg/money"
+
+	"github.com/google/uuid"
+)
+
+// Reports never convert currencies: every figure is reported per currency.
+
+type ReportGranularity string
+
+const (
+	GranularityDay   ReportGranularity = "day"
+	GranularityMonth ReportGranularity = "month"
+)
+
+// ReportFilter: From inclusive, To exclusive (both in the agency timezone).
+type ReportFilter struct {
+	From        *time.Time
+	To          *time.Time
+	Granularity ReportGranularity
+}
+
+// RevenuePoint is the money received in one period. Orders count when the
+// payment was confirmed, bookings when an installment was paid (in full
+// when the booking has no payment plan). Refunded orders/bookings are left out.
+type RevenuePoint struct {
+	Period       string      `json:"period"` // "2026-10-17" or "2026-10"
+	Orders       money.Money `json:"orders"`
+	Bookings     money.Money `json:"bookings"`
+	Total        money.Money `json:"total"`
+	OrderCount   int64       `json:"order_count"`
+	PaymentCount int64       `json:"payment_count"` // Paid installments and plan-less paid bookings
+}
+
+type RevenueReport struct {
+	From        time.Time         `json:"from"`
+	To          time.Time         `json:"to"`
+	Granularity ReportGranularity `json:"granularity"`
+	Points      []RevenuePoint    `json:"points"`
+	Totals      []money.Money     `json:"totals"` // One per currency
+}
+
+type ProductRevenue struct {
+	ProductID   uuid.UUID   `json:"product_id"`
+	ProductName string      `json:"product_name"`
+	Revenue     money.Money `json:"revenue"`
+	Quantity    int64       `json:"quantity"`
+	Orders      int64       `json:"orders"`
+}
+
+// CategoryRevenue: Revenue is money received in the period; Bookings, Pax
+// and BookedValue cover bookings made in the period that still hold seats.
+type CategoryRevenue struct {
+	Category    PackageCategory `json:"category"`
+	Revenue     money.Money     `json:"revenue"`
+	Payments    int64           `json:"payments"`
+	Bookings    int64           `json:"bookings"`
+	Pax         int64           `json:"pax"`
+	BookedValue money.Money     `json:"booked_value"`
+}
+
+type PackageOccupancy struct {
+	PackageID     uuid.UUID       `json:"package_id"`
+	Name          string          `json:"name"`
+	Category      PackageCategory `json:"category"`
+	DepartureDate time.Time       `json:"departure_date"`
+	Quota         int             `json:"quota"`
+	Available     int             `json:"available"`
+	Booked        int             `json:"booked"`
+	Occupancy     float64         `json:"occupancy_percent"`
+	PendingPax    int             `json:"pending_pax"`
+	PaidPax       int             `json:"paid_pax"`
+	ConfirmedPax  int             `json:"confirmed_pax"`
+}
+
+// OutstandingItem is an unpaid order or the unpaid part of a booking.
+type OutstandingItem struct {
+	Source      string      `json:"source"` // ORDER or BOOKING
+	ID          uuid.UUID   `json:"id"`
+	Customer    string      `json:"customer"`
+	Phone       string      `json:"phone"`
+	Description string      `json:"description"`
+	Status      string      `json:"status"`
+	Total       money.Money `json:"total"`
+	Paid        money.Money `json:"paid"`
+	Outstanding money.Money `json:"outstanding"`
+	DueDate     *time.Time  `json:"due_date,omitempty"`
+	Overdue     bool        `json:"overdue"`
+}
+
+type OutstandingTotal struct {
+	Source  string      `json:"source"`
+	Count   int64       `json:"count"`
+	Amount  money.Money `json:"amount"`
+	Overdue money.Money `json:"overdue"`
+}
+
+type OutstandingReport struct {
+	Totals []OutstandingTotal `json:"totals"` // Per source and currency
+	Items  []OutstandingItem  `json:"items"`
+}
@@ -0,0 +1,79 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Room Gender: pilgrims only share a room with the same gender,
+// unless they travel as one family (mahram).
+const (
+	RoomMale   = "MALE"
+	RoomFemale = "FEMALE"
+	RoomFamily = "FAMILY"
+)
+
+// RoomCapacity returns the beds per room for a booking room type.
+func RoomCapacity(roomType string) int {
+	switch roomType {
+	case "QUAD":
+		return 4
+	case "TRIPLE":
+		return 3
+	case "DOUBLE":
+		return 2
+	default:
+		return 0
+	}
+}
+
+// Room is one hotel room of a package departure.
+// The same rooming list is used at HotelMakkah and HotelMadinah.
+type Room struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	PackageID uuid.UUID `gorm:"type:uuid;not null;index" json:"package_id"`
+
+	Label    string `gorm:"type:varchar(20);not null" json:"label"` // e.g. "Q-01"
+	RoomType string `gorm:"type:varchar(10);not null" json:"room_type"`
+	Capacity int    `json:"capacity"`
+	Gender   string `gorm:"type:varchar(10);not null" json:"gender"` // MALE, FEMALE, FAMILY
+
+	// AutoGenerated: opened by the allocator; dropped again when it ends up
+	// empty on regeneration. Rooms created by an admin are always kept.
+	AutoGenerated bool `gorm:"default:false" json:"auto_generated"`
+
+	Occupants []RoomAssignment `gorm:"foreignKey:RoomID" json:"occupants,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type RoomAssignment struct {
+	ID          uuid.UUID         `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	RoomID      uuid.UUID         `gorm:"type:uuid;not null;index" json:"room_id"`
+	PassengerID uuid.UUID         `gorm:"type:uuid;not null;uniqueIndex" json:"passenger_id"`
+	Passenger   *BookingPassenger `gorm:"foreignKey:PassengerID" json:"passenger,omitempty"`
+
+	// IsManual: true if an admin placed this pilgrim; kept when the list is regenerated
+	IsManual bool `gorm:"default:false" json:"is_manual"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RoomingList is the rooming list sent to the hotels of a package.
+type RoomingList struct {
+	Package *TravelPackage `json:"package"`
+	Rooms   []Room         `json:"rooms"`
+}
+
+// --- REQUEST DTOs ---
+type CreateRoomDTO struct {
+	Label    string `json:"label" validate:"required,max=20"`
+	RoomType string `json:"room_type" validate:"required,oneof=QUAD TRIPLE DOUBLE"`
+	Gender   string `json:"gender" validate:"required,oneof=MALE FEMALE FAMILY"`
+}
+
+type AssignRoomDTO struct {
+	PassengerID string `json:"passenger_id" validate:"required,uuid"`
+	RoomID      string `json:"room_id" validate:"required,uuid"`
+}
@@ -12,6 +12,7 @@ const (
 	RoleAdmin    = "ADMIN"
 	RoleMutawwif = "MUTAWWIF"
 	RoleJamaah   = "JAMAAH"
+	RoleAgent    = "AGENT" // Field sales agent, see Agent
 )
 
 // DATABASE MODEL
@@ -60,7 +61,7 @@ type CreateUserInternalDTO struct {
 	FullName    string `json:"full_name" validate:"required"`
 	PhoneNumber string `json:"phone_number" validate:"required"`
 	Password    string `json:"password" validate:"required"`
-	Role        string `json:"role" validate:"required,oneof=JAMAAH MUTAWWIF ADMIN"`
+	Role        string `json:"role" validate:"required,oneof=JAMAAH MUTAWWIF ADMIN AGENT"`
 }
 
 type LoginDTO struct {
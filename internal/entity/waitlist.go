@@ -0,0 +1,60 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type WaitlistStatus string
+
+const (
+	WaitlistWaiting   WaitlistStatus = "WAITING"   // In the queue
+	WaitlistOffered   WaitlistStatus = "OFFERED"   // Seats held for the entry until OfferExpiresAt
+	WaitlistClaimed   WaitlistStatus = "CLAIMED"   // Offer turned into a booking
+	WaitlistExpired   WaitlistStatus = "EXPIRED"   // Offer not claimed in time, seats passed on
+	WaitlistCancelled WaitlistStatus = "CANCELLED" // Left the waitlist
+)
+
+// WaitlistEntry is a place in the queue of a sold-out package. Freed seats
+// are offered in join order; an offer holds the seats until it is claimed
+// or runs out. A user has at most one WAITING or OFFERED entry per package
+// (partial unique index idx_waitlist_active).
+type WaitlistEntry struct {
+	ID        uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	PackageID uuid.UUID      `gorm:"type:uuid;not null;index" json:"package_id"`
+	Package   *TravelPackage `gorm:"foreignKey:PackageID" json:"package,omitempty"`
+	UserID    uuid.UUID      `gorm:"type:uuid;not null;index" json:"user_id"`
+	User      *User          `gorm:"foreignKey:UserID" json:"user,omitempty"`
+
+	RoomType string `gorm:"type:varchar(10);not null" json:"room_type"`
+	PaxCount int    `gorm:"not null" json:"pax_count"`
+
+	Status   WaitlistStatus `gorm:"type:varchar(20);default:'WAITING';index" json:"status"`
+	JoinedAt time.Time      `gorm:"index" json:"joined_at"` // FIFO order
+
+	OfferedAt      *time.Time `json:"offered_at,omitempty"`
+	OfferExpiresAt *time.Time `gorm:"index" json:"offer_expires_at,omitempty"`
+	ClaimedAt      *time.Time `json:"claimed_at,omitempty"`
+	BookingID      *uuid.UUID `gorm:"type:uuid" json:"booking_id,omitempty"`
+
+	// 1 = next in line; only set for WAITING entries (not stored)
+	Position int `gorm:"-" json:"position,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// --- REQUEST DTOs ---
+
+type JoinWaitlistDTO struct {
+	PackageID string `json:"package_id" validate:"required,uuid"`
+	RoomType  string `json:"room_type" validate:"required,oneof=QUAD TRIPLE DOUBLE"`
+	PaxCount  int    `json:"pax_count" validate:"required,min=1"`
+}
+
+// ClaimWaitlistDTO books the offered seats; room type and pax come from the entry.
+type ClaimWaitlistDTO struct {
+	PromoCode    string `json:"promo_code" validate:"max=40"`
+	ReferralCode string `json:"referral_code" validate:"max=20"`
+}
@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"errors"
+	"umrah-backend/internal/entity"
+	"umrah-backend/internal/service"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type AgentHandler struct {
+	svc       service.AgentService
+	validator *validator.Validate
+}
+
+func NewAgentHandler(svc service.AgentService) *AgentHandler {
+	return &AgentHandler{svc: svc, validator: validator.New()}
+}
+
+// GET /admin/agents
+func (h *AgentHandler) List(c *fiber.Ctx) error {
+	agents, err := h.svc.GetAgents(c.Context())
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(agents)
+}
+
+// GET /admin/agents/:id
+func (h *AgentHandler) Get(c *fiber.Ctx) error {
+	agent, err := h.svc.GetAgent(c.Context(), c.Params("id"))
+	if err != nil {
+		return c.Status(agentErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(agent)
+}
+
+// POST /admin/agents (Turns an existing user into an agent)
+func (h *AgentHandler) Create(c *fiber.Ctx) error {
+	var req entity.CreateAgentDTO
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	agent, err := h.svc.CreateAgent(c.Context(), req)
+	if err != nil {
+		return c.Status(agentErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(201).JSON(agent)
+}
+
+// PUT /admin/agents/:id
+func (h *AgentHandler) Update(c *fiber.Ctx) error {
+	var req entity.UpdateAgentDTO
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	agent, err := h.svc.UpdateAgent(c.Context(), c.Params("id"), req)
+	if err != nil {
+		return c.Status(agentErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(agent)
+}
+
+// GET /admin/agents/:id/statement?from=&to=
+func (h *AgentHandler) Statement(c *fiber.Ctx) error {
+	return h.statement(c, c.Params("id"))
+}
+
+// POST /admin/agents/:id/payouts (Pays out every earned commission in one currency)
+func (h *AgentHandler) CreatePayout(c *fiber.Ctx) error {
+	adminID, err := getUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var req entity.CreatePayoutDTO
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	payout, err := h.svc.CreatePayout(c.Context(), adminID, c.Params("id"), req)
+	if err != nil {
+		return c.Status(agentErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(201).JSON(payout)
+}
+
+// GET /admin/commission-rules (Defaults)
+// GET /admin/packages/:id/commission-rules
+func (h *AgentHandler) GetRules(c *fiber.Ctx) error {
+	rules, err := h.svc.GetRules(c.Context(), c.Params("id"))
+	if err != nil {
+		return c.Status(agentErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(rules)
+}
+
+// PUT /admin/commission-rules (Replaces the defaults)
+// PUT /admin/packages/:id/commission-rules (Replaces the package's own rules)
+func (h *AgentHandler) SetRules(c *fiber.Ctx) error {
+	var req entity.SetCommissionRulesDTO
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	rules, err := h.svc.SetRules(c.Context(), c.Params("id"), req)
+	if err != nil {
+		return c.Status(agentErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(rules)
+}
+
+// GET /agent/me
+func (h *AgentHandler) Me(c *fiber.Ctx) error {
+	agent, err := h.myAgent(c)
+	if err != nil {
+		return c.Status(agentErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(agent)
+}
+
+// GET /agent/bookings?status=&package_id=&departure_from=&departure_to=&page=&limit=
+func (h *AgentHandler) MyBookings(c *fiber.Ctx) error {
+	agent, err := h.myAgent(c)
+	if err != nil {
+		return c.Status(agentErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	filter, err := parseBookingFilter(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	bookings, total, err := h.svc.GetBookings(c.Context(), agent.ID.String(), filter)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"data":  bookings,
+		"total": total,
+		"page":  filter.Page,
+		"limit": filter.Limit,
+	})
+}
+
+// GET /agent/statement?from=&to=
+func (h *AgentHandler) MyStatement(c *fiber.Ctx) error {
+	agent, err := h.myAgent(c)
+	if err != nil {
+		return c.Status(agentErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return h.statement(c, agent.ID.String())
+}
+
+// statement: from inclusive, to exclusive (YYYY-MM-DD); totals are all-time.
+func (h *AgentHandler) statement(c *fiber.Ctx, agentID string) error {
+	from, err := parseDateQuery(c, "from")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	to, err := parseDateQuery(c, "to")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	statement, err := h.svc.GetStatement(c.Context(), agentID, from, to)
+	if err != nil {
+		return c.Status(agentErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(statement)
+}
+
+func (h *AgentHandler) myAgent(c *fiber.Ctx) (*entity.Agent, error) {
+	userID, err := getUserID(c)
+	if err != nil {
+		return nil, err
+	}
+	return h.svc.GetMyAgent(c.Context(), userID)
+}
+
+func agentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrAgentNotFound):
+		return 404
+	case err.Error() == "package not found":
+		return 404
+	default:
+		return 400
+	}
+}
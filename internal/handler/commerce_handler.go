@@ -1,22 +1,27 @@
 package handler
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"path/filepath"
+	"strconv"
+	"strings"
 	"umrah-backend/internal/entity"
 	"umrah-backend/internal/service"
 
+	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
 type CommerceHandler struct {
-	svc service.CommerceService
+	svc       service.CommerceService
+	validator *validator.Validate
 }
 
 func NewCommerceHandler(svc service.CommerceService) *CommerceHandler {
-	return &CommerceHandler{svc: svc}
+	return &CommerceHandler{svc: svc, validator: validator.New()}
 }
 
 // POST /products (Admin Only)
@@ -27,40 +32,339 @@ func (h *CommerceHandler) CreateProduct(c *fiber.Ctx) error {
 	}
 
 	if err := h.svc.CreateProduct(c.Context(), req); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return c.Status(currencyErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
 	}
 	return c.Status(201).JSON(fiber.Map{"message": "Product created"})
 }
 
-// GET /products (Catalog)
+// GET /products?category=sim-card&q=zamzam&sort=price_asc&currency=USD (Catalog)
 func (h *CommerceHandler) GetCatalog(c *fiber.Ctx) error {
-	products, err := h.svc.GetCatalog(c.Context())
+	return h.listProducts(c, false)
+}
+
+// GET /admin/products (Admin: includes inactive and out-of-window products)
+func (h *CommerceHandler) ListAllProducts(c *fiber.Ctx) error {
+	return h.listProducts(c, true)
+}
+
+func (h *CommerceHandler) listProducts(c *fiber.Ctx, includeHidden bool) error {
+	filter := entity.ProductFilter{
+		Category:      c.Query("category"),
+		Search:        strings.TrimSpace(c.Query("q")),
+		Sort:          c.Query("sort"),
+		IncludeHidden: includeHidden,
+	}
+	switch filter.Sort {
+	case "", "newest", "price_asc", "price_desc":
+	default:
+		return c.Status(400).JSON(fiber.Map{"error": "sort must be one of newest, price_asc, price_desc"})
+	}
+
+	products, err := h.svc.GetCatalog(c.Context(), filter, c.Query("currency"))
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return c.Status(currencyErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
 	}
 	return c.JSON(products)
 }
 
+// GET /products/:id
+func (h *CommerceHandler) GetProduct(c *fiber.Ctx) error {
+	product, err := h.svc.GetProduct(c.Context(), c.Params("id"), false)
+	if err != nil {
+		return c.Status(catalogErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(product)
+}
+
+// PUT /admin/products/:id (Admin Only: partial update)
+func (h *CommerceHandler) UpdateProduct(c *fiber.Ctx) error {
+	var req entity.UpdateProductDTO
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	product, err := h.svc.UpdateProduct(c.Context(), c.Params("id"), req)
+	if err != nil {
+		return c.Status(catalogErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(product)
+}
+
+// DELETE /admin/products/:id (Admin Only: soft delete)
+func (h *CommerceHandler) DeleteProduct(c *fiber.Ctx) error {
+	if err := h.svc.DeleteProduct(c.Context(), c.Params("id")); err != nil {
+		return c.Status(catalogErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "Product deleted"})
+}
+
+// PUT /admin/products/:id/variants/:variant_id (Admin Only)
+func (h *CommerceHandler) UpdateVariant(c *fiber.Ctx) error {
+	var req entity.UpdateVariantDTO
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	variant, err := h.svc.UpdateVariant(c.Context(), c.Params("id"), c.Params("variant_id"), req)
+	if err != nil {
+		return c.Status(catalogErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(variant)
+}
+
+// POST /admin/products/:id/images (Admin Only: multipart "image", optional "sort_order")
+func (h *CommerceHandler) AddProductImage(c *fiber.Ctx) error {
+	sortOrder := 0
+	if raw := c.FormValue("sort_order"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "sort_order must be a number"})
+		}
+		sortOrder = n
+	}
+
+	if _, err := h.svc.GetProduct(c.Context(), c.Params("id"), true); err != nil {
+		return c.Status(catalogErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	publicURL, status, err := saveImage(c, "image", "product_"+c.Params("id"))
+	if err != nil {
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	image, err := h.svc.AddProductImage(c.Context(), c.Params("id"), publicURL, sortOrder)
+	if err != nil {
+		removeUpload(publicURL)
+		return c.Status(catalogErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(201).JSON(image)
+}
+
+// DELETE /admin/products/:id/images/:image_id (Admin Only)
+func (h *CommerceHandler) DeleteProductImage(c *fiber.Ctx) error {
+	image, err := h.svc.DeleteProductImage(c.Context(), c.Params("id"), c.Params("image_id"))
+	if err != nil {
+		return c.Status(catalogErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	removeUpload(image.URL)
+	return c.JSON(fiber.Map{"message": "Image deleted"})
+}
+
+// GET /product-categories
+func (h *CommerceHandler) GetCategories(c *fiber.Ctx) error {
+	categories, err := h.svc.GetCategories(c.Context())
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(categories)
+}
+
+// POST /admin/product-categories (Admin Only)
+func (h *CommerceHandler) CreateCategory(c *fiber.Ctx) error {
+	var req entity.ProductCategoryDTO
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	category, err := h.svc.CreateCategory(c.Context(), req)
+	if err != nil {
+		return c.Status(catalogErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(201).JSON(category)
+}
+
+// PUT /admin/product-categories/:id (Admin Only)
+func (h *CommerceHandler) UpdateCategory(c *fiber.Ctx) error {
+	var req entity.ProductCategoryDTO
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	category, err := h.svc.UpdateCategory(c.Context(), c.Params("id"), req)
+	if err != nil {
+		return c.Status(catalogErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(category)
+}
+
+// DELETE /admin/product-categories/:id (Admin Only: products become uncategorised)
+func (h *CommerceHandler) DeleteCategory(c *fiber.Ctx) error {
+	if err := h.svc.DeleteCategory(c.Context(), c.Params("id")); err != nil {
+		return c.Status(catalogErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "Category deleted"})
+}
+
+// catalogErrorStatus: 404 for unknown products/categories, otherwise as currencyErrorStatus
+func catalogErrorStatus(err error) int {
+	if errors.Is(err, service.ErrProductNotFound) || errors.Is(err, service.ErrCategoryNotFound) {
+		return 404
+	}
+	return currencyErrorStatus(err)
+}
+
 // POST /orders (Buy)
 func (h *CommerceHandler) CreateOrder(c *fiber.Ctx) error {
 	user := c.Locals("user").(*jwt.Token)
 	claims := user.Claims.(jwt.MapClaims)
 	userID := claims["user_id"].(string)
 
-	var req struct {
-		ProductID string `json:"product_id"`
+	var req entity.CreateOrderDTO
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// Older app versions send a single product_id
+	items := req.Items
+	if len(items) == 0 && req.ProductID != "" {
+		items = []entity.OrderItemDTO{{ProductID: req.ProductID, Quantity: 1}}
+	}
+
+	order, err := h.svc.CreateOrder(c.Context(), userID, items, req.PromoCode)
+	if err != nil {
+		return c.Status(orderErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(201).JSON(order)
+}
+
+// GET /cart
+func (h *CommerceHandler) GetCart(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	cart, err := h.svc.GetCart(c.Context(), userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(cart)
+}
+
+// POST /cart/items
+func (h *CommerceHandler) AddToCart(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
 	}
+
+	var req entity.AddCartItemDTO
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": "Invalid input"})
 	}
+	if err := h.validator.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
 
-	order, err := h.svc.CreateOrder(c.Context(), userID, req.ProductID)
+	cart, err := h.svc.AddToCart(c.Context(), userID, req)
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
+	return c.JSON(cart)
+}
+
+// PUT /cart/items/:product_id?variant_id=
+func (h *CommerceHandler) UpdateCartItem(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var req entity.UpdateCartItemDTO
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	cart, err := h.svc.UpdateCartItem(c.Context(), userID, c.Params("product_id"), c.Query("variant_id"), req.Quantity)
+	if err != nil {
+		status := 400
+		if errors.Is(err, service.ErrCartItemNotFound) {
+			status = 404
+		}
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(cart)
+}
+
+// DELETE /cart/items/:product_id?variant_id=
+func (h *CommerceHandler) RemoveFromCart(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	cart, err := h.svc.RemoveFromCart(c.Context(), userID, c.Params("product_id"), c.Query("variant_id"))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(cart)
+}
+
+// POST /cart/checkout
+func (h *CommerceHandler) Checkout(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	// Body is optional: {"promo_code": "..."}
+	var req entity.CheckoutDTO
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid input"})
+		}
+		if err := h.validator.Struct(req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	order, err := h.svc.Checkout(c.Context(), userID, req.PromoCode)
+	if err != nil {
+		return c.Status(orderErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
 	return c.Status(201).JSON(order)
 }
 
+// POST /cart/promo (Preview a promo code on the current cart)
+func (h *CommerceHandler) QuoteCart(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var req entity.ApplyPromoDTO
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	quote, err := h.svc.QuoteCart(c.Context(), userID, req.PromoCode)
+	if err != nil {
+		return c.Status(orderErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(quote)
+}
+
 // GET /orders/my (History)
 func (h *CommerceHandler) GetMyOrders(c *fiber.Ctx) error {
 	user := c.Locals("user").(*jwt.Token)
@@ -83,52 +387,304 @@ func (h *CommerceHandler) UploadProof(c *fiber.Ctx) error {
 
 	orderID := c.Params("id")
 
-	// 1. Handle File Upload
-	file, err := c.FormFile("image")
+	// 1. Handle File Upload (validated & saved by helper)
+	publicURL, status, err := saveImage(c, "image", userID)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Image required"})
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	// [SECURITY FIX] Validasi MIME Type / Ekstensi
-	allowedTypes := map[string]bool{
-		"image/jpeg": true,
-		"image/png":  true,
-		"image/jpg":  true,
+	// 2. Call Service
+	// Pass c.Context()
+	if err := h.svc.UploadPaymentProof(c.Context(), orderID, publicURL, userID); err != nil {
+		return c.Status(orderErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
 	}
-	contentType := file.Header.Get("Content-Type")
-	if !allowedTypes[contentType] {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid file type. Only JPG/PNG allowed"})
+
+	return c.JSON(fiber.Map{"message": "Proof uploaded", "url": publicURL})
+}
+
+// PATCH /orders/:id/verify (Admin Only)
+func (h *CommerceHandler) VerifyOrder(c *fiber.Ctx) error {
+	adminID, err := getUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
 	}
 
-	// [SECURITY FIX] Batasi ukuran file (misal max 2MB)
-	if file.Size > 2*1024*1024 {
-		return c.Status(400).JSON(fiber.Map{"error": "File size too large (max 2MB)"})
+	orderID := c.Params("id")
+	if err := h.svc.VerifyOrder(c.Context(), adminID, orderID); err != nil {
+		return c.Status(orderErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
 	}
+	return c.JSON(fiber.Map{"message": "Order verified"})
+}
 
-	// 2. Save File Locally
-	ext := filepath.Ext(file.Filename)
-	filename := fmt.Sprintf("%s_%s%s", userID, uuid.New().String(), ext)
-	savePath := fmt.Sprintf("./uploads/%s", filename)
+// GET /orders/:id/events (Admin Only: audit trail)
+func (h *CommerceHandler) GetEvents(c *fiber.Ctx) error {
+	events, err := h.svc.GetOrderEvents(c.Context(), c.Params("id"))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(events)
+}
 
-	if err := c.SaveFile(file, savePath); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to save image"})
+// PATCH /orders/:id/reject (Admin Only)
+func (h *CommerceHandler) RejectOrder(c *fiber.Ctx) error {
+	adminID, err := getUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
 	}
 
-	// 3. Call Service
-	publicURL := fmt.Sprintf("/uploads/%s", filename)
-	// Pass c.Context()
-	if err := h.svc.UploadPaymentProof(c.Context(), orderID, publicURL, userID); err != nil {
+	var req entity.RejectOrderDTO
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	if err := h.validator.Struct(req); err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	return c.JSON(fiber.Map{"message": "Proof uploaded", "url": publicURL})
+	if err := h.svc.RejectOrder(c.Context(), adminID, c.Params("id"), req.Reason); err != nil {
+		return c.Status(orderErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "Order rejected"})
 }
 
-// PATCH /orders/:id/verify (Admin Only)
-func (h *CommerceHandler) VerifyOrder(c *fiber.Ctx) error {
-	orderID := c.Params("id")
-	if err := h.svc.VerifyOrder(c.Context(), orderID); err != nil {
+// GET /orders/:id/proofs (Proof history: owner or admin)
+func (h *CommerceHandler) GetProofs(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	role, _ := getUserRole(c)
+
+	proofs, err := h.svc.GetOrderProofs(c.Context(), c.Params("id"), userID, role)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(proofs)
+}
+
+// POST /orders/:id/cancel (Unpaid orders)
+func (h *CommerceHandler) CancelOrder(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	// Body is optional
+	var req entity.CancelOrderDTO
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid input"})
+		}
+		if err := h.validator.Struct(req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	if err := h.svc.CancelOrder(c.Context(), userID, c.Params("id"), req.Reason); err != nil {
+		return c.Status(orderErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "Order cancelled"})
+}
+
+// POST /orders/:id/refund (Paid orders)
+func (h *CommerceHandler) RequestRefund(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var req entity.RequestRefundDTO
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	refund, err := h.svc.RequestRefund(c.Context(), userID, c.Params("id"), req.Reason)
+	if err != nil {
+		return c.Status(orderErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(201).JSON(refund)
+}
+
+// GET /refund-requests?status=REQUESTED (Admin Only)
+func (h *CommerceHandler) ListRefunds(c *fiber.Ctx) error {
+	refunds, err := h.svc.GetRefundRequests(c.Context(), c.Query("status"))
+	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
-	return c.JSON(fiber.Map{"message": "Order verified"})
+	return c.JSON(refunds)
+}
+
+// PATCH /refund-requests/:id/approve (Admin Only)
+func (h *CommerceHandler) ApproveRefund(c *fiber.Ctx) error {
+	return h.reviewRefund(c, h.svc.ApproveRefund)
+}
+
+// PATCH /refund-requests/:id/decline (Admin Only)
+func (h *CommerceHandler) DeclineRefund(c *fiber.Ctx) error {
+	return h.reviewRefund(c, h.svc.DeclineRefund)
+}
+
+func (h *CommerceHandler) reviewRefund(c *fiber.Ctx, review func(ctx context.Context, adminID, requestID, note string) (*entity.RefundRequest, error)) error {
+	adminID, err := getUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var req entity.ReviewRefundDTO
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid input"})
+		}
+		if err := h.validator.Struct(req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	refund, err := review(c.Context(), adminID, c.Params("id"), req.Note)
+	if err != nil {
+		return c.Status(orderErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(refund)
+}
+
+// POST /refund-requests/:id/proof (Admin Only: upload transfer receipt)
+func (h *CommerceHandler) UploadRefundProof(c *fiber.Ctx) error {
+	adminID, err := getUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	publicURL, status, err := saveImage(c, "image", adminID)
+	if err != nil {
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	refund, err := h.svc.CompleteRefund(c.Context(), adminID, c.Params("id"), publicURL)
+	if err != nil {
+		return c.Status(orderErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(refund)
+}
+
+// GET /orders?status=PAID,REJECTED&user_id=&product_id=&created_from=&created_to=&cursor=&limit= (Admin Only)
+// Default status is PAID: the "waiting for verification" queue. status=ALL lists everything.
+func (h *CommerceHandler) ListOrders(c *fiber.Ctx) error {
+	filter, err := parseOrderFilter(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	page, err := h.svc.ListOrders(c.Context(), filter, c.Query("cursor"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCursor) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// Inline preview: absolute URL so the dashboard can render the image directly
+	for i := range page.Items {
+		if proof := page.Items[i].ProofImage; strings.HasPrefix(proof, "/") {
+			page.Items[i].ProofImageURL = c.BaseURL() + proof
+		}
+	}
+	return c.JSON(page)
+}
+
+// POST /orders/verify (Admin Only: bulk verify)
+func (h *CommerceHandler) BulkVerify(c *fiber.Ctx) error {
+	adminID, err := getUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var req entity.BulkVerifyOrdersDTO
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	results := h.svc.BulkVerifyOrders(c.Context(), adminID, req.OrderIDs)
+
+	verified := 0
+	for _, r := range results {
+		if r.Verified {
+			verified++
+		}
+	}
+	return c.JSON(fiber.Map{"verified": verified, "failed": len(results) - verified, "results": results})
+}
+
+func parseOrderFilter(c *fiber.Ctx) (entity.OrderFilter, error) {
+	from, err := parseDateQuery(c, "created_from")
+	if err != nil {
+		return entity.OrderFilter{}, err
+	}
+	to, err := parseDateQuery(c, "created_to")
+	if err != nil {
+		return entity.OrderFilter{}, err
+	}
+	if to != nil {
+		// created_to names the last day to include
+		end := to.AddDate(0, 0, 1)
+		to = &end
+	}
+	if from != nil && to != nil && !from.Before(*to) {
+		return entity.OrderFilter{}, errors.New("created_from must not be after created_to")
+	}
+	for _, key := range []string{"user_id", "product_id"} {
+		if raw := c.Query(key); raw != "" {
+			if _, err := uuid.Parse(raw); err != nil {
+				return entity.OrderFilter{}, fmt.Errorf("invalid %s", key)
+			}
+		}
+	}
+
+	limit := c.QueryInt("limit", 20)
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	filter := entity.OrderFilter{
+		UserID:      c.Query("user_id"),
+		ProductID:   c.Query("product_id"),
+		CreatedFrom: from,
+		CreatedTo:   to,
+		Limit:       limit,
+	}
+
+	switch raw := c.Query("status"); raw {
+	case "":
+		filter.Statuses = []entity.OrderStatus{entity.OrderPaid}
+	case "ALL":
+	default:
+		for _, st := range strings.Split(raw, ",") {
+			status := entity.OrderStatus(strings.ToUpper(strings.TrimSpace(st)))
+			if !status.Valid() {
+				return entity.OrderFilter{}, fmt.Errorf("invalid status %q", st)
+			}
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+	return filter, nil
+}
+
+// orderErrorStatus: 409 when stock or time ran out or the status forbids
+// the action, 400 for other input errors
+func orderErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrOutOfStock),
+		errors.Is(err, service.ErrOrderExpired),
+		errors.Is(err, service.ErrInvalidOrderTransition):
+		return 409
+	case errors.Is(err, service.ErrRefundNotFound), errors.Is(err, service.ErrPromoNotFound), errors.Is(err, service.ErrOrderNotFound):
+		return 404
+	default:
+		return 400
+	}
 }
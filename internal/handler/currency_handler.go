@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"umrah-backend/internal/entity"
+	"umrah-backend/internal/service"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type CurrencyHandler struct {
+	svc       service.CurrencyService
+	validator *validator.Validate
+}
+
+func NewCurrencyHandler(svc service.CurrencyService) *CurrencyHandler {
+	return &CurrencyHandler{svc: svc, validator: validator.New()}
+}
+
+// PUT /admin/exchange-rates
+func (h *CurrencyHandler) SetRate(c *fiber.Ctx) error {
+	adminID, err := getUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var req entity.SetExchangeRateDTO
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	rate, err := h.svc.SetRate(c.Context(), adminID, req)
+	if err != nil {
+		return c.Status(currencyErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(rate)
+}
+
+// GET /admin/exchange-rates
+func (h *CurrencyHandler) GetRates(c *fiber.Ctx) error {
+	rates, err := h.svc.GetRates(c.Context())
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(rates)
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"errors"
+	"umrah-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type DocumentHandler struct {
+	svc service.DocumentService
+}
+
+func NewDocumentHandler(svc service.DocumentService) *DocumentHandler {
+	return &DocumentHandler{svc: svc}
+}
+
+// GET /orders/:id/documents (Invoice + receipt of an order)
+func (h *DocumentHandler) GetOrderDocuments(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	role, _ := getUserRole(c)
+
+	docs, err := h.svc.GetOrderDocuments(c.Context(), c.Params("id"), userID, role)
+	if err != nil {
+		return c.Status(documentErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(docs)
+}
+
+// GET /bookings/:id/documents (Invoice + one receipt per paid installment)
+func (h *DocumentHandler) GetBookingDocuments(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	role, _ := getUserRole(c)
+
+	docs, err := h.svc.GetBookingDocuments(c.Context(), c.Params("id"), userID, role)
+	if err != nil {
+		return c.Status(documentErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(docs)
+}
+
+// GET /documents/:id/download (PDF)
+func (h *DocumentHandler) Download(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	role, _ := getUserRole(c)
+
+	doc, path, err := h.svc.OpenDocument(c.Context(), c.Params("id"), userID, role)
+	if err != nil {
+		return c.Status(documentErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	c.Set(fiber.HeaderCacheControl, "private, no-store")
+	return c.Download(path, doc.Number+".pdf")
+}
+
+func documentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrDocumentNotFound), errors.Is(err, service.ErrBookingNotFound), errors.Is(err, service.ErrOrderNotFound):
+		return 404
+	case err.Error() == "unauthorized":
+		return 403
+	default:
+		return 400
+	}
+}
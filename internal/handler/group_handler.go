@@ -68,3 +68,39 @@ func (h *GroupHandler) GetMembers(c *fiber.Ctx) error {
 
 	return c.JSON(members)
 }
+
+// POST /admin/packages/:id/group
+func (h *GroupHandler) CreateFromPackage(c *fiber.Ctx) error {
+	userID, role := getUserFromCtx(c)
+
+	var req entity.CreatePackageGroupDTO
+	// Body is optional
+	_ = c.BodyParser(&req)
+
+	group, err := h.svc.CreateGroupFromPackage(c.Context(), userID, role, c.Params("id"), req)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(201).JSON(group)
+}
+
+// POST /admin/packages/:id/group/sync (enroll newly confirmed pilgrims)
+func (h *GroupHandler) SyncPackageMembers(c *fiber.Ctx) error {
+	added, err := h.svc.SyncPackageMembers(c.Context(), c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"message": "Members synced", "added": added})
+}
+
+// POST /admin/packages/:id/group/rundown (re-apply the package's template rundown)
+func (h *GroupHandler) GeneratePackageRundown(c *fiber.Ctx) error {
+	generated, err := h.svc.GeneratePackageRundown(c.Context(), c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"message": "Rundown generated", "generated": generated})
+}
@@ -1,10 +1,19 @@
 package handler
 
 import (
+	"encoding/csv"
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"umrah-backend/internal/service"
+	"umrah-backend/pkg/money"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 // Helper: Ambil UserID dari JWT Context
@@ -42,3 +51,90 @@ func getUserRole(c *fiber.Ctx) (string, error) {
 	}
 	return role, nil
 }
+
+// Helper: Parse optional YYYY-MM-DD query param
+func parseDateQuery(c *fiber.Ctx, key string) (*time.Time, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+	t, err := time.Parse("2006-01-02", raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s format (use YYYY-MM-DD)", key)
+	}
+	return &t, nil
+}
+
+// Helper: Stream rows as a downloadable CSV file
+func sendCSV(c *fiber.Ctx, filename string, header []string, rows [][]string) error {
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	w := csv.NewWriter(c.Response().BodyWriter())
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return err
+	}
+	return w.Error()
+}
+
+// Helper: Save an uploaded image (proof, product photo; JPG/PNG, max 2MB) into ./uploads.
+// Returns the public URL, or the HTTP status to reply with on failure.
+func saveImage(c *fiber.Ctx, field, ownerID string) (string, int, error) {
+	file, err := c.FormFile(field)
+	if err != nil {
+		return "", 400, errors.New("Image required")
+	}
+
+	// [SECURITY FIX] Validasi MIME Type / Ekstensi
+	allowedTypes := map[string]bool{
+		"image/jpeg": true,
+		"image/png":  true,
+		"image/jpg":  true,
+	}
+	contentType := file.Header.Get("Content-Type")
+	if !allowedTypes[contentType] {
+		return "", 400, errors.New("Invalid file type. Only JPG/PNG allowed")
+	}
+
+	// [SECURITY FIX] Batasi ukuran file (misal max 2MB)
+	if file.Size > 2*1024*1024 {
+		return "", 400, errors.New("File size too large (max 2MB)")
+	}
+
+	// Save File Locally
+	ext := filepath.Ext(file.Filename)
+	filename := fmt.Sprintf("%s_%s%s", ownerID, uuid.New().String(), ext)
+	savePath := fmt.Sprintf("./uploads/%s", filename)
+
+	if err := c.SaveFile(file, savePath); err != nil {
+		return "", 500, errors.New("Failed to save image")
+	}
+
+	return fmt.Sprintf("/uploads/%s", filename), 200, nil
+}
+
+// Helper: remove a file saved by saveImage (best effort)
+func removeUpload(publicURL string) {
+	if !strings.HasPrefix(publicURL, "/uploads/") {
+		return
+	}
+	_ = os.Remove("." + filepath.Clean(publicURL))
+}
+
+// Helper: HTTP status for money/currency errors (invalid input vs missing rate)
+func currencyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, money.ErrInvalidCurrency),
+		errors.Is(err, money.ErrInvalidAmount),
+		errors.Is(err, money.ErrInvalidRate),
+		errors.Is(err, money.ErrCurrencyMismatch):
+		return 400
+	case errors.Is(err, service.ErrRateNotFound):
+		return 422
+	default:
+		return 500
+	}
+}
@@ -1,18 +1,25 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"umrah-backend/internal/entity"
 	"umrah-backend/internal/service"
+	"umrah-backend/pkg/money"
 
+	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 )
 
 type PackageHandler struct {
-	svc service.PackageService
+	svc       service.PackageService
+	validator *validator.Validate
 }
 
 func NewPackageHandler(svc service.PackageService) *PackageHandler {
-	return &PackageHandler{svc: svc}
+	return &PackageHandler{svc: svc, validator: validator.New()}
 }
 
 // POST /packages (Admin)
@@ -22,21 +29,174 @@ func (h *PackageHandler) Create(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
 	}
 	if err := h.svc.CreatePackage(c.Context(), req); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return c.Status(currencyErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
 	}
 	return c.Status(201).JSON(fiber.Map{"message": "Package created"})
 }
 
-// GET /packages?category=UMRAH_PLUS
+// GET /admin/packages?status=inactive|scheduled|archived|all (plus the public search filters)
+func (h *PackageHandler) AdminList(c *fiber.Ctx) error {
+	search, err := parsePackageSearch(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	switch search.Status = c.Query("status"); search.Status {
+	case "", "inactive", "scheduled", "archived", "all":
+	default:
+		return c.Status(400).JSON(fiber.Map{"error": "status must be inactive, scheduled, archived or all"})
+	}
+
+	data, err := h.svc.GetList(c.Context(), search, c.Query("currency"))
+	if err != nil {
+		return c.Status(currencyErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(data)
+}
+
+// GET /admin/packages/:id (Any status, with content)
+func (h *PackageHandler) AdminGet(c *fiber.Ctx) error {
+	pkg, err := h.svc.GetPackageAdmin(c.Context(), c.Params("id"))
+	if err != nil {
+		return c.Status(packageErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(pkg)
+}
+
+// PUT /admin/packages/:id (Only the fields sent are changed)
+func (h *PackageHandler) Update(c *fiber.Ctx) error {
+	var req entity.UpdatePackageDTO
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	pkg, err := h.svc.UpdatePackage(c.Context(), c.Params("id"), req)
+	if err != nil {
+		return c.Status(packageErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(pkg)
+}
+
+// POST /admin/packages/:id/clone (Same package, new departure)
+func (h *PackageHandler) Clone(c *fiber.Ctx) error {
+	var req entity.ClonePackageDTO
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	pkg, err := h.svc.ClonePackage(c.Context(), c.Params("id"), req)
+	if err != nil {
+		return c.Status(packageErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(201).JSON(pkg)
+}
+
+// POST /admin/packages/:id/archive
+func (h *PackageHandler) Archive(c *fiber.Ctx) error {
+	pkg, err := h.svc.ArchivePackage(c.Context(), c.Params("id"))
+	if err != nil {
+		return c.Status(packageErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(pkg)
+}
+
+// GET /packages?q=&category=&min_price=&max_price=&price_currency=&departure_from=&departure_to=
+// &departure_city=&min_duration=&max_duration=&min_rating_makkah=&min_rating_madinah=&sort=&page=&limit=&currency=
 func (h *PackageHandler) GetList(c *fiber.Ctx) error {
-	cat := c.Query("category") // Optional filter
-	data, err := h.svc.GetList(c.Context(), cat)
+	search, err := parsePackageSearch(c)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	data, err := h.svc.GetList(c.Context(), search, c.Query("currency"))
+	if err != nil {
+		return c.Status(currencyErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
 	}
 	return c.JSON(data)
 }
 
+// GET /packages/:id?currency=USD (Detail with itinerary, inclusions, flights and hotels)
+func (h *PackageHandler) Get(c *fiber.Ctx) error {
+	pkg, err := h.svc.GetPackage(c.Context(), c.Params("id"), c.Query("currency"))
+	if err != nil {
+		return c.Status(packageErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(pkg)
+}
+
+// PUT /admin/packages/:id/itinerary (Replaces the template rundown)
+func (h *PackageHandler) SetItinerary(c *fiber.Ctx) error {
+	var req entity.SetItineraryDTO
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	pkg, err := h.svc.SetItinerary(c.Context(), c.Params("id"), req)
+	if err != nil {
+		return c.Status(packageErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(pkg)
+}
+
+// PUT /admin/packages/:id/inclusions
+func (h *PackageHandler) SetInclusions(c *fiber.Ctx) error {
+	var req entity.SetInclusionsDTO
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	pkg, err := h.svc.SetInclusions(c.Context(), c.Params("id"), req)
+	if err != nil {
+		return c.Status(packageErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(pkg)
+}
+
+// PUT /admin/packages/:id/flights
+func (h *PackageHandler) SetFlights(c *fiber.Ctx) error {
+	var req entity.SetFlightsDTO
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	pkg, err := h.svc.SetFlights(c.Context(), c.Params("id"), req)
+	if err != nil {
+		return c.Status(packageErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(pkg)
+}
+
+// PUT /admin/packages/:id/hotels
+func (h *PackageHandler) SetHotels(c *fiber.Ctx) error {
+	var req entity.SetHotelsDTO
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	pkg, err := h.svc.SetHotels(c.Context(), c.Params("id"), req)
+	if err != nil {
+		return c.Status(packageErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(pkg)
+}
+
 // POST /bookings
 func (h *PackageHandler) Book(c *fiber.Ctx) error {
 	userID, err := getUserID(c) // Gunakan Helper
@@ -44,20 +204,529 @@ func (h *PackageHandler) Book(c *fiber.Ctx) error {
 		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
 	}
 
+	var req entity.BookPackageDTO
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	// Pass c.Context()
+	booking, err := h.svc.BookPackage(c.Context(), userID, req)
+	if err != nil {
+		if errors.Is(err, service.ErrNotEnoughSeats) {
+			return c.Status(409).JSON(fiber.Map{"error": err.Error(), "can_join_waitlist": true})
+		}
+		if errors.Is(err, service.ErrPackageTermsChanged) {
+			return c.Status(409).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(201).JSON(booking)
+}
+
+// POST /bookings/quote (Preview a promo code on a booking)
+func (h *PackageHandler) Quote(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var req entity.BookingQuoteDTO
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	quote, err := h.svc.QuoteBooking(c.Context(), userID, req)
+	if err != nil {
+		status := 400
+		if errors.Is(err, service.ErrPromoNotFound) {
+			status = 404
+		}
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(quote)
+}
+
+// POST /bookings/:id/cancel
+func (h *PackageHandler) Cancel(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	booking, err := h.svc.CancelBooking(c.Context(), c.Params("id"), userID)
+	if err != nil {
+		return c.Status(bookingErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(booking)
+}
+
+// GET /bookings/:id/passengers
+func (h *PackageHandler) GetPassengers(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	role, _ := getUserRole(c)
+
+	passengers, err := h.svc.GetPassengers(c.Context(), c.Params("id"), userID, role)
+	if err != nil {
+		return c.Status(bookingErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(passengers)
+}
+
+// PUT /bookings/:id/passengers (replaces the whole list)
+func (h *PackageHandler) SetPassengers(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	role, _ := getUserRole(c)
+
+	var req entity.UpdatePassengersDTO
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	passengers, err := h.svc.SetPassengers(c.Context(), c.Params("id"), userID, role, req)
+	if err != nil {
+		return c.Status(bookingErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(passengers)
+}
+
+// POST /bookings/:id/payment-plan
+func (h *PackageHandler) CreatePaymentPlan(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	role, _ := getUserRole(c)
+
+	var req entity.CreatePaymentPlanDTO
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	balance, err := h.svc.CreatePaymentPlan(c.Context(), c.Params("id"), userID, role, req)
+	if err != nil {
+		return c.Status(bookingErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(201).JSON(balance)
+}
+
+// GET /bookings/:id/balance
+func (h *PackageHandler) GetBalance(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	role, _ := getUserRole(c)
+
+	balance, err := h.svc.GetBalance(c.Context(), c.Params("id"), userID, role)
+	if err != nil {
+		return c.Status(bookingErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(balance)
+}
+
+// POST /bookings/:id/installments/:installment_id/proof (Upload Image)
+func (h *PackageHandler) UploadInstallmentProof(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	publicURL, status, err := saveImage(c, "image", userID)
+	if err != nil {
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := h.svc.UploadInstallmentProof(c.Context(), c.Params("id"), c.Params("installment_id"), userID, publicURL); err != nil {
+		return c.Status(bookingErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"message": "Proof uploaded", "url": publicURL})
+}
+
+// PATCH /admin/installments/:id/verify
+func (h *PackageHandler) VerifyInstallment(c *fiber.Ctx) error {
+	adminID, err := getUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	balance, err := h.svc.VerifyInstallment(c.Context(), c.Params("id"), adminID)
+	if err != nil {
+		return c.Status(bookingErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(balance)
+}
+
+// GET /admin/bookings?package_id=&status=&user_id=&agent_id=&departure_from=&departure_to=&page=&limit=
+func (h *PackageHandler) ListBookings(c *fiber.Ctx) error {
+	filter, err := parseBookingFilter(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	bookings, total, err := h.svc.ListBookings(c.Context(), filter)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"data":  bookings,
+		"total": total,
+		"page":  filter.Page,
+		"limit": filter.Limit,
+	})
+}
+
+// GET /admin/bookings/export (same filters as list, CSV, no pagination)
+func (h *PackageHandler) ExportBookings(c *fiber.Ctx) error {
+	filter, err := parseBookingFilter(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	filter.Page, filter.Limit = 1, 0
+
+	bookings, _, err := h.svc.ListBookings(c.Context(), filter)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	header := []string{"booking_id", "status", "customer", "phone", "package", "departure_date", "room_type", "pax", "currency", "total_price", "created_at"}
+	rows := make([][]string, 0, len(bookings))
+	for _, b := range bookings {
+		var customer, phone, pkgName, departure string
+		if b.User != nil {
+			customer, phone = b.User.FullName, b.User.PhoneNumber
+		}
+		if b.Package != nil {
+			pkgName, departure = b.Package.Name, b.Package.DepartureDate.Format("2006-01-02")
+		}
+		rows = append(rows, []string{
+			b.ID.String(), string(b.Status), customer, phone, pkgName, departure,
+			b.RoomType, strconv.Itoa(b.PaxCount), string(b.TotalPrice.Currency), b.TotalPrice.Major(),
+			b.CreatedAt.Format("2006-01-02 15:04"),
+		})
+	}
+
+	return sendCSV(c, "bookings.csv", header, rows)
+}
+
+// PATCH /admin/bookings/:id/approve
+func (h *PackageHandler) ApproveBooking(c *fiber.Ctx) error {
+	booking, err := h.svc.ApproveBooking(c.Context(), c.Params("id"))
+	if err != nil {
+		return c.Status(bookingErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(booking)
+}
+
+// PATCH /admin/bookings/:id/cancel
+func (h *PackageHandler) AdminCancelBooking(c *fiber.Ctx) error {
 	var req struct {
-		PackageID string `json:"package_id"`
-		RoomType  string `json:"room_type"`
-		PaxCount  int    `json:"pax_count"`
+		Reason string `json:"reason"`
+	}
+	// Body is optional
+	_ = c.BodyParser(&req)
+
+	booking, err := h.svc.AdminCancelBooking(c.Context(), c.Params("id"), req.Reason)
+	if err != nil {
+		return c.Status(bookingErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(booking)
+}
+
+// GET /admin/packages/:id/manifest (?format=csv)
+func (h *PackageHandler) Manifest(c *fiber.Ctx) error {
+	bookings, err := h.svc.GetManifest(c.Context(), c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if c.Query("format") != "csv" {
+		return c.JSON(bookings)
+	}
+
+	// One row per passenger; bookings without a manifest yet get a placeholder row
+	header := []string{"no", "booking_id", "status", "customer", "phone", "room_type", "passenger", "gender", "birth_date", "passport_number", "passport_expiry", "mahram_relation", "mahram_name"}
+	var rows [][]string
+	for _, b := range bookings {
+		var customer, phone string
+		if b.User != nil {
+			customer, phone = b.User.FullName, b.User.PhoneNumber
+		}
+		prefix := []string{b.ID.String(), string(b.Status), customer, phone, b.RoomType}
+
+		if len(b.Passengers) == 0 {
+			row := append([]string{strconv.Itoa(len(rows) + 1)}, prefix...)
+			rows = append(rows, append(row, fmt.Sprintf("(%d pax, manifest not filled)", b.PaxCount), "", "", "", "", "", ""))
+			continue
+		}
+		for _, p := range b.Passengers {
+			row := append([]string{strconv.Itoa(len(rows) + 1)}, prefix...)
+			rows = append(rows, append(row,
+				p.FullName, string(p.Gender), p.BirthDate.Format("2006-01-02"),
+				p.PassportNumber, p.PassportExpiry.Format("2006-01-02"),
+				p.MahramRelation, p.MahramName,
+			))
+		}
+	}
+
+	return sendCSV(c, fmt.Sprintf("manifest_%s.csv", c.Params("id")), header, rows)
+}
+
+func parseBookingFilter(c *fiber.Ctx) (entity.BookingFilter, error) {
+	from, err := parseDateQuery(c, "departure_from")
+	if err != nil {
+		return entity.BookingFilter{}, err
+	}
+	to, err := parseDateQuery(c, "departure_to")
+	if err != nil {
+		return entity.BookingFilter{}, err
+	}
+
+	page := c.QueryInt("page", 1)
+	if page < 1 {
+		page = 1
+	}
+	limit := c.QueryInt("limit", 20)
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	return entity.BookingFilter{
+		PackageID:     c.Query("package_id"),
+		UserID:        c.Query("user_id"),
+		AgentID:       c.Query("agent_id"),
+		Status:        entity.BookingStatus(c.Query("status")),
+		DepartureFrom: from,
+		DepartureTo:   to,
+		Page:          page,
+		Limit:         limit,
+	}, nil
+}
+
+func parsePackageSearch(c *fiber.Ctx) (entity.PackageSearch, error) {
+	search := entity.PackageSearch{
+		Query:            strings.TrimSpace(c.Query("q")),
+		Category:         c.Query("category"),
+		DepartureCity:    strings.TrimSpace(c.Query("departure_city")),
+		MinDuration:      c.QueryInt("min_duration"),
+		MaxDuration:      c.QueryInt("max_duration"),
+		MinRatingMakkah:  c.QueryInt("min_rating_makkah"),
+		MinRatingMadinah: c.QueryInt("min_rating_madinah"),
+		Sort:             c.Query("sort"),
+		Page:             c.QueryInt("page", 1),
+		Limit:            c.QueryInt("limit", 20),
 	}
+	switch search.Sort {
+	case "", "price_asc", "price_desc", "duration_asc", "duration_desc", "newest":
+	default:
+		return search, errors.New("sort must be one of price_asc, price_desc, duration_asc, duration_desc, newest")
+	}
+	if search.Page < 1 {
+		search.Page = 1
+	}
+	if search.Limit <= 0 || search.Limit > 100 {
+		search.Limit = 20
+	}
+
+	var err error
+	if search.DepartureFrom, err = parseDateQuery(c, "departure_from"); err != nil {
+		return search, err
+	}
+	if search.DepartureTo, err = parseDateQuery(c, "departure_to"); err != nil {
+		return search, err
+	}
+	if search.MinPrice, err = parseMinorQuery(c, "min_price"); err != nil {
+		return search, err
+	}
+	if search.MaxPrice, err = parseMinorQuery(c, "max_price"); err != nil {
+		return search, err
+	}
+	if raw := c.Query("price_currency"); raw != "" {
+		if search.PriceCurrency, err = money.ParseCurrency(raw); err != nil {
+			return search, err
+		}
+	}
+	return search, nil
+}
+
+// parseMinorQuery parses an optional amount in minor units, like the
+// "amount" of money in request bodies.
+func parseMinorQuery(c *fiber.Ctx, key string) (*int64, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+	n, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("invalid %s (use a non-negative amount in minor units)", key)
+	}
+	return &n, nil
+}
+
+// GET /admin/packages/:id/versions (Terms history with what changed per version)
+func (h *PackageHandler) Versions(c *fiber.Ctx) error {
+	versions, err := h.svc.GetPackageVersions(c.Context(), c.Params("id"))
+	if err != nil {
+		return c.Status(packageErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(versions)
+}
+
+// GET /bookings/:id/terms (Package terms the booking was sold under)
+func (h *PackageHandler) BookingTerms(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	role, _ := getUserRole(c)
+
+	version, err := h.svc.GetBookingTerms(c.Context(), c.Params("id"), userID, role)
+	if err != nil {
+		return c.Status(bookingErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(version)
+}
+
+// --- WAITLIST ---
+
+// POST /waitlist (Queue for a sold-out package)
+func (h *PackageHandler) JoinWaitlist(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var req entity.JoinWaitlistDTO
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
 	}
+	if err := h.validator.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
 
-	// Pass c.Context()
-	booking, err := h.svc.BookPackage(c.Context(), userID, req.PackageID, req.RoomType, req.PaxCount)
+	entry, err := h.svc.JoinWaitlist(c.Context(), userID, req)
+	if err != nil {
+		return c.Status(waitlistErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(201).JSON(entry)
+}
+
+// GET /waitlist/my
+func (h *PackageHandler) MyWaitlist(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
 	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	entries, err := h.svc.GetMyWaitlist(c.Context(), userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(entries)
+}
+
+// POST /waitlist/:id/cancel
+func (h *PackageHandler) LeaveWaitlist(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	entry, err := h.svc.LeaveWaitlist(c.Context(), c.Params("id"), userID)
+	if err != nil {
+		return c.Status(waitlistErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(entry)
+}
+
+// POST /waitlist/:id/claim (Books the seats held by an open offer)
+func (h *PackageHandler) ClaimWaitlist(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var req entity.ClaimWaitlistDTO
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+		}
+	}
+	if err := h.validator.Struct(req); err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
 
+	booking, err := h.svc.ClaimWaitlistOffer(c.Context(), c.Params("id"), userID, req)
+	if err != nil {
+		return c.Status(waitlistErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
 	return c.Status(201).JSON(booking)
 }
+
+// GET /admin/packages/:id/waitlist
+func (h *PackageHandler) Waitlist(c *fiber.Ctx) error {
+	entries, err := h.svc.GetWaitlist(c.Context(), c.Params("id"))
+	if err != nil {
+		return c.Status(waitlistErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(entries)
+}
+
+func waitlistErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrPackageNotFound), errors.Is(err, service.ErrWaitlistEntryNotFound):
+		return 404
+	case errors.Is(err, service.ErrNoWaitlistOffer), errors.Is(err, service.ErrAlreadyOnWaitlist):
+		return 409
+	case err.Error() == "unauthorized":
+		return 403
+	default:
+		return 400
+	}
+}
+
+func packageErrorStatus(err error) int {
+	if errors.Is(err, service.ErrPackageNotFound) {
+		return 404
+	}
+	if errors.Is(err, service.ErrPackageArchived) {
+		return 409
+	}
+	if errors.Is(err, service.ErrRateNotFound) {
+		return 422
+	}
+	return 400
+}
+
+// bookingErrorStatus maps booking lifecycle errors to HTTP status codes.
+func bookingErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrBookingNotFound), errors.Is(err, service.ErrInstallmentNotFound),
+		errors.Is(err, service.ErrPackageVersionNotFound):
+		return 404
+	case errors.Is(err, service.ErrInvalidBookingTransition):
+		return 409
+	case err.Error() == "unauthorized":
+		return 403
+	default:
+		return 400
+	}
+}
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"errors"
+	"umrah-backend/internal/entity"
+	"umrah-backend/internal/service"
+	"umrah-backend/pkg/payment"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type PaymentHandler struct {
+	svc       service.PaymentService
+	simulator *payment.Simulator // nil unless PAYMENT_SIMULATOR_ENABLED
+	validator *validator.Validate
+}
+
+func NewPaymentHandler(svc service.PaymentService, simulator *payment.Simulator) *PaymentHandler {
+	return &PaymentHandler{svc: svc, simulator: simulator, validator: validator.New()}
+}
+
+// POST /orders/:id/pay
+func (h *PaymentHandler) PayOrder(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var req entity.CreateChargeDTO
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	trx, err := h.svc.CreateOrderCharge(c.Context(), userID, c.Params("id"), req)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(201).JSON(trx)
+}
+
+// POST /bookings/:id/installments/:installment_id/pay
+func (h *PaymentHandler) PayInstallment(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var req entity.CreateChargeDTO
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	trx, err := h.svc.CreateInstallmentCharge(c.Context(), userID, c.Params("id"), c.Params("installment_id"), req)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(201).JSON(trx)
+}
+
+// GET /payments/:id (poll status)
+func (h *PaymentHandler) Get(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	role, _ := getUserRole(c)
+
+	trx, err := h.svc.GetTransaction(c.Context(), userID, role, c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(trx)
+}
+
+// POST /payments/webhook/:provider (Public, signature-verified)
+func (h *PaymentHandler) Webhook(c *fiber.Ctx) error {
+	headers := make(map[string]string)
+	c.Request().Header.VisitAll(func(key, value []byte) {
+		headers[string(key)] = string(value)
+	})
+
+	err := h.svc.HandleWebhook(c.Context(), c.Params("provider"), headers, c.Body())
+	switch {
+	case err == nil:
+		return c.JSON(fiber.Map{"message": "OK"})
+	case errors.Is(err, payment.ErrInvalidSignature):
+		return c.Status(401).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrUnknownProvider), errors.Is(err, service.ErrTransactionNotFound):
+		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrAmountMismatch):
+		// Recorded as rejected; a retry would not change the answer
+		return c.Status(422).JSON(fiber.Map{"error": err.Error()})
+	default:
+		// 5xx makes the provider retry the callback later
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+}
+
+// POST /admin/payments/simulator/:external_id/:status (Simulator only)
+// Plays the gateway: sends a signed callback for a simulator charge.
+func (h *PaymentHandler) Simulate(c *fiber.Ctx) error {
+	if h.simulator == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Simulator disabled"})
+	}
+
+	var req struct {
+		Amount int64 `json:"amount"` // Minor units, 0 = exact charge amount
+	}
+	// Body is optional
+	_ = c.BodyParser(&req)
+
+	body, headers, err := h.simulator.SimulateCallback(c.Params("external_id"), payment.EventStatus(c.Params("status")), req.Amount)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := h.svc.HandleWebhook(c.Context(), h.simulator.Name(), headers, body); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "Callback delivered", "payload": string(body)})
+}
+
+// GET /admin/payments?status=NEEDS_REFUND
+func (h *PaymentHandler) List(c *fiber.Ctx) error {
+	trxs, err := h.svc.GetTransactions(c.Context(), c.Query("status"))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(trxs)
+}
+
+// POST /admin/payments/:id/refund
+func (h *PaymentHandler) Refund(c *fiber.Ctx) error {
+	adminID, err := getUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	// Body is optional
+	_ = c.BodyParser(&req)
+
+	trx, err := h.svc.Refund(c.Context(), adminID, c.Params("id"), req.Reason)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(trx)
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"errors"
+	"umrah-backend/internal/entity"
+	"umrah-backend/internal/service"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type PromoHandler struct {
+	svc       service.PromoService
+	validator *validator.Validate
+}
+
+func NewPromoHandler(svc service.PromoService) *PromoHandler {
+	return &PromoHandler{svc: svc, validator: validator.New()}
+}
+
+// GET /admin/promo-codes?active=true
+func (h *PromoHandler) List(c *fiber.Ctx) error {
+	promos, err := h.svc.GetPromos(c.Context(), c.QueryBool("active"))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(promos)
+}
+
+// GET /admin/promo-codes/:id
+func (h *PromoHandler) Get(c *fiber.Ctx) error {
+	promo, err := h.svc.GetPromo(c.Context(), c.Params("id"))
+	if err != nil {
+		return c.Status(promoErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(promo)
+}
+
+// POST /admin/promo-codes
+func (h *PromoHandler) Create(c *fiber.Ctx) error {
+	var req entity.PromoCodeDTO
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	promo, err := h.svc.CreatePromo(c.Context(), req)
+	if err != nil {
+		return c.Status(promoErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(201).JSON(promo)
+}
+
+// PUT /admin/promo-codes/:id (Full replace; the usage counter is kept)
+func (h *PromoHandler) Update(c *fiber.Ctx) error {
+	var req entity.PromoCodeDTO
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	promo, err := h.svc.UpdatePromo(c.Context(), c.Params("id"), req)
+	if err != nil {
+		return c.Status(promoErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(promo)
+}
+
+// GET /admin/promo-codes/:id/redemptions
+func (h *PromoHandler) Redemptions(c *fiber.Ctx) error {
+	redemptions, err := h.svc.GetRedemptions(c.Context(), c.Params("id"))
+	if err != nil {
+		return c.Status(promoErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(redemptions)
+}
+
+func promoErrorStatus(err error) int {
+	if errors.Is(err, service.ErrPromoNotFound) {
+		return 404
+	}
+	return 400
+}
@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"umrah-backend/internal/entity"
+	"umrah-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Every report accepts ?format=csv for a spreadsheet-friendly download.
+// Amounts in CSV are in major units without grouping ("35000000.00").
+type ReportHandler struct {
+	svc service.ReportService
+}
+
+func NewReportHandler(svc service.ReportService) *ReportHandler {
+	return &ReportHandler{svc: svc}
+}
+
+// GET /admin/reports/revenue?granularity=day|month&from=YYYY-MM-DD&to=YYYY-MM-DD (to is exclusive)
+func (h *ReportHandler) Revenue(c *fiber.Ctx) error {
+	filter, err := parseReportFilter(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	report, err := h.svc.Revenue(c.Context(), filter)
+	if err != nil {
+		return c.Status(reportErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if c.Query("format") == "csv" {
+		header := []string{"period", "currency", "orders", "bookings", "total", "order_count", "payment_count"}
+		rows := make([][]string, 0, len(report.Points))
+		for _, p := range report.Points {
+			rows = append(rows, []string{
+				p.Period, string(p.Total.Currency), p.Orders.Major(), p.Bookings.Major(), p.Total.Major(),
+				strconv.FormatInt(p.OrderCount, 10), strconv.FormatInt(p.PaymentCount, 10),
+			})
+		}
+		return sendCSV(c, fmt.Sprintf("revenue-%s.csv", report.Granularity), header, rows)
+	}
+	return c.JSON(report)
+}
+
+// GET /admin/reports/revenue/products?from=&to=
+func (h *ReportHandler) RevenueByProduct(c *fiber.Ctx) error {
+	filter, err := parseReportFilter(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	products, err := h.svc.RevenueByProduct(c.Context(), filter)
+	if err != nil {
+		return c.Status(reportErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if c.Query("format") == "csv" {
+		header := []string{"product_id", "product_name", "currency", "revenue", "quantity", "orders"}
+		rows := make([][]string, 0, len(products))
+		for _, p := range products {
+			rows = append(rows, []string{
+				p.ProductID.String(), p.ProductName, string(p.Revenue.Currency), p.Revenue.Major(),
+				strconv.FormatInt(p.Quantity, 10), strconv.FormatInt(p.Orders, 10),
+			})
+		}
+		return sendCSV(c, "revenue-by-product.csv", header, rows)
+	}
+	return c.JSON(products)
+}
+
+// GET /admin/reports/revenue/categories?from=&to=
+func (h *ReportHandler) RevenueByCategory(c *fiber.Ctx) error {
+	filter, err := parseReportFilter(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	categories, err := h.svc.RevenueByCategory(c.Context(), filter)
+	if err != nil {
+		return c.Status(reportErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if c.Query("format") == "csv" {
+		header := []string{"category", "currency", "revenue", "payments", "bookings", "pax", "booked_value"}
+		rows := make([][]string, 0, len(categories))
+		for _, r := range categories {
+			rows = append(rows, []string{
+				string(r.Category), string(r.Revenue.Currency), r.Revenue.Major(), strconv.FormatInt(r.Payments, 10),
+				strconv.FormatInt(r.Bookings, 10), strconv.FormatInt(r.Pax, 10), r.BookedValue.Major(),
+			})
+		}
+		return sendCSV(c, "revenue-by-category.csv", header, rows)
+	}
+	return c.JSON(categories)
+}
+
+// GET /admin/reports/occupancy?departure_from=&departure_to= (default: upcoming departures)
+func (h *ReportHandler) Occupancy(c *fiber.Ctx) error {
+	from, err := parseDateQuery(c, "departure_from")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	to, err := parseDateQuery(c, "departure_to")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	packages, err := h.svc.Occupancy(c.Context(), from, to)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if c.Query("format") == "csv" {
+		header := []string{"package_id", "name", "category", "departure_date", "quota", "available", "booked", "occupancy_percent", "pending_pax", "paid_pax", "confirmed_pax"}
+		rows := make([][]string, 0, len(packages))
+		for _, p := range packages {
+			rows = append(rows, []string{
+				p.PackageID.String(), p.Name, string(p.Category), p.DepartureDate.Format("2006-01-02"),
+				strconv.Itoa(p.Quota), strconv.Itoa(p.Available), strconv.Itoa(p.Booked),
+				strconv.FormatFloat(p.Occupancy, 'f', 1, 64),
+				strconv.Itoa(p.PendingPax), strconv.Itoa(p.PaidPax), strconv.Itoa(p.ConfirmedPax),
+			})
+		}
+		return sendCSV(c, "occupancy.csv", header, rows)
+	}
+	return c.JSON(packages)
+}
+
+// GET /admin/reports/outstanding (unpaid orders + unpaid booking balances)
+func (h *ReportHandler) Outstanding(c *fiber.Ctx) error {
+	report, err := h.svc.Outstanding(c.Context())
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if c.Query("format") == "csv" {
+		header := []string{"source", "id", "customer", "phone", "description", "status", "currency", "total", "paid", "outstanding", "due_date", "overdue"}
+		rows := make([][]string, 0, len(report.Items))
+		for _, item := range report.Items {
+			due := ""
+			if item.DueDate != nil {
+				due = item.DueDate.Format("2006-01-02 15:04")
+			}
+			rows = append(rows, []string{
+				item.Source, item.ID.String(), item.Customer, item.Phone, item.Description, item.Status,
+				string(item.Outstanding.Currency), item.Total.Major(), item.Paid.Major(), item.Outstanding.Major(),
+				due, strconv.FormatBool(item.Overdue),
+			})
+		}
+		return sendCSV(c, "outstanding.csv", header, rows)
+	}
+	return c.JSON(report)
+}
+
+func parseReportFilter(c *fiber.Ctx) (entity.ReportFilter, error) {
+	from, err := parseDateQuery(c, "from")
+	if err != nil {
+		return entity.ReportFilter{}, err
+	}
+	to, err := parseDateQuery(c, "to")
+	if err != nil {
+		return entity.ReportFilter{}, err
+	}
+	return entity.ReportFilter{
+		From:        from,
+		To:          to,
+		Granularity: entity.ReportGranularity(c.Query("granularity")),
+	}, nil
+}
+
+func reportErrorStatus(err error) int {
+	if errors.Is(err, service.ErrInvalidReportRange) {
+		return 400
+	}
+	return 500
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"umrah-backend/internal/entity"
+	"umrah-backend/internal/service"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type RoomingHandler struct {
+	svc       service.RoomingService
+	validator *validator.Validate
+}
+
+func NewRoomingHandler(svc service.RoomingService) *RoomingHandler {
+	return &RoomingHandler{svc: svc, validator: validator.New()}
+}
+
+// POST /admin/packages/:id/rooming/generate
+func (h *RoomingHandler) Generate(c *fiber.Ctx) error {
+	list, err := h.svc.GenerateRooming(c.Context(), c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(list)
+}
+
+// GET /admin/packages/:id/rooming?format=csv&hotel=makkah|madinah
+func (h *RoomingHandler) Get(c *fiber.Ctx) error {
+	list, err := h.svc.GetRooming(c.Context(), c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if c.Query("format") != "csv" {
+		return c.JSON(list)
+	}
+
+	hotel := list.Package.HotelMakkah
+	if strings.EqualFold(c.Query("hotel"), "madinah") {
+		hotel = list.Package.HotelMadinah
+	}
+
+	header := []string{"hotel", "room", "room_type", "room_gender", "guest", "gender", "passport_number", "booking_id"}
+	var rows [][]string
+	for _, room := range list.Rooms {
+		for _, o := range room.Occupants {
+			if o.Passenger == nil {
+				continue
+			}
+			rows = append(rows, []string{
+				hotel, room.Label, room.RoomType, room.Gender,
+				o.Passenger.FullName, string(o.Passenger.Gender), o.Passenger.PassportNumber,
+				o.Passenger.BookingID.String(),
+			})
+		}
+	}
+
+	return sendCSV(c, fmt.Sprintf("rooming_%s.csv", c.Params("id")), header, rows)
+}
+
+// POST /admin/packages/:id/rooms (create an empty room for manual placement)
+func (h *RoomingHandler) CreateRoom(c *fiber.Ctx) error {
+	var req entity.CreateRoomDTO
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	room, err := h.svc.CreateRoom(c.Context(), c.Params("id"), req)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(201).JSON(room)
+}
+
+// PUT /admin/rooming/assignments (admin override)
+func (h *RoomingHandler) Assign(c *fiber.Ctx) error {
+	var req entity.AssignRoomDTO
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	assignment, err := h.svc.AssignRoom(c.Context(), req)
+	if err != nil {
+		return c.Status(roomingErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(assignment)
+}
+
+// DELETE /admin/rooming/assignments/:passenger_id
+func (h *RoomingHandler) Unassign(c *fiber.Ctx) error {
+	passengerID := c.Params("passenger_id")
+	if _, err := uuid.Parse(passengerID); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid passenger_id"})
+	}
+	if err := h.svc.UnassignRoom(c.Context(), passengerID); err != nil {
+		if errors.Is(err, service.ErrRoomAssignmentNotFound) {
+			return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "Passenger removed from room"})
+}
+
+func roomingErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrPassengerNotFound), errors.Is(err, service.ErrRoomNotFound),
+		errors.Is(err, service.ErrRoomAssignmentNotFound), errors.Is(err, service.ErrBookingNotFound):
+		return 404
+	default:
+		return 400
+	}
+}
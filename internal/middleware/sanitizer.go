@@ -2,13 +2,26 @@ package middleware
 
 import (
 	"encoding/json"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/microcosm-cc/bluemonday"
 )
 
+// Paths whose raw body must reach the handler untouched
+// (payment callbacks are signed over the exact bytes sent).
+var sanitizerSkipPrefixes = []string{
+	"/api/payments/webhook/",
+}
+
 // XSSSanitizer cleans all incoming JSON bodies
 func XSSSanitizer(c *fiber.Ctx) error {
+	for _, prefix := range sanitizerSkipPrefixes {
+		if strings.HasPrefix(c.Path(), prefix) {
+			return c.Next()
+		}
+	}
+
 	// Only run on methods that have a body
 	if c.Method() == "POST" || c.Method() == "PUT" || c.Method() == "PATCH" {
 		var body map[string]interface{}
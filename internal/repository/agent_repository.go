@@ -0,0 +1,243 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"umrah-backend/internal/entity"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CommissionTotalRow: sum of one agent's commissions per currency and status.
+type CommissionTotalRow struct {
+	Currency string
+	Status   string
+	Amount   int64
+}
+
+type AgentRepository interface {
+	WithTx(ctx context.Context, fn func(repo AgentRepository) error) error
+
+	// Agents
+	CreateAgent(ctx context.Context, agent *entity.Agent) error
+	UpdateAgent(ctx context.Context, agent *entity.Agent) error
+	FindAgentByID(ctx context.Context, id string) (*entity.Agent, error)
+	FindAgentByUserID(ctx context.Context, userID string) (*entity.Agent, error)
+	FindAgentByCode(ctx context.Context, code string) (*entity.Agent, error)
+	GetAgents(ctx context.Context) ([]entity.Agent, error)
+	CountSubAgents(ctx context.Context, agentID string) (int64, error)
+	SetUserRole(ctx context.Context, userID, role string) error
+
+	// Commission Rules (packageID nil = defaults)
+	GetRules(ctx context.Context, packageID *uuid.UUID) ([]entity.CommissionRule, error)
+	ReplaceRules(ctx context.Context, packageID *uuid.UUID, rules []entity.CommissionRule) error
+	FindApplicableRules(ctx context.Context, packageID string) ([]entity.CommissionRule, error) // Package rules + defaults
+
+	// Commissions
+	CreateCommissions(ctx context.Context, commissions []entity.Commission) error // Existing (booking, level) rows are kept
+	CancelCommissions(ctx context.Context, bookingID string, at time.Time) (int64, error)
+	GetCommissions(ctx context.Context, agentID string, from, to *time.Time) ([]entity.Commission, error)
+	GetCommissionTotals(ctx context.Context, agentID string) ([]CommissionTotalRow, error)
+	FindUncommissionedBookingIDs(ctx context.Context, agentID string) ([]string, error)
+
+	// Payouts
+	FindEarnedCommissions(ctx context.Context, agentID, currency string) ([]entity.Commission, error) // Locks the rows
+	CreatePayout(ctx context.Context, payout *entity.CommissionPayout) error
+	MarkCommissionsPaid(ctx context.Context, ids []uuid.UUID, payoutID uuid.UUID, at time.Time) error
+	GetPayouts(ctx context.Context, agentID string, from, to *time.Time) ([]entity.CommissionPayout, error)
+}
+
+type agentRepo struct {
+	db *gorm.DB
+}
+
+func NewAgentRepository(db *gorm.DB) AgentRepository {
+	return &agentRepo{db: db}
+}
+
+func (r *agentRepo) WithTx(ctx context.Context, fn func(repo AgentRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&agentRepo{db: tx})
+	})
+}
+
+func (r *agentRepo) CreateAgent(ctx context.Context, agent *entity.Agent) error {
+	return r.db.WithContext(ctx).Omit("User", "Parent").Create(agent).Error
+}
+
+func (r *agentRepo) UpdateAgent(ctx context.Context, agent *entity.Agent) error {
+	return r.db.WithContext(ctx).Omit("User", "Parent").Save(agent).Error
+}
+
+func (r *agentRepo) FindAgentByID(ctx context.Context, id string) (*entity.Agent, error) {
+	var agent entity.Agent
+	if err := r.db.WithContext(ctx).Preload("User").First(&agent, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &agent, nil
+}
+
+func (r *agentRepo) FindAgentByUserID(ctx context.Context, userID string) (*entity.Agent, error) {
+	var agent entity.Agent
+	if err := r.db.WithContext(ctx).Preload("User").First(&agent, "user_id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+	return &agent, nil
+}
+
+func (r *agentRepo) FindAgentByCode(ctx context.Context, code string) (*entity.Agent, error) {
+	var agent entity.Agent
+	if err := r.db.WithContext(ctx).First(&agent, "referral_code = ?", code).Error; err != nil {
+		return nil, err
+	}
+	return &agent, nil
+}
+
+func (r *agentRepo) GetAgents(ctx context.Context) ([]entity.Agent, error) {
+	var agents []entity.Agent
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Order("created_at asc").
+		Find(&agents).Error
+	return agents, err
+}
+
+func (r *agentRepo) CountSubAgents(ctx context.Context, agentID string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&entity.Agent{}).Where("parent_id = ?", agentID).Count(&count).Error
+	return count, err
+}
+
+func (r *agentRepo) SetUserRole(ctx context.Context, userID, role string) error {
+	return r.db.WithContext(ctx).Model(&entity.User{}).Where("id = ?", userID).Update("role", role).Error
+}
+
+func (r *agentRepo) GetRules(ctx context.Context, packageID *uuid.UUID) ([]entity.CommissionRule, error) {
+	var rules []entity.CommissionRule
+	query := r.db.WithContext(ctx)
+	if packageID == nil {
+		query = query.Where("package_id IS NULL")
+	} else {
+		query = query.Where("package_id = ?", *packageID)
+	}
+	err := query.Order("level asc").Find(&rules).Error
+	return rules, err
+}
+
+func (r *agentRepo) ReplaceRules(ctx context.Context, packageID *uuid.UUID, rules []entity.CommissionRule) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := tx
+		if packageID == nil {
+			query = query.Where("package_id IS NULL")
+		} else {
+			query = query.Where("package_id = ?", *packageID)
+		}
+		if err := query.Delete(&entity.CommissionRule{}).Error; err != nil {
+			return err
+		}
+		if len(rules) == 0 {
+			return nil
+		}
+		return tx.Create(&rules).Error
+	})
+}
+
+func (r *agentRepo) FindApplicableRules(ctx context.Context, packageID string) ([]entity.CommissionRule, error) {
+	var rules []entity.CommissionRule
+	err := r.db.WithContext(ctx).
+		Where("package_id = ? OR package_id IS NULL", packageID).
+		Find(&rules).Error
+	return rules, err
+}
+
+func (r *agentRepo) CreateCommissions(ctx context.Context, commissions []entity.Commission) error {
+	if len(commissions) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "booking_id"}, {Name: "level"}}, DoNothing: true}).
+		Create(&commissions).Error
+}
+
+// CancelCommissions voids the unpaid commissions of a booking. Paid ones are
+// kept: the money already left, recovering it is up to the finance team.
+func (r *agentRepo) CancelCommissions(ctx context.Context, bookingID string, at time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&entity.Commission{}).
+		Where("booking_id = ? AND status = ?", bookingID, entity.CommissionEarned).
+		Updates(map[string]interface{}{"status": entity.CommissionCancelled, "cancelled_at": at})
+	return result.RowsAffected, result.Error
+}
+
+func (r *agentRepo) GetCommissions(ctx context.Context, agentID string, from, to *time.Time) ([]entity.Commission, error) {
+	query := r.db.WithContext(ctx).Where("agent_id = ?", agentID)
+	if from != nil {
+		query = query.Where("earned_at >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("earned_at < ?", *to)
+	}
+
+	var commissions []entity.Commission
+	err := query.Order("earned_at desc").Find(&commissions).Error
+	return commissions, err
+}
+
+func (r *agentRepo) GetCommissionTotals(ctx context.Context, agentID string) ([]CommissionTotalRow, error) {
+	var rows []CommissionTotalRow
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT amount_currency AS currency, status, SUM(amount_minor) AS amount
+		FROM commissions
+		WHERE agent_id = ?
+		GROUP BY amount_currency, status
+		ORDER BY amount_currency`, agentID).Scan(&rows).Error
+	return rows, err
+}
+
+// FindUncommissionedBookingIDs: confirmed bookings referred by the agent
+// or its sub-agents that have no commission rows yet.
+func (r *agentRepo) FindUncommissionedBookingIDs(ctx context.Context, agentID string) ([]string, error) {
+	var ids []string
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT b.id FROM bookings b
+		WHERE b.status = ?
+		  AND (b.agent_id = ? OR b.agent_id IN (SELECT id FROM agents WHERE parent_id = ?))
+		  AND NOT EXISTS (SELECT 1 FROM commissions c WHERE c.booking_id = b.id)`,
+		entity.BookingConfirmed, agentID, agentID).Scan(&ids).Error
+	return ids, err
+}
+
+func (r *agentRepo) FindEarnedCommissions(ctx context.Context, agentID, currency string) ([]entity.Commission, error) {
+	var commissions []entity.Commission
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("agent_id = ? AND status = ? AND amount_currency = ?", agentID, entity.CommissionEarned, currency).
+		Order("earned_at asc").
+		Find(&commissions).Error
+	return commissions, err
+}
+
+func (r *agentRepo) CreatePayout(ctx context.Context, payout *entity.CommissionPayout) error {
+	return r.db.WithContext(ctx).Create(payout).Error
+}
+
+func (r *agentRepo) MarkCommissionsPaid(ctx context.Context, ids []uuid.UUID, payoutID uuid.UUID, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&entity.Commission{}).
+		Where("id IN ?", ids).
+		Updates(map[string]interface{}{"status": entity.CommissionPaid, "payout_id": payoutID, "paid_at": at}).Error
+}
+
+func (r *agentRepo) GetPayouts(ctx context.Context, agentID string, from, to *time.Time) ([]entity.CommissionPayout, error) {
+	query := r.db.WithContext(ctx).Where("agent_id = ?", agentID)
+	if from != nil {
+		query = query.Where("paid_at >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("paid_at < ?", *to)
+	}
+
+	var payouts []entity.CommissionPayout
+	err := query.Order("paid_at desc").Find(&payouts).Error
+	return payouts, err
+}
@@ -2,24 +2,79 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"strings"
+	"time"
 	"umrah-backend/internal/entity"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+var ErrInsufficientStock = errors.New("insufficient stock")
+
 type CommerceRepository interface {
+	// Transaction: fn receives a repository bound to the tx
+	WithTx(ctx context.Context, fn func(repo CommerceRepository) error) error
+
 	// Product Management (Admin View)
 	CreateProduct(ctx context.Context, product *entity.Product) error
-	GetActiveProducts(ctx context.Context) ([]entity.Product, error)
+	GetProducts(ctx context.Context, filter entity.ProductFilter) ([]entity.Product, error)
 	FindProductByID(ctx context.Context, id string) (*entity.Product, error)
-	// UpdateProduct, DeleteProduct, etc. would go here, but omitted for now.
+	FindProductsByIDs(ctx context.Context, ids []string) ([]entity.Product, error)
+	UpdateProductFields(ctx context.Context, id string, fields map[string]interface{}) error
+	DeleteProduct(ctx context.Context, id string) error // Soft delete
+	FindVariantByID(ctx context.Context, id string) (*entity.ProductVariant, error)
+	UpdateVariantFields(ctx context.Context, id string, fields map[string]interface{}) error
+
+	// Product Images
+	CreateProductImage(ctx context.Context, image *entity.ProductImage) error
+	DeleteProductImage(ctx context.Context, productID, imageID string) (*entity.ProductImage, error)
+
+	// Product Categories
+	CreateCategory(ctx context.Context, category *entity.ProductCategory) error
+	GetCategories(ctx context.Context) ([]entity.ProductCategory, error)
+	FindCategoryByID(ctx context.Context, id string) (*entity.ProductCategory, error)
+	UpdateCategory(ctx context.Context, category *entity.ProductCategory) error
+	DeleteCategory(ctx context.Context, id string) error
+
+	// Inventory (atomic, like PackageRepository.DecreaseQuota)
+	DecreaseProductStock(ctx context.Context, productID string, count int) error
+	IncreaseProductStock(ctx context.Context, productID string, count int) error
+	DecreaseVariantStock(ctx context.Context, variantID string, count int) error
+	IncreaseVariantStock(ctx context.Context, variantID string, count int) error
 
 	// Order Management (User/Admin View)
 	CreateOrder(ctx context.Context, order *entity.Order) error
 	FindOrderByID(ctx context.Context, id string) (*entity.Order, error)
 	GetOrdersByUser(ctx context.Context, userID string) ([]entity.Order, error)
 	GetPendingOrders(ctx context.Context) ([]entity.Order, error)
+	ListOrders(ctx context.Context, filter entity.OrderFilter) ([]entity.Order, error)
 	UpdateOrder(ctx context.Context, order *entity.Order) error
+	FindExpiredOrderIDs(ctx context.Context, now time.Time, limit int) ([]string, error)
+
+	// Audit Trail
+	CreateEvent(ctx context.Context, event *entity.OrderEvent) error
+	GetEvents(ctx context.Context, orderID string) ([]entity.OrderEvent, error)
+
+	// Refund Requests
+	CreateRefundRequest(ctx context.Context, req *entity.RefundRequest) error
+	FindRefundRequestByID(ctx context.Context, id string) (*entity.RefundRequest, error)
+	GetRefundRequests(ctx context.Context, status string) ([]entity.RefundRequest, error)
+	UpdateRefundRequest(ctx context.Context, req *entity.RefundRequest) error
+
+	// Proof History
+	CreateProof(ctx context.Context, proof *entity.OrderProof) error
+	GetProofs(ctx context.Context, orderID string) ([]entity.OrderProof, error)
+	FindOpenProof(ctx context.Context, orderID string) (*entity.OrderProof, error)
+	UpdateProof(ctx context.Context, proof *entity.OrderProof) error
+
+	// Cart
+	GetCartItems(ctx context.Context, userID string) ([]entity.CartItem, error)
+	FindCartItem(ctx context.Context, userID, productID, variantID string) (*entity.CartItem, error)
+	SaveCartItem(ctx context.Context, item *entity.CartItem) error
+	DeleteCartItem(ctx context.Context, userID, productID, variantID string) error
+	ClearCart(ctx context.Context, userID string) error
 }
 
 type commerceRepo struct {
@@ -30,6 +85,12 @@ func NewCommerceRepository(db *gorm.DB) CommerceRepository {
 	return &commerceRepo{db: db}
 }
 
+func (r *commerceRepo) WithTx(ctx context.Context, fn func(repo CommerceRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&commerceRepo{db: tx})
+	})
+}
+
 // -----------------------------------------------------------
 // Implementation: Product
 // -----------------------------------------------------------
@@ -38,33 +99,247 @@ func (r *commerceRepo) CreateProduct(ctx context.Context, product *entity.Produc
 	return r.db.WithContext(ctx).Create(product).Error
 }
 
-func (r *commerceRepo) GetActiveProducts(ctx context.Context) ([]entity.Product, error) {
+// activeVariants: preload condition shared by the product queries
+func activeVariants(db *gorm.DB) *gorm.DB {
+	return db.Where("is_active = ?", true).Order("created_at asc")
+}
+
+func productImages(db *gorm.DB) *gorm.DB {
+	return db.Order("sort_order asc, created_at asc")
+}
+
+// GetProducts lists the catalog. Unless IncludeHidden, only active products
+// inside their availability window are returned.
+func (r *commerceRepo) GetProducts(ctx context.Context, filter entity.ProductFilter) ([]entity.Product, error) {
+	query := r.db.WithContext(ctx).
+		Model(&entity.Product{}).
+		Preload("Images", productImages).
+		Preload("Category")
+
+	if filter.IncludeHidden {
+		query = query.Preload("Variants", func(db *gorm.DB) *gorm.DB { return db.Order("created_at asc") })
+	} else {
+		now := time.Now()
+		query = query.
+			Preload("Variants", activeVariants).
+			Where("products.is_active = ?", true).
+			Where("products.available_from IS NULL OR products.available_from <= ?", now).
+			Where("products.available_until IS NULL OR products.available_until > ?", now)
+	}
+
+	if filter.Category != "" {
+		query = query.
+			Joins("JOIN product_categories ON product_categories.id = products.category_id").
+			Where("product_categories.slug = ?", filter.Category)
+	}
+	if filter.Search != "" {
+		query = query.Where("products.name ILIKE ?", "%"+escapeLike(filter.Search)+"%")
+	}
+
+	switch filter.Sort {
+	case "newest":
+		query = query.Order("products.created_at desc")
+	case "price_asc":
+		query = query.Order("products.price_minor asc")
+	case "price_desc":
+		query = query.Order("products.price_minor desc")
+	default:
+		query = query.Order("products.sort_order asc, products.name asc")
+	}
+
 	var products []entity.Product
-	err := r.db.WithContext(ctx).Where("is_active = ?", true).Find(&products).Error
+	err := query.Find(&products).Error
 	return products, err
 }
 
+// escapeLike makes user input literal inside a LIKE pattern.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
 func (r *commerceRepo) FindProductByID(ctx context.Context, id string) (*entity.Product, error) {
 	var product entity.Product
-	err := r.db.WithContext(ctx).First(&product, "id = ?", id).Error
+	err := r.db.WithContext(ctx).
+		Preload("Variants", activeVariants).
+		Preload("Images", productImages).
+		Preload("Category").
+		First(&product, "id = ?", id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &product, nil
 }
 
+func (r *commerceRepo) FindProductsByIDs(ctx context.Context, ids []string) ([]entity.Product, error) {
+	var products []entity.Product
+	err := r.db.WithContext(ctx).
+		Preload("Variants", activeVariants).
+		Where("id IN ?", ids).
+		Find(&products).Error
+	return products, err
+}
+
+// UpdateProductFields updates only the given columns, so a concurrent stock
+// reservation is never overwritten by a stale full-row save.
+func (r *commerceRepo) UpdateProductFields(ctx context.Context, id string, fields map[string]interface{}) error {
+	result := r.db.WithContext(ctx).Model(&entity.Product{}).Where("id = ?", id).Updates(fields)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *commerceRepo) DeleteProduct(ctx context.Context, id string) error {
+	result := r.db.WithContext(ctx).Delete(&entity.Product{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *commerceRepo) UpdateVariantFields(ctx context.Context, id string, fields map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&entity.ProductVariant{}).Where("id = ?", id).Updates(fields).Error
+}
+
+func (r *commerceRepo) FindVariantByID(ctx context.Context, id string) (*entity.ProductVariant, error) {
+	var variant entity.ProductVariant
+	err := r.db.WithContext(ctx).First(&variant, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &variant, nil
+}
+
+// -----------------------------------------------------------
+// Implementation: Product Images & Categories
+// -----------------------------------------------------------
+
+func (r *commerceRepo) CreateProductImage(ctx context.Context, image *entity.ProductImage) error {
+	return r.db.WithContext(ctx).Create(image).Error
+}
+
+// DeleteProductImage removes the row and returns it so the file can be deleted.
+func (r *commerceRepo) DeleteProductImage(ctx context.Context, productID, imageID string) (*entity.ProductImage, error) {
+	var image entity.ProductImage
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND product_id = ?", imageID, productID).
+		First(&image).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := r.db.WithContext(ctx).Delete(&image).Error; err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+func (r *commerceRepo) CreateCategory(ctx context.Context, category *entity.ProductCategory) error {
+	return r.db.WithContext(ctx).Create(category).Error
+}
+
+func (r *commerceRepo) GetCategories(ctx context.Context) ([]entity.ProductCategory, error) {
+	var categories []entity.ProductCategory
+	err := r.db.WithContext(ctx).Order("sort_order asc, name asc").Find(&categories).Error
+	return categories, err
+}
+
+func (r *commerceRepo) FindCategoryByID(ctx context.Context, id string) (*entity.ProductCategory, error) {
+	var category entity.ProductCategory
+	err := r.db.WithContext(ctx).First(&category, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r *commerceRepo) UpdateCategory(ctx context.Context, category *entity.ProductCategory) error {
+	return r.db.WithContext(ctx).Save(category).Error
+}
+
+// DeleteCategory detaches its products (they become uncategorised) and
+// removes the category. Call it inside WithTx.
+func (r *commerceRepo) DeleteCategory(ctx context.Context, id string) error {
+	if err := r.db.WithContext(ctx).
+		Model(&entity.Product{}).
+		Where("category_id = ?", id).
+		Update("category_id", nil).Error; err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Delete(&entity.ProductCategory{}, "id = ?", id).Error
+}
+
+// -----------------------------------------------------------
+// Implementation: Inventory
+// -----------------------------------------------------------
+
+// DecreaseProductStock reserves units. A NULL stock means unlimited and
+// stays NULL (NULL - n = NULL), so the same query covers both cases.
+func (r *commerceRepo) DecreaseProductStock(ctx context.Context, productID string, count int) error {
+	// Query: UPDATE products SET stock = stock - count WHERE id = ? AND (stock IS NULL OR stock >= count)
+	result := r.db.WithContext(ctx).
+		Model(&entity.Product{}).
+		Where("id = ? AND (stock IS NULL OR stock >= ?)", productID, count).
+		Update("stock", gorm.Expr("stock - ?", count))
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInsufficientStock
+	}
+	return nil
+}
+
+func (r *commerceRepo) IncreaseProductStock(ctx context.Context, productID string, count int) error {
+	return r.db.WithContext(ctx).
+		Model(&entity.Product{}).
+		Where("id = ?", productID).
+		Update("stock", gorm.Expr("stock + ?", count)).Error
+}
+
+func (r *commerceRepo) DecreaseVariantStock(ctx context.Context, variantID string, count int) error {
+	result := r.db.WithContext(ctx).
+		Model(&entity.ProductVariant{}).
+		Where("id = ? AND (stock IS NULL OR stock >= ?)", variantID, count).
+		Update("stock", gorm.Expr("stock - ?", count))
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInsufficientStock
+	}
+	return nil
+}
+
+func (r *commerceRepo) IncreaseVariantStock(ctx context.Context, variantID string, count int) error {
+	return r.db.WithContext(ctx).
+		Model(&entity.ProductVariant{}).
+		Where("id = ?", variantID).
+		Update("stock", gorm.Expr("stock + ?", count)).Error
+}
+
 // -----------------------------------------------------------
 // Implementation: Order
 // -----------------------------------------------------------
 
+// CreateOrder inserts the order together with its Items
 func (r *commerceRepo) CreateOrder(ctx context.Context, order *entity.Order) error {
 	return r.db.WithContext(ctx).Create(order).Error
 }
 
+// FindOrderByID locks the order row (FOR UPDATE) when called inside WithTx.
 func (r *commerceRepo) FindOrderByID(ctx context.Context, id string) (*entity.Order, error) {
 	var order entity.Order
 	err := r.db.WithContext(ctx).
-		Preload("Product").
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("created_at asc") }).
 		Preload("User").
 		First(&order, "id = ?", id).Error
 	if err != nil {
@@ -76,7 +351,7 @@ func (r *commerceRepo) FindOrderByID(ctx context.Context, id string) (*entity.Or
 func (r *commerceRepo) GetOrdersByUser(ctx context.Context, userID string) ([]entity.Order, error) {
 	var orders []entity.Order
 	err := r.db.WithContext(ctx).
-		Preload("Product").
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("created_at asc") }).
 		Where("user_id = ?", userID).
 		Order("created_at desc").
 		Find(&orders).Error
@@ -86,7 +361,7 @@ func (r *commerceRepo) GetOrdersByUser(ctx context.Context, userID string) ([]en
 func (r *commerceRepo) GetPendingOrders(ctx context.Context) ([]entity.Order, error) {
 	var orders []entity.Order
 	err := r.db.WithContext(ctx).
-		Preload("Product").
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("created_at asc") }).
 		Preload("User").
 		Where("status = ?", entity.OrderPaid).
 		Order("created_at asc").
@@ -94,7 +369,199 @@ func (r *commerceRepo) GetPendingOrders(ctx context.Context) ([]entity.Order, er
 	return orders, err
 }
 
+// ListOrders returns the admin order queue, oldest first, using keyset
+// pagination on (created_at, id) so pages stay stable while orders arrive.
+func (r *commerceRepo) ListOrders(ctx context.Context, filter entity.OrderFilter) ([]entity.Order, error) {
+	query := r.db.WithContext(ctx).Model(&entity.Order{})
+
+	if len(filter.Statuses) > 0 {
+		query = query.Where("orders.status IN ?", filter.Statuses)
+	}
+	if filter.UserID != "" {
+		query = query.Where("orders.user_id = ?", filter.UserID)
+	}
+	if filter.ProductID != "" {
+		query = query.Where("EXISTS (SELECT 1 FROM order_items WHERE order_items.order_id = orders.id AND order_items.product_id = ?)", filter.ProductID)
+	}
+	if filter.CreatedFrom != nil {
+		query = query.Where("orders.created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		query = query.Where("orders.created_at < ?", *filter.CreatedTo)
+	}
+	if filter.AfterTime != nil {
+		query = query.Where("(orders.created_at, orders.id) > (?, ?)", *filter.AfterTime, filter.AfterID)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var orders []entity.Order
+	err := query.
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("created_at asc") }).
+		Preload("User").
+		Order("orders.created_at asc, orders.id asc").
+		Find(&orders).Error
+	return orders, err
+}
+
 func (r *commerceRepo) UpdateOrder(ctx context.Context, order *entity.Order) error {
-	// Saves all fields, including status and proof image URL
-	return r.db.WithContext(ctx).Save(order).Error
+	// Saves all fields, including status and proof image URL.
+	// Items are immutable snapshots, so associations are never re-saved.
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(order).Error
+}
+
+// FindExpiredOrderIDs returns unpaid (or rejected) orders whose reservation has run out.
+func (r *commerceRepo) FindExpiredOrderIDs(ctx context.Context, now time.Time, limit int) ([]string, error) {
+	var ids []string
+	err := r.db.WithContext(ctx).
+		Model(&entity.Order{}).
+		Where("status IN ? AND expires_at IS NOT NULL AND expires_at <= ?",
+			[]entity.OrderStatus{entity.OrderPending, entity.OrderRejected}, now).
+		Order("expires_at asc").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// -----------------------------------------------------------
+// Implementation: Audit Trail
+// -----------------------------------------------------------
+
+func (r *commerceRepo) CreateEvent(ctx context.Context, event *entity.OrderEvent) error {
+	return r.db.WithContext(ctx).Omit("Actor").Create(event).Error
+}
+
+func (r *commerceRepo) GetEvents(ctx context.Context, orderID string) ([]entity.OrderEvent, error) {
+	var events []entity.OrderEvent
+	err := r.db.WithContext(ctx).
+		Preload("Actor").
+		Where("order_id = ?", orderID).
+		Order("created_at asc").
+		Find(&events).Error
+	return events, err
+}
+
+// -----------------------------------------------------------
+// Implementation: Refund Requests
+// -----------------------------------------------------------
+
+func (r *commerceRepo) CreateRefundRequest(ctx context.Context, req *entity.RefundRequest) error {
+	return r.db.WithContext(ctx).Omit("Order").Create(req).Error
+}
+
+// FindRefundRequestByID locks the request row (FOR UPDATE) when called inside WithTx.
+func (r *commerceRepo) FindRefundRequestByID(ctx context.Context, id string) (*entity.RefundRequest, error) {
+	var req entity.RefundRequest
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&req, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// GetRefundRequests lists requests, oldest first; empty status = all.
+func (r *commerceRepo) GetRefundRequests(ctx context.Context, status string) ([]entity.RefundRequest, error) {
+	var reqs []entity.RefundRequest
+	query := r.db.WithContext(ctx).
+		Preload("Order").
+		Preload("Order.Items").
+		Preload("Order.User")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("created_at asc").Find(&reqs).Error
+	return reqs, err
+}
+
+func (r *commerceRepo) UpdateRefundRequest(ctx context.Context, req *entity.RefundRequest) error {
+	return r.db.WithContext(ctx).Omit("Order").Save(req).Error
+}
+
+// -----------------------------------------------------------
+// Implementation: Proof History
+// -----------------------------------------------------------
+
+func (r *commerceRepo) CreateProof(ctx context.Context, proof *entity.OrderProof) error {
+	return r.db.WithContext(ctx).Create(proof).Error
+}
+
+func (r *commerceRepo) GetProofs(ctx context.Context, orderID string) ([]entity.OrderProof, error) {
+	var proofs []entity.OrderProof
+	err := r.db.WithContext(ctx).
+		Where("order_id = ?", orderID).
+		Order("created_at desc").
+		Find(&proofs).Error
+	return proofs, err
+}
+
+// FindOpenProof returns the proof still waiting for review, or nil.
+func (r *commerceRepo) FindOpenProof(ctx context.Context, orderID string) (*entity.OrderProof, error) {
+	var proof entity.OrderProof
+	err := r.db.WithContext(ctx).
+		Where("order_id = ? AND status = ?", orderID, entity.ProofSubmitted).
+		Order("created_at desc").
+		First(&proof).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &proof, nil
+}
+
+func (r *commerceRepo) UpdateProof(ctx context.Context, proof *entity.OrderProof) error {
+	return r.db.WithContext(ctx).Save(proof).Error
+}
+
+// -----------------------------------------------------------
+// Implementation: Cart
+// -----------------------------------------------------------
+
+func (r *commerceRepo) GetCartItems(ctx context.Context, userID string) ([]entity.CartItem, error) {
+	var items []entity.CartItem
+	err := r.db.WithContext(ctx).
+		Preload("Product").
+		Preload("Variant").
+		Where("user_id = ?", userID).
+		Order("created_at asc").
+		Find(&items).Error
+	return items, err
+}
+
+func (r *commerceRepo) FindCartItem(ctx context.Context, userID, productID, variantID string) (*entity.CartItem, error) {
+	var item entity.CartItem
+	err := cartItemScope(r.db.WithContext(ctx), userID, productID, variantID).First(&item).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (r *commerceRepo) SaveCartItem(ctx context.Context, item *entity.CartItem) error {
+	return r.db.WithContext(ctx).Omit("Product", "Variant").Save(item).Error
+}
+
+func (r *commerceRepo) DeleteCartItem(ctx context.Context, userID, productID, variantID string) error {
+	return cartItemScope(r.db.WithContext(ctx), userID, productID, variantID).
+		Delete(&entity.CartItem{}).Error
+}
+
+// cartItemScope matches one cart line; empty variantID = product without variant.
+func cartItemScope(db *gorm.DB, userID, productID, variantID string) *gorm.DB {
+	db = db.Where("user_id = ? AND product_id = ?", userID, productID)
+	if variantID == "" {
+		return db.Where("variant_id IS NULL")
+	}
+	return db.Where("variant_id = ?", variantID)
+}
+
+func (r *commerceRepo) ClearCart(ctx context.Context, userID string) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&entity.CartItem{}).Error
 }
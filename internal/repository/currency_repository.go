@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"umrah-backend/internal/entity"
+	"umrah-backend/pkg/money"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CurrencyRepository interface {
+	UpsertRate(ctx context.Context, rate *entity.ExchangeRate) error
+	GetRates(ctx context.Context) ([]entity.ExchangeRate, error)
+	FindRate(ctx context.Context, base, quote money.Currency) (*entity.ExchangeRate, error)
+}
+
+type currencyRepo struct {
+	db *gorm.DB
+}
+
+func NewCurrencyRepository(db *gorm.DB) CurrencyRepository {
+	return &currencyRepo{db: db}
+}
+
+// UpsertRate inserts or overwrites the rate of a (base, quote) pair.
+func (r *currencyRepo) UpsertRate(ctx context.Context, rate *entity.ExchangeRate) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "base"}, {Name: "quote"}},
+			DoUpdates: clause.AssignmentColumns([]string{"rate", "updated_by", "updated_at"}),
+		}).
+		Create(rate).Error
+}
+
+func (r *currencyRepo) GetRates(ctx context.Context) ([]entity.ExchangeRate, error) {
+	var rates []entity.ExchangeRate
+	err := r.db.WithContext(ctx).Order("base asc, quote asc").Find(&rates).Error
+	return rates, err
+}
+
+func (r *currencyRepo) FindRate(ctx context.Context, base, quote money.Currency) (*entity.ExchangeRate, error) {
+	var rate entity.ExchangeRate
+	err := r.db.WithContext(ctx).Where("base = ? AND quote = ?", base, quote).First(&rate).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rate, nil
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"umrah-backend/internal/entity"
+
+	"gorm.io/gorm"
+)
+
+type DocumentRepository interface {
+	WithTx(ctx context.Context, fn func(repo DocumentRepository) error) error
+
+	// NextNumber increments the sequence of a prefix and returns the new
+	// value. The sequence row stays locked until the transaction ends, so
+	// concurrent issuers get consecutive numbers and a rollback leaves no gap.
+	NextNumber(ctx context.Context, prefix string) (int64, error)
+
+	CreateDocument(ctx context.Context, doc *entity.Document) error
+	FindDocumentByID(ctx context.Context, id string) (*entity.Document, error)
+	FindDocument(ctx context.Context, docType entity.DocumentType, refType entity.DocumentReference, refID string) (*entity.Document, error)
+	GetOrderDocuments(ctx context.Context, orderID string) ([]entity.Document, error)
+	GetBookingDocuments(ctx context.Context, bookingID string) ([]entity.Document, error)
+}
+
+type documentRepo struct {
+	db *gorm.DB
+}
+
+func NewDocumentRepository(db *gorm.DB) DocumentRepository {
+	return &documentRepo{db: db}
+}
+
+func (r *documentRepo) WithTx(ctx context.Context, fn func(repo DocumentRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&documentRepo{db: tx})
+	})
+}
+
+func (r *documentRepo) NextNumber(ctx context.Context, prefix string) (int64, error) {
+	var value int64
+	err := r.db.WithContext(ctx).Raw(`
+		INSERT INTO document_sequences (prefix, last_value) VALUES (?, 1)
+		ON CONFLICT (prefix) DO UPDATE SET last_value = document_sequences.last_value + 1
+		RETURNING last_value`, prefix).Scan(&value).Error
+	return value, err
+}
+
+func (r *documentRepo) CreateDocument(ctx context.Context, doc *entity.Document) error {
+	return r.db.WithContext(ctx).Create(doc).Error
+}
+
+func (r *documentRepo) FindDocumentByID(ctx context.Context, id string) (*entity.Document, error) {
+	var doc entity.Document
+	if err := r.db.WithContext(ctx).First(&doc, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// FindDocument returns nil, nil when the document was not issued yet.
+func (r *documentRepo) FindDocument(ctx context.Context, docType entity.DocumentType, refType entity.DocumentReference, refID string) (*entity.Document, error) {
+	var doc entity.Document
+	err := r.db.WithContext(ctx).
+		Where("type = ? AND reference_type = ? AND reference_id = ?", docType, refType, refID).
+		First(&doc).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &doc, nil
+}
+
+func (r *documentRepo) GetOrderDocuments(ctx context.Context, orderID string) ([]entity.Document, error) {
+	var docs []entity.Document
+	err := r.db.WithContext(ctx).
+		Where("reference_type = ? AND reference_id = ?", entity.DocRefOrder, orderID).
+		Order("issued_at asc").
+		Find(&docs).Error
+	return docs, err
+}
+
+// GetBookingDocuments lists the booking invoice plus its installment receipts.
+func (r *documentRepo) GetBookingDocuments(ctx context.Context, bookingID string) ([]entity.Document, error) {
+	var docs []entity.Document
+	err := r.db.WithContext(ctx).
+		Where("(reference_type = ? AND reference_id = ?) OR booking_id = ?", entity.DocRefBooking, bookingID, bookingID).
+		Order("issued_at asc").
+		Find(&docs).Error
+	return docs, err
+}
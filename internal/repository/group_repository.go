@@ -11,10 +11,12 @@ type GroupRepository interface {
 	Create(ctx context.Context, group *entity.Group) error
 	Join(ctx context.Context, member *entity.GroupMember) error
 	FindByCode(ctx context.Context, code string) (*entity.Group, error)
+	FindByPackageID(ctx context.Context, packageID string) (*entity.Group, error)
 	GetByID(ctx context.Context, id string) (*entity.Group, error)
 	GetMembers(ctx context.Context, groupID string) ([]entity.GroupMember, error)
 	GetAllGroups(ctx context.Context) ([]entity.Group, error)
 	IsMember(ctx context.Context, groupID, userID string) (bool, error)
+	RemoveMember(ctx context.Context, groupID, userID string) (bool, error)
 }
 
 type groupRepo struct {
@@ -38,6 +40,14 @@ func (r *groupRepo) FindByCode(ctx context.Context, code string) (*entity.Group,
 	return &group, nil
 }
 
+func (r *groupRepo) FindByPackageID(ctx context.Context, packageID string) (*entity.Group, error) {
+	var group entity.Group
+	if err := r.db.WithContext(ctx).Where("package_id = ?", packageID).First(&group).Error; err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
 // [FIX] Ganti nama dari AddMember jadi Join (Sesuai Interface)
 func (r *groupRepo) Join(ctx context.Context, member *entity.GroupMember) error {
 	return r.db.WithContext(ctx).Create(member).Error
@@ -70,6 +80,14 @@ func (r *groupRepo) GetAllGroups(ctx context.Context) ([]entity.Group, error) {
 	return groups, err
 }
 
+// RemoveMember reports whether the user was a member.
+func (r *groupRepo) RemoveMember(ctx context.Context, groupID, userID string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Where("group_id = ? AND user_id = ?", groupID, userID).
+		Delete(&entity.GroupMember{})
+	return result.RowsAffected > 0, result.Error
+}
+
 func (r *groupRepo) IsMember(ctx context.Context, groupID, userID string) (bool, error) {
 	var count int64
 	err := r.db.WithContext(ctx).
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 	"umrah-backend/internal/entity"
 
 	"gorm.io/gorm"
@@ -16,6 +17,10 @@ type ItineraryRepository interface {
 	UpdateItinerary(ctx context.Context, itinerary *entity.Itinerary) error
 	DeleteItinerary(ctx context.Context, id string) error
 
+	// Rundown generated from the package template
+	CountTemplateItems(ctx context.Context, groupID string) (int64, error)
+	ReplaceTemplateItems(ctx context.Context, groupID string, after time.Time, items []entity.Itinerary) error
+
 	// Attendance Management
 	CreateAttendance(ctx context.Context, attendance *entity.Attendance) error
 	GetAttendanceByItinerary(ctx context.Context, itineraryID string) ([]entity.Attendance, error)
@@ -64,6 +69,30 @@ func (r *itineraryRepo) DeleteItinerary(ctx context.Context, id string) error {
 	return r.db.WithContext(ctx).Delete(&entity.Itinerary{}, "id = ?", id).Error
 }
 
+func (r *itineraryRepo) CountTemplateItems(ctx context.Context, groupID string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&entity.Itinerary{}).
+		Where("group_id = ? AND template_item_id IS NOT NULL", groupID).
+		Count(&count).Error
+	return count, err
+}
+
+// ReplaceTemplateItems removes the generated items starting after the given
+// time and inserts the new ones. Items added by hand are never touched.
+func (r *itineraryRepo) ReplaceTemplateItems(ctx context.Context, groupID string, after time.Time, items []entity.Itinerary) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("group_id = ? AND template_item_id IS NOT NULL AND start_time > ?", groupID, after).
+			Delete(&entity.Itinerary{}).Error
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		return tx.Create(&items).Error
+	})
+}
+
 // -----------------------------------------------------------
 // Implementation: Attendance
 // -----------------------------------------------------------
@@ -3,19 +3,80 @@ package repository
 import (
 	"context"
 	"errors" // [FIX] Wajib import errors
+	"strings"
+	"time"
 	"umrah-backend/internal/entity"
+	"umrah-backend/pkg/money"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PackageRepository interface {
+	// WithTx runs fn inside a single database transaction.
+	// The repository passed to fn is bound to that transaction.
+	WithTx(ctx context.Context, fn func(repo PackageRepository) error) error
+
 	CreatePackage(ctx context.Context, pkg *entity.TravelPackage) error
-	GetPackages(ctx context.Context, category string) ([]entity.TravelPackage, error)
+	SearchPackages(ctx context.Context, search entity.PackageSearch) ([]entity.TravelPackage, int64, error)
+	GetPackageFacets(ctx context.Context, search entity.PackageSearch) (*entity.PackageFacets, error)
 	FindPackageByID(ctx context.Context, id string) (*entity.TravelPackage, error)
+	UpdatePackageFields(ctx context.Context, id string, fields map[string]interface{}) error
+	PublishDuePackages(ctx context.Context, now time.Time) (int64, error)
+	UnpublishDuePackages(ctx context.Context, now time.Time) (int64, error)
+
+	// Package Content (template rundown, inclusions, flights, hotels)
+	FindPackageDetail(ctx context.Context, id string) (*entity.TravelPackage, error) // Package with all content
+	GetItineraryTemplate(ctx context.Context, packageID string) ([]entity.PackageItineraryDay, error)
+	ReplaceItinerary(ctx context.Context, packageID string, days []entity.PackageItineraryDay) error
+	ReplaceInclusions(ctx context.Context, packageID string, items []entity.PackageInclusion) error
+	ReplaceFlights(ctx context.Context, packageID string, flights []entity.PackageFlight) error
+	ReplaceHotels(ctx context.Context, packageID string, hotels []entity.PackageHotel) error
+
+	// Package Versions (snapshots of the terms bookings were sold under)
+	LockPackageTerms(ctx context.Context, id string) (*entity.TravelPackage, error) // Locks the row, preloads hotels and flights
+	FindLatestPackageVersion(ctx context.Context, packageID string) (*entity.PackageVersion, error)
+	CreatePackageVersion(ctx context.Context, version *entity.PackageVersion) error
+	GetPackageVersions(ctx context.Context, packageID string) ([]entity.PackageVersion, error)
+	FindPackageVersionByID(ctx context.Context, id string) (*entity.PackageVersion, error)
 
 	CreateBooking(ctx context.Context, booking *entity.Booking) error
+	FindBookingByID(ctx context.Context, id string) (*entity.Booking, error)
+	UpdateBooking(ctx context.Context, booking *entity.Booking) error
+	FindExpiredBookingIDs(ctx context.Context, now time.Time, limit int) ([]string, error)
+
+	// Waitlist
+	CreateWaitlistEntry(ctx context.Context, entry *entity.WaitlistEntry) error
+	UpdateWaitlistEntry(ctx context.Context, entry *entity.WaitlistEntry) error
+	FindWaitlistEntryByID(ctx context.Context, id string) (*entity.WaitlistEntry, error) // Locks the row
+	FindWaitlistEntriesByIDs(ctx context.Context, ids []uuid.UUID) ([]entity.WaitlistEntry, error)
+	FindActiveWaitlistEntry(ctx context.Context, packageID, userID string) (*entity.WaitlistEntry, error)
+	GetWaitingEntries(ctx context.Context, packageID string) ([]entity.WaitlistEntry, error)      // FIFO, locks the rows
+	GetOpenWaitlistEntries(ctx context.Context, packageID string) ([]entity.WaitlistEntry, error) // WAITING or OFFERED, locks the rows
+	CountWaitingAhead(ctx context.Context, entry *entity.WaitlistEntry) (int64, error)
+	CountWaiting(ctx context.Context, packageID string) (int64, error)
+	GetWaitlist(ctx context.Context, packageID string) ([]entity.WaitlistEntry, error)
+	GetUserWaitlist(ctx context.Context, userID string) ([]entity.WaitlistEntry, error)
+	FindExpiredOfferIDs(ctx context.Context, now time.Time, limit int) ([]string, error)
+	FindPackagesToOffer(ctx context.Context) ([]string, error)
+
+	// Passenger Manifest
+	ReplacePassengers(ctx context.Context, bookingID string, passengers []entity.BookingPassenger) error
+	GetPassengers(ctx context.Context, bookingID string) ([]entity.BookingPassenger, error)
+
+	// Payment Schedule
+	ReplaceInstallments(ctx context.Context, bookingID string, installments []entity.BookingInstallment) error
+	GetInstallments(ctx context.Context, bookingID string) ([]entity.BookingInstallment, error)
+	FindInstallmentByID(ctx context.Context, id string) (*entity.BookingInstallment, error)
+	UpdateInstallment(ctx context.Context, installment *entity.BookingInstallment) error
+
+	// Admin Views
+	ListBookings(ctx context.Context, filter entity.BookingFilter) ([]entity.Booking, int64, error)
+	GetManifest(ctx context.Context, packageID string) ([]entity.Booking, error)
 	// Method ini ada di interface, jadi WAJIB diimplementasikan di bawah
 	DecreaseQuota(ctx context.Context, packageID string, count int) error
+	IncreaseQuota(ctx context.Context, packageID string, count int) error
 }
 
 type packageRepo struct {
@@ -26,20 +87,180 @@ func NewPackageRepository(db *gorm.DB) PackageRepository {
 	return &packageRepo{db: db}
 }
 
+func (r *packageRepo) WithTx(ctx context.Context, fn func(repo PackageRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&packageRepo{db: tx})
+	})
+}
+
 func (r *packageRepo) CreatePackage(ctx context.Context, pkg *entity.TravelPackage) error {
-	return r.db.WithContext(ctx).Create(pkg).Error
+	// Content is set through its own endpoints
+	return r.db.WithContext(ctx).Omit(clause.Associations).Create(pkg).Error
 }
 
-func (r *packageRepo) GetPackages(ctx context.Context, category string) ([]entity.TravelPackage, error) {
+// Filters of a package search that facets can leave out
+const (
+	searchCategory      = "category"
+	searchCity          = "city"
+	searchDeparture     = "departure"
+	searchDuration      = "duration"
+	searchRatingMakkah  = "rating_makkah"
+	searchRatingMadinah = "rating_madinah"
+	searchPrice         = "price"
+)
+
+// searchPackagesQuery applies every filter of a search to the packages of
+// its status (listed ones by default), except the filter named by skip.
+func (r *packageRepo) searchPackagesQuery(ctx context.Context, search entity.PackageSearch, skip string) *gorm.DB {
+	query := r.db.WithContext(ctx).Model(&entity.TravelPackage{})
+	switch search.Status {
+	case "":
+		query = query.Where("is_active = ?", true)
+	case "inactive":
+		query = query.Where("is_active = ? AND archived_at IS NULL", false)
+	case "scheduled":
+		query = query.Where("(publish_at IS NOT NULL OR unpublish_at IS NOT NULL) AND archived_at IS NULL")
+	case "archived":
+		query = query.Where("archived_at IS NOT NULL")
+	}
+
+	for _, word := range strings.Fields(search.Query) {
+		query = query.Where(`(name ILIKE @p OR description ILIKE @p OR tags ILIKE @p OR sub_category ILIKE @p
+			OR hotel_makkah ILIKE @p OR hotel_madinah ILIKE @p OR airline_name ILIKE @p)`,
+			map[string]interface{}{"p": "%" + escapeLike(word) + "%"})
+	}
+	if search.Category != "" && skip != searchCategory {
+		query = query.Where("category = ?", search.Category)
+	}
+	if search.DepartureCity != "" && skip != searchCity {
+		query = query.Where("LOWER(departure_city) = LOWER(?)", search.DepartureCity)
+	}
+	if skip != searchDeparture {
+		if search.DepartureFrom != nil {
+			query = query.Where("departure_date >= ?", *search.DepartureFrom)
+		}
+		if search.DepartureTo != nil {
+			query = query.Where("departure_date < ?", *search.DepartureTo)
+		}
+	}
+	if skip != searchDuration {
+		if search.MinDuration > 0 {
+			query = query.Where("duration_days >= ?", search.MinDuration)
+		}
+		if search.MaxDuration > 0 {
+			query = query.Where("duration_days <= ?", search.MaxDuration)
+		}
+	}
+	if search.MinRatingMakkah > 0 && skip != searchRatingMakkah {
+		query = query.Where("rating_makkah >= ?", search.MinRatingMakkah)
+	}
+	if search.MinRatingMadinah > 0 && skip != searchRatingMadinah {
+		query = query.Where("rating_madinah >= ?", search.MinRatingMadinah)
+	}
+	if (search.MinPrice != nil || search.MaxPrice != nil) && skip != searchPrice {
+		query = query.Where("price_quad_currency = ?", search.PriceCurrency)
+		if search.MinPrice != nil {
+			query = query.Where("price_quad_minor >= ?", *search.MinPrice)
+		}
+		if search.MaxPrice != nil {
+			query = query.Where("price_quad_minor <= ?", *search.MaxPrice)
+		}
+	}
+	return query
+}
+
+func (r *packageRepo) SearchPackages(ctx context.Context, search entity.PackageSearch) ([]entity.TravelPackage, int64, error) {
+	query := r.searchPackagesQuery(ctx, search, "")
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	switch search.Sort {
+	case "price_asc":
+		query = query.Order("price_quad_currency asc, price_quad_minor asc")
+	case "price_desc":
+		query = query.Order("price_quad_currency asc, price_quad_minor desc")
+	case "duration_asc":
+		query = query.Order("duration_days asc")
+	case "duration_desc":
+		query = query.Order("duration_days desc")
+	case "newest":
+		query = query.Order("created_at desc")
+	default:
+		// Packages whose name matches the whole query come first
+		if q := strings.TrimSpace(search.Query); q != "" {
+			query = query.Order(clause.OrderBy{Expression: clause.Expr{
+				SQL:  "CASE WHEN name ILIKE ? THEN 0 ELSE 1 END",
+				Vars: []interface{}{"%" + escapeLike(q) + "%"},
+			}})
+		}
+	}
+	query = query.Order("departure_date asc")
+
+	if search.Limit > 0 {
+		query = query.Limit(search.Limit).Offset((search.Page - 1) * search.Limit)
+	}
+
 	var packages []entity.TravelPackage
-	query := r.db.WithContext(ctx).Where("is_active = ?", true)
+	err := query.Find(&packages).Error
+	return packages, total, err
+}
+
+// packageFacet: how one facet is grouped and ordered.
+type packageFacet struct {
+	skip    string
+	value   string // SQL expression, as text
+	present string // Rows without a value are not counted
+	order   string
+	into    *[]entity.FacetCount
+}
 
-	if category != "" {
-		query = query.Where("category = ?", category)
+func (r *packageRepo) GetPackageFacets(ctx context.Context, search entity.PackageSearch) (*entity.PackageFacets, error) {
+	facets := &entity.PackageFacets{}
+	specs := []packageFacet{
+		{searchCategory, "category", "category <> ''", "COUNT(*) DESC, 1", &facets.Categories},
+		{searchCity, "departure_city", "departure_city <> ''", "COUNT(*) DESC, 1", &facets.DepartureCities},
+		{searchDeparture, "to_char(departure_date, 'YYYY-MM')", "departure_date IS NOT NULL", "1", &facets.DepartureMonths},
+		{searchDuration, "CAST(duration_days AS TEXT)", "duration_days > 0", "MIN(duration_days)", &facets.Durations},
+		{searchRatingMakkah, "CAST(rating_makkah AS TEXT)", "rating_makkah > 0", "MIN(rating_makkah) DESC", &facets.RatingsMakkah},
+		{searchRatingMadinah, "CAST(rating_madinah AS TEXT)", "rating_madinah > 0", "MIN(rating_madinah) DESC", &facets.RatingsMadinah},
+		{"", "airline_name", "airline_name <> ''", "COUNT(*) DESC, 1", &facets.Airlines},
+	}
+	for _, spec := range specs {
+		rows := []entity.FacetCount{}
+		err := r.searchPackagesQuery(ctx, search, spec.skip).
+			Select(spec.value + " AS value, COUNT(*) AS count").
+			Where(spec.present).
+			Group(spec.value).
+			Order(spec.order).
+			Scan(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+		*spec.into = rows
 	}
 
-	err := query.Order("departure_date asc").Find(&packages).Error
-	return packages, err
+	var prices []struct {
+		Currency string
+		Min      int64
+		Max      int64
+	}
+	err := r.searchPackagesQuery(ctx, search, searchPrice).
+		Select("price_quad_currency AS currency, MIN(price_quad_minor) AS min, MAX(price_quad_minor) AS max").
+		Group("price_quad_currency").
+		Order("price_quad_currency").
+		Scan(&prices).Error
+	if err != nil {
+		return nil, err
+	}
+	facets.PriceRanges = make([]entity.PriceRange, 0, len(prices))
+	for _, p := range prices {
+		currency := money.Currency(p.Currency)
+		facets.PriceRanges = append(facets.PriceRanges, entity.PriceRange{Min: money.New(p.Min, currency), Max: money.New(p.Max, currency)})
+	}
+	return facets, nil
 }
 
 func (r *packageRepo) FindPackageByID(ctx context.Context, id string) (*entity.TravelPackage, error) {
@@ -48,10 +269,463 @@ func (r *packageRepo) FindPackageByID(ctx context.Context, id string) (*entity.T
 	return &pkg, err
 }
 
+// UpdatePackageFields updates only the given columns, so concurrent seat
+// changes are never overwritten by a stale full-row save.
+func (r *packageRepo) UpdatePackageFields(ctx context.Context, id string, fields map[string]interface{}) error {
+	result := r.db.WithContext(ctx).Model(&entity.TravelPackage{}).Where("id = ?", id).Updates(fields)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// PublishDuePackages lists the packages whose publish time has come and
+// clears the schedule, so a later manual unpublish sticks.
+func (r *packageRepo) PublishDuePackages(ctx context.Context, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entity.TravelPackage{}).
+		Where("publish_at IS NOT NULL AND publish_at <= ? AND archived_at IS NULL", now).
+		Updates(map[string]interface{}{"is_active": true, "publish_at": nil, "updated_at": now})
+	return result.RowsAffected, result.Error
+}
+
+// UnpublishDuePackages is the counterpart of PublishDuePackages.
+func (r *packageRepo) UnpublishDuePackages(ctx context.Context, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entity.TravelPackage{}).
+		Where("unpublish_at IS NOT NULL AND unpublish_at <= ?", now).
+		Updates(map[string]interface{}{"is_active": false, "unpublish_at": nil, "updated_at": now})
+	return result.RowsAffected, result.Error
+}
+
+func (r *packageRepo) FindPackageDetail(ctx context.Context, id string) (*entity.TravelPackage, error) {
+	var pkg entity.TravelPackage
+	err := r.db.WithContext(ctx).
+		Preload("ItineraryDays", func(db *gorm.DB) *gorm.DB { return db.Order("day_number asc") }).
+		Preload("ItineraryDays.Items", func(db *gorm.DB) *gorm.DB { return db.Order("sequence asc") }).
+		Preload("Inclusions", func(db *gorm.DB) *gorm.DB { return db.Order("kind desc, sequence asc") }).
+		Preload("Flights", func(db *gorm.DB) *gorm.DB { return db.Order("sequence asc") }).
+		Preload("Hotels", func(db *gorm.DB) *gorm.DB { return db.Order("sequence asc") }).
+		First(&pkg, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &pkg, nil
+}
+
+func (r *packageRepo) GetItineraryTemplate(ctx context.Context, packageID string) ([]entity.PackageItineraryDay, error) {
+	var days []entity.PackageItineraryDay
+	err := r.db.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("sequence asc") }).
+		Where("package_id = ?", packageID).
+		Order("day_number asc").
+		Find(&days).Error
+	return days, err
+}
+
+// ReplaceItinerary swaps the whole template rundown. Group rundowns that
+// were generated from the old template keep their own copies.
+func (r *packageRepo) ReplaceItinerary(ctx context.Context, packageID string, days []entity.PackageItineraryDay) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("day_id IN (?)", tx.Model(&entity.PackageItineraryDay{}).Select("id").Where("package_id = ?", packageID)).
+			Delete(&entity.PackageItineraryItem{}).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("package_id = ?", packageID).Delete(&entity.PackageItineraryDay{}).Error; err != nil {
+			return err
+		}
+		if len(days) == 0 {
+			return nil
+		}
+		return tx.Create(&days).Error // Items are created with their day
+	})
+}
+
+func (r *packageRepo) ReplaceInclusions(ctx context.Context, packageID string, items []entity.PackageInclusion) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("package_id = ?", packageID).Delete(&entity.PackageInclusion{}).Error; err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		return tx.Create(&items).Error
+	})
+}
+
+func (r *packageRepo) ReplaceFlights(ctx context.Context, packageID string, flights []entity.PackageFlight) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("package_id = ?", packageID).Delete(&entity.PackageFlight{}).Error; err != nil {
+			return err
+		}
+		if len(flights) == 0 {
+			return nil
+		}
+		return tx.Create(&flights).Error
+	})
+}
+
+func (r *packageRepo) ReplaceHotels(ctx context.Context, packageID string, hotels []entity.PackageHotel) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("package_id = ?", packageID).Delete(&entity.PackageHotel{}).Error; err != nil {
+			return err
+		}
+		if len(hotels) == 0 {
+			return nil
+		}
+		return tx.Create(&hotels).Error
+	})
+}
+
+// LockPackageTerms serializes version snapshots of one package: the
+// version number is taken while the package row is locked.
+func (r *packageRepo) LockPackageTerms(ctx context.Context, id string) (*entity.TravelPackage, error) {
+	var pkg entity.TravelPackage
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("Flights", func(db *gorm.DB) *gorm.DB { return db.Order("sequence asc") }).
+		Preload("Hotels", func(db *gorm.DB) *gorm.DB { return db.Order("sequence asc") }).
+		First(&pkg, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &pkg, nil
+}
+
+// FindLatestPackageVersion returns nil, nil when the package has no version yet.
+func (r *packageRepo) FindLatestPackageVersion(ctx context.Context, packageID string) (*entity.PackageVersion, error) {
+	var version entity.PackageVersion
+	err := r.db.WithContext(ctx).
+		Where("package_id = ?", packageID).
+		Order("version desc").
+		First(&version).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+func (r *packageRepo) CreatePackageVersion(ctx context.Context, version *entity.PackageVersion) error {
+	return r.db.WithContext(ctx).Create(version).Error
+}
+
+func (r *packageRepo) GetPackageVersions(ctx context.Context, packageID string) ([]entity.PackageVersion, error) {
+	var versions []entity.PackageVersion
+	err := r.db.WithContext(ctx).
+		Where("package_id = ?", packageID).
+		Order("version asc").
+		Find(&versions).Error
+	return versions, err
+}
+
+func (r *packageRepo) FindPackageVersionByID(ctx context.Context, id string) (*entity.PackageVersion, error) {
+	var version entity.PackageVersion
+	err := r.db.WithContext(ctx).First(&version, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
 func (r *packageRepo) CreateBooking(ctx context.Context, booking *entity.Booking) error {
 	return r.db.WithContext(ctx).Create(booking).Error
 }
 
+// FindBookingByID locks the row (SELECT ... FOR UPDATE) so that concurrent
+// status changes on the same booking are serialized inside a transaction.
+func (r *packageRepo) FindBookingByID(ctx context.Context, id string) (*entity.Booking, error) {
+	var booking entity.Booking
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&booking, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &booking, nil
+}
+
+func (r *packageRepo) UpdateBooking(ctx context.Context, booking *entity.Booking) error {
+	return r.db.WithContext(ctx).Save(booking).Error
+}
+
+// FindExpiredBookingIDs returns PENDING bookings whose payment hold has passed.
+func (r *packageRepo) FindExpiredBookingIDs(ctx context.Context, now time.Time, limit int) ([]string, error) {
+	var ids []string
+	err := r.db.WithContext(ctx).
+		Model(&entity.Booking{}).
+		Where("status = ? AND expires_at IS NOT NULL AND expires_at <= ?", entity.BookingPending, now).
+		Order("expires_at asc").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (r *packageRepo) CreateWaitlistEntry(ctx context.Context, entry *entity.WaitlistEntry) error {
+	return r.db.WithContext(ctx).Omit("Package", "User").Create(entry).Error
+}
+
+func (r *packageRepo) UpdateWaitlistEntry(ctx context.Context, entry *entity.WaitlistEntry) error {
+	return r.db.WithContext(ctx).Omit("Package", "User").Save(entry).Error
+}
+
+func (r *packageRepo) FindWaitlistEntryByID(ctx context.Context, id string) (*entity.WaitlistEntry, error) {
+	var entry entity.WaitlistEntry
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&entry, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *packageRepo) FindWaitlistEntriesByIDs(ctx context.Context, ids []uuid.UUID) ([]entity.WaitlistEntry, error) {
+	var entries []entity.WaitlistEntry
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Preload("Package").
+		Where("id IN ?", ids).
+		Find(&entries).Error
+	return entries, err
+}
+
+// FindActiveWaitlistEntry returns the user's WAITING or OFFERED entry on
+// the package, nil when there is none.
+func (r *packageRepo) FindActiveWaitlistEntry(ctx context.Context, packageID, userID string) (*entity.WaitlistEntry, error) {
+	var entry entity.WaitlistEntry
+	err := r.db.WithContext(ctx).
+		Where("package_id = ? AND user_id = ? AND status IN ?", packageID, userID,
+			[]entity.WaitlistStatus{entity.WaitlistWaiting, entity.WaitlistOffered}).
+		First(&entry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *packageRepo) GetWaitingEntries(ctx context.Context, packageID string) ([]entity.WaitlistEntry, error) {
+	var entries []entity.WaitlistEntry
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("package_id = ? AND status = ?", packageID, entity.WaitlistWaiting).
+		Order("joined_at asc").
+		Find(&entries).Error
+	return entries, err
+}
+
+func (r *packageRepo) GetOpenWaitlistEntries(ctx context.Context, packageID string) ([]entity.WaitlistEntry, error) {
+	var entries []entity.WaitlistEntry
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("package_id = ? AND status IN ?", packageID, []entity.WaitlistStatus{entity.WaitlistWaiting, entity.WaitlistOffered}).
+		Order("joined_at asc").
+		Find(&entries).Error
+	return entries, err
+}
+
+func (r *packageRepo) CountWaitingAhead(ctx context.Context, entry *entity.WaitlistEntry) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&entity.WaitlistEntry{}).
+		Where("package_id = ? AND status = ? AND joined_at < ?", entry.PackageID, entity.WaitlistWaiting, entry.JoinedAt).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *packageRepo) CountWaiting(ctx context.Context, packageID string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&entity.WaitlistEntry{}).
+		Where("package_id = ? AND status = ?", packageID, entity.WaitlistWaiting).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *packageRepo) GetWaitlist(ctx context.Context, packageID string) ([]entity.WaitlistEntry, error) {
+	var entries []entity.WaitlistEntry
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Where("package_id = ?", packageID).
+		Order("joined_at asc").
+		Find(&entries).Error
+	return entries, err
+}
+
+func (r *packageRepo) GetUserWaitlist(ctx context.Context, userID string) ([]entity.WaitlistEntry, error) {
+	var entries []entity.WaitlistEntry
+	err := r.db.WithContext(ctx).
+		Preload("Package").
+		Where("user_id = ?", userID).
+		Order("joined_at desc").
+		Find(&entries).Error
+	return entries, err
+}
+
+// FindExpiredOfferIDs returns OFFERED entries whose claim window has passed.
+func (r *packageRepo) FindExpiredOfferIDs(ctx context.Context, now time.Time, limit int) ([]string, error) {
+	var ids []string
+	err := r.db.WithContext(ctx).
+		Model(&entity.WaitlistEntry{}).
+		Where("status = ? AND offer_expires_at <= ?", entity.WaitlistOffered, now).
+		Order("offer_expires_at asc").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// FindPackagesToOffer returns packages with free seats and someone waiting.
+func (r *packageRepo) FindPackagesToOffer(ctx context.Context) ([]string, error) {
+	var ids []string
+	err := r.db.WithContext(ctx).
+		Model(&entity.TravelPackage{}).
+		Where("available > 0").
+		Where("EXISTS (SELECT 1 FROM waitlist_entries w WHERE w.package_id = travel_packages.id AND w.status = ?)", entity.WaitlistWaiting).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// ReplacePassengers swaps the whole passenger list of a booking. Passengers
+// passed with an existing ID are updated in place and keep their room;
+// the ones left out are removed together with their room assignment.
+// Call it inside WithTx so the delete and upsert are atomic.
+func (r *packageRepo) ReplacePassengers(ctx context.Context, bookingID string, passengers []entity.BookingPassenger) error {
+	keep := make([]uuid.UUID, 0, len(passengers))
+	for _, p := range passengers {
+		keep = append(keep, p.ID)
+	}
+
+	removed := r.db.WithContext(ctx).Model(&entity.BookingPassenger{}).Select("id").Where("booking_id = ?", bookingID)
+	if len(keep) > 0 {
+		removed = removed.Where("id NOT IN ?", keep)
+	}
+	if err := r.db.WithContext(ctx).Where("passenger_id IN (?)", removed).Delete(&entity.RoomAssignment{}).Error; err != nil {
+		return err
+	}
+
+	query := r.db.WithContext(ctx).Where("booking_id = ?", bookingID)
+	if len(keep) > 0 {
+		query = query.Where("id NOT IN ?", keep)
+	}
+	if err := query.Delete(&entity.BookingPassenger{}).Error; err != nil {
+		return err
+	}
+	if len(passengers) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "id"}}, UpdateAll: true}).
+		Create(&passengers).Error
+}
+
+func (r *packageRepo) GetPassengers(ctx context.Context, bookingID string) ([]entity.BookingPassenger, error) {
+	var passengers []entity.BookingPassenger
+	err := r.db.WithContext(ctx).
+		Where("booking_id = ?", bookingID).
+		Order("sequence asc").
+		Find(&passengers).Error
+	return passengers, err
+}
+
+func (r *packageRepo) ReplaceInstallments(ctx context.Context, bookingID string, installments []entity.BookingInstallment) error {
+	if err := r.db.WithContext(ctx).Where("booking_id = ?", bookingID).Delete(&entity.BookingInstallment{}).Error; err != nil {
+		return err
+	}
+	if len(installments) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(&installments).Error
+}
+
+func (r *packageRepo) GetInstallments(ctx context.Context, bookingID string) ([]entity.BookingInstallment, error) {
+	var installments []entity.BookingInstallment
+	err := r.db.WithContext(ctx).
+		Where("booking_id = ?", bookingID).
+		Order("sequence asc").
+		Find(&installments).Error
+	return installments, err
+}
+
+func (r *packageRepo) FindInstallmentByID(ctx context.Context, id string) (*entity.BookingInstallment, error) {
+	var installment entity.BookingInstallment
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&installment, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &installment, nil
+}
+
+func (r *packageRepo) UpdateInstallment(ctx context.Context, installment *entity.BookingInstallment) error {
+	return r.db.WithContext(ctx).Save(installment).Error
+}
+
+func (r *packageRepo) ListBookings(ctx context.Context, filter entity.BookingFilter) ([]entity.Booking, int64, error) {
+	query := r.db.WithContext(ctx).
+		Model(&entity.Booking{}).
+		Joins("JOIN travel_packages ON travel_packages.id = bookings.package_id")
+
+	if filter.PackageID != "" {
+		query = query.Where("bookings.package_id = ?", filter.PackageID)
+	}
+	if filter.UserID != "" {
+		query = query.Where("bookings.user_id = ?", filter.UserID)
+	}
+	if filter.AgentID != "" {
+		query = query.Where("bookings.agent_id = ?", filter.AgentID)
+	}
+	if filter.Status != "" {
+		query = query.Where("bookings.status = ?", filter.Status)
+	}
+	if filter.DepartureFrom != nil {
+		query = query.Where("travel_packages.departure_date >= ?", *filter.DepartureFrom)
+	}
+	if filter.DepartureTo != nil {
+		query = query.Where("travel_packages.departure_date < ?", *filter.DepartureTo)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit).Offset((filter.Page - 1) * filter.Limit)
+	}
+
+	var bookings []entity.Booking
+	err := query.
+		Preload("User").
+		Preload("Package").
+		Order("bookings.created_at desc").
+		Find(&bookings).Error
+	return bookings, total, err
+}
+
+// GetManifest returns every booking that still occupies seats on the package.
+func (r *packageRepo) GetManifest(ctx context.Context, packageID string) ([]entity.Booking, error) {
+	var bookings []entity.Booking
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Preload("Passengers", func(db *gorm.DB) *gorm.DB {
+			return db.Order("sequence asc")
+		}).
+		Where("package_id = ? AND status IN ?", packageID, []entity.BookingStatus{
+			entity.BookingPending, entity.BookingPaid, entity.BookingConfirmed,
+		}).
+		Order("created_at asc").
+		Find(&bookings).Error
+	return bookings, err
+}
+
 // [FIX] INI IMPLEMENTASI YANG HILANG
 func (r *packageRepo) DecreaseQuota(ctx context.Context, packageID string, count int) error {
 	// Menggunakan gorm.Expr untuk Atomic Update (Thread Safe)
@@ -72,3 +746,22 @@ func (r *packageRepo) DecreaseQuota(ctx context.Context, packageID string, count
 
 	return nil
 }
+
+// IncreaseQuota returns seats to a package, never above its total Quota.
+func (r *packageRepo) IncreaseQuota(ctx context.Context, packageID string, count int) error {
+	// Query: UPDATE travel_packages SET available = available + count WHERE id = ? AND available + count <= quota
+	result := r.db.WithContext(ctx).
+		Model(&entity.TravelPackage{}).
+		Where("id = ? AND available + ? <= quota", packageID, count).
+		Update("available", gorm.Expr("available + ?", count))
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errors.New("quota overflow or package not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"umrah-backend/internal/entity"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PaymentRepository interface {
+	WithTx(ctx context.Context, fn func(repo PaymentRepository) error) error
+
+	CreateTransaction(ctx context.Context, trx *entity.PaymentTransaction) error
+	FindTransactionByID(ctx context.Context, id string) (*entity.PaymentTransaction, error)
+	FindTransactionByExternalID(ctx context.Context, provider, externalID string) (*entity.PaymentTransaction, error)
+	FindOpenTransaction(ctx context.Context, refType entity.PaymentReference, refID string, now time.Time) (*entity.PaymentTransaction, error)
+	UpdateTransaction(ctx context.Context, trx *entity.PaymentTransaction) error
+	GetTransactions(ctx context.Context, status string) ([]entity.PaymentTransaction, error)
+
+	// Idempotency log
+	IsEventProcessed(ctx context.Context, provider, eventID string) (bool, error)
+	RecordEvent(ctx context.Context, event *entity.PaymentWebhookEvent) error
+
+	// Repositories on the same connection: inside WithTx, what a payment
+	// settles commits together with the payment itself
+	Commerce() CommerceRepository
+	Packages() PackageRepository
+}
+
+type paymentRepo struct {
+	db *gorm.DB
+}
+
+func NewPaymentRepository(db *gorm.DB) PaymentRepository {
+	return &paymentRepo{db: db}
+}
+
+func (r *paymentRepo) WithTx(ctx context.Context, fn func(repo PaymentRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&paymentRepo{db: tx})
+	})
+}
+
+func (r *paymentRepo) Commerce() CommerceRepository { return &commerceRepo{db: r.db} }
+func (r *paymentRepo) Packages() PackageRepository  { return &packageRepo{db: r.db} }
+
+func (r *paymentRepo) CreateTransaction(ctx context.Context, trx *entity.PaymentTransaction) error {
+	return r.db.WithContext(ctx).Create(trx).Error
+}
+
+func (r *paymentRepo) FindTransactionByID(ctx context.Context, id string) (*entity.PaymentTransaction, error) {
+	var trx entity.PaymentTransaction
+	if err := r.db.WithContext(ctx).First(&trx, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &trx, nil
+}
+
+// FindTransactionByExternalID locks the row so concurrent callbacks for the
+// same charge are processed one after another.
+func (r *paymentRepo) FindTransactionByExternalID(ctx context.Context, provider, externalID string) (*entity.PaymentTransaction, error) {
+	var trx entity.PaymentTransaction
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("provider = ? AND external_id = ?", provider, externalID).
+		First(&trx).Error
+	if err != nil {
+		return nil, err
+	}
+	return &trx, nil
+}
+
+// FindOpenTransaction returns a still-payable charge for the reference, if any.
+func (r *paymentRepo) FindOpenTransaction(ctx context.Context, refType entity.PaymentReference, refID string, now time.Time) (*entity.PaymentTransaction, error) {
+	var trx entity.PaymentTransaction
+	err := r.db.WithContext(ctx).
+		Where("reference_type = ? AND reference_id = ? AND status = ? AND expires_at > ?",
+			refType, refID, entity.PaymentPending, now).
+		Order("created_at desc").
+		First(&trx).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &trx, nil
+}
+
+func (r *paymentRepo) UpdateTransaction(ctx context.Context, trx *entity.PaymentTransaction) error {
+	return r.db.WithContext(ctx).Save(trx).Error
+}
+
+// GetTransactions lists transactions, oldest first; empty status = all.
+func (r *paymentRepo) GetTransactions(ctx context.Context, status string) ([]entity.PaymentTransaction, error) {
+	var trxs []entity.PaymentTransaction
+	query := r.db.WithContext(ctx)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("created_at asc").Find(&trxs).Error
+	return trxs, err
+}
+
+func (r *paymentRepo) IsEventProcessed(ctx context.Context, provider, eventID string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&entity.PaymentWebhookEvent{}).
+		Where("provider = ? AND event_id = ?", provider, eventID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// RecordEvent ignores duplicates (ON CONFLICT DO NOTHING).
+func (r *paymentRepo) RecordEvent(ctx context.Context, event *entity.PaymentWebhookEvent) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(event).Error
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"umrah-backend/internal/entity"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrPromoExhausted = errors.New("promo code usage limit reached")
+
+type PromoRepository interface {
+	WithTx(ctx context.Context, fn func(repo PromoRepository) error) error
+
+	// Promo Codes (Admin)
+	CreatePromo(ctx context.Context, promo *entity.PromoCode) error
+	UpdatePromo(ctx context.Context, promo *entity.PromoCode) error // Replaces the restrictions too
+	GetPromos(ctx context.Context, activeOnly bool) ([]entity.PromoCode, error)
+	FindPromoByID(ctx context.Context, id string) (*entity.PromoCode, error)
+	FindPromoByCode(ctx context.Context, code string) (*entity.PromoCode, error) // Locks the row
+
+	// Usage (atomic, like PackageRepository.DecreaseQuota)
+	IncrementUsage(ctx context.Context, promoID string, maxUses int) error // ErrPromoExhausted when full
+	DecrementUsage(ctx context.Context, promoID string) error
+
+	// Redemptions
+	CountUserRedemptions(ctx context.Context, promoID, userID string) (int64, error) // Reserved + applied
+	CreateRedemption(ctx context.Context, redemption *entity.PromoRedemption) error
+	FindRedemptionByID(ctx context.Context, id string) (*entity.PromoRedemption, error) // Locks the row
+	FindRedemptionByReference(ctx context.Context, refType entity.DocumentReference, refID string) (*entity.PromoRedemption, error)
+	UpdateRedemption(ctx context.Context, redemption *entity.PromoRedemption) error
+	GetRedemptions(ctx context.Context, promoID string) ([]entity.PromoRedemption, error)
+	GetStaleReservations(ctx context.Context, before time.Time) ([]entity.PromoRedemption, error) // RESERVED since before
+}
+
+type promoRepo struct {
+	db *gorm.DB
+}
+
+func NewPromoRepository(db *gorm.DB) PromoRepository {
+	return &promoRepo{db: db}
+}
+
+func (r *promoRepo) WithTx(ctx context.Context, fn func(repo PromoRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&promoRepo{db: tx})
+	})
+}
+
+func (r *promoRepo) CreatePromo(ctx context.Context, promo *entity.PromoCode) error {
+	return r.db.WithContext(ctx).Create(promo).Error
+}
+
+func (r *promoRepo) UpdatePromo(ctx context.Context, promo *entity.PromoCode) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("promo_id = ?", promo.ID).Delete(&entity.PromoRestriction{}).Error; err != nil {
+			return err
+		}
+		for i := range promo.Restrictions {
+			promo.Restrictions[i].PromoID = promo.ID
+		}
+		if len(promo.Restrictions) > 0 {
+			if err := tx.Create(&promo.Restrictions).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Omit("Restrictions", "UsedCount").Save(promo).Error
+	})
+}
+
+func (r *promoRepo) GetPromos(ctx context.Context, activeOnly bool) ([]entity.PromoCode, error) {
+	var promos []entity.PromoCode
+	query := r.db.WithContext(ctx).Preload("Restrictions")
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+	err := query.Order("created_at desc").Find(&promos).Error
+	return promos, err
+}
+
+func (r *promoRepo) FindPromoByID(ctx context.Context, id string) (*entity.PromoCode, error) {
+	var promo entity.PromoCode
+	if err := r.db.WithContext(ctx).Preload("Restrictions").First(&promo, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &promo, nil
+}
+
+func (r *promoRepo) FindPromoByCode(ctx context.Context, code string) (*entity.PromoCode, error) {
+	var promo entity.PromoCode
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("code = ?", code).
+		First(&promo).Error
+	if err != nil {
+		return nil, err
+	}
+	// Loaded separately: FOR UPDATE cannot be combined with the preload
+	if err := r.db.WithContext(ctx).Where("promo_id = ?", promo.ID).Find(&promo.Restrictions).Error; err != nil {
+		return nil, err
+	}
+	return &promo, nil
+}
+
+func (r *promoRepo) IncrementUsage(ctx context.Context, promoID string, maxUses int) error {
+	query := r.db.WithContext(ctx).Model(&entity.PromoCode{}).Where("id = ?", promoID)
+	if maxUses > 0 {
+		query = query.Where("used_count < ?", maxUses)
+	}
+	result := query.Update("used_count", gorm.Expr("used_count + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrPromoExhausted
+	}
+	return nil
+}
+
+func (r *promoRepo) DecrementUsage(ctx context.Context, promoID string) error {
+	return r.db.WithContext(ctx).Model(&entity.PromoCode{}).
+		Where("id = ? AND used_count > 0", promoID).
+		Update("used_count", gorm.Expr("used_count - 1")).Error
+}
+
+func (r *promoRepo) CountUserRedemptions(ctx context.Context, promoID, userID string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&entity.PromoRedemption{}).
+		Where("promo_id = ? AND user_id = ? AND status <> ?", promoID, userID, entity.RedemptionReleased).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *promoRepo) CreateRedemption(ctx context.Context, redemption *entity.PromoRedemption) error {
+	return r.db.WithContext(ctx).Create(redemption).Error
+}
+
+func (r *promoRepo) FindRedemptionByID(ctx context.Context, id string) (*entity.PromoRedemption, error) {
+	var redemption entity.PromoRedemption
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&redemption, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &redemption, nil
+}
+
+func (r *promoRepo) FindRedemptionByReference(ctx context.Context, refType entity.DocumentReference, refID string) (*entity.PromoRedemption, error) {
+	var redemption entity.PromoRedemption
+	err := r.db.WithContext(ctx).
+		Where("reference_type = ? AND reference_id = ?", refType, refID).
+		First(&redemption).Error
+	if err != nil {
+		return nil, err
+	}
+	return &redemption, nil
+}
+
+func (r *promoRepo) UpdateRedemption(ctx context.Context, redemption *entity.PromoRedemption) error {
+	return r.db.WithContext(ctx).Save(redemption).Error
+}
+
+func (r *promoRepo) GetRedemptions(ctx context.Context, promoID string) ([]entity.PromoRedemption, error) {
+	var redemptions []entity.PromoRedemption
+	err := r.db.WithContext(ctx).
+		Where("promo_id = ?", promoID).
+		Order("created_at desc").
+		Find(&redemptions).Error
+	return redemptions, err
+}
+
+func (r *promoRepo) GetStaleReservations(ctx context.Context, before time.Time) ([]entity.PromoRedemption, error) {
+	var redemptions []entity.PromoRedemption
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND created_at < ?", entity.RedemptionReserved, before).
+		Find(&redemptions).Error
+	return redemptions, err
+}
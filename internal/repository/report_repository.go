@@ -0,0 +1,259 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"umrah-backend/internal/entity"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Raw report rows: amounts are in minor units of Currency.
+
+type RevenueRow struct {
+	Period   time.Time
+	Currency string
+	Amount   int64
+	Count    int64
+}
+
+type ProductRevenueRow struct {
+	ProductID   uuid.UUID
+	ProductName string
+	Currency    string
+	Amount      int64
+	Quantity    int64
+	Orders      int64
+}
+
+type CategoryRevenueRow struct {
+	Category string
+	Currency string
+	Amount   int64
+	Count    int64
+	Pax      int64
+}
+
+type OccupancyRow struct {
+	ID            uuid.UUID
+	Name          string
+	Category      string
+	DepartureDate time.Time
+	Quota         int
+	Available     int
+	PendingPax    int
+	PaidPax       int
+	ConfirmedPax  int
+}
+
+type OutstandingOrderRow struct {
+	ID        uuid.UUID
+	Customer  string
+	Phone     string
+	Status    string
+	Currency  string
+	Amount    int64
+	ExpiresAt *time.Time
+	Items     int64
+}
+
+type OutstandingBookingRow struct {
+	ID            uuid.UUID
+	Customer      string
+	Phone         string
+	PackageName   string
+	DepartureDate time.Time
+	Status        string
+	Currency      string
+	Total         int64
+	Paid          int64
+	NextDue       *time.Time
+	ExpiresAt     *time.Time
+}
+
+// ReportRepository runs read-only aggregate queries. Periods are truncated
+// on local wall time: timestamps are shifted by offsetSeconds (the agency
+// UTC offset) before date_trunc.
+type ReportRepository interface {
+	OrderRevenue(ctx context.Context, from, to time.Time, trunc string, offsetSeconds int) ([]RevenueRow, error)
+	BookingRevenue(ctx context.Context, from, to time.Time, trunc string, offsetSeconds int) ([]RevenueRow, error)
+	ProductRevenue(ctx context.Context, from, to time.Time) ([]ProductRevenueRow, error)
+	CategoryRevenue(ctx context.Context, from, to time.Time) ([]CategoryRevenueRow, error)
+	CategoryBookings(ctx context.Context, from, to time.Time) ([]CategoryRevenueRow, error)
+	PackageOccupancy(ctx context.Context, departureFrom, departureTo *time.Time) ([]OccupancyRow, error)
+	OutstandingOrders(ctx context.Context) ([]OutstandingOrderRow, error)
+	OutstandingBookings(ctx context.Context) ([]OutstandingBookingRow, error)
+}
+
+type reportRepo struct {
+	db *gorm.DB
+}
+
+func NewReportRepository(db *gorm.DB) ReportRepository {
+	return &reportRepo{db: db}
+}
+
+// completedOrders: orders whose payment was confirmed and not refunded,
+// with the time of their FIRST completion (a declined refund request
+// completes the order again). Orders older than the audit trail fall back
+// to updated_at.
+const completedOrders = `
+	SELECT o.*, COALESCE(c.completed_at, o.updated_at) AS completed_at
+	FROM orders o
+	LEFT JOIN (
+		SELECT order_id, MIN(created_at) AS completed_at
+		FROM order_events WHERE to_status = 'COMPLETED'
+		GROUP BY order_id
+	) c ON c.order_id = o.id
+	WHERE o.status IN ('COMPLETED', 'REFUND_REQUESTED')
+	  AND (c.completed_at IS NOT NULL OR o.status = 'COMPLETED')`
+
+func (r *reportRepo) OrderRevenue(ctx context.Context, from, to time.Time, trunc string, offsetSeconds int) ([]RevenueRow, error) {
+	var rows []RevenueRow
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT date_trunc(?, (co.completed_at AT TIME ZONE 'UTC') + make_interval(secs => ?)) AS period,
+		       co.amount_currency AS currency, SUM(co.amount_minor) AS amount, COUNT(*) AS count
+		FROM (`+completedOrders+`) co
+		WHERE co.completed_at >= ? AND co.completed_at < ?
+		GROUP BY period, currency
+		ORDER BY period, currency`,
+		trunc, offsetSeconds, from, to).Scan(&rows).Error
+	return rows, err
+}
+
+// bookingPayments is one row per payment received for a booking: every
+// PAID installment, and the full price of a PAID/CONFIRMED booking without
+// a payment plan (its transfer was checked by hand at approval). Bookings
+// paid before paid_at existed fall back to updated_at.
+const bookingPayments = `
+	SELECT i.booking_id, i.paid_at, i.amount_currency AS currency, i.amount_minor AS amount
+	FROM booking_installments i
+	JOIN bookings b ON b.id = i.booking_id
+	WHERE i.status = 'PAID' AND b.status <> 'REFUNDED'
+	UNION ALL
+	SELECT b.id, COALESCE(b.paid_at, b.updated_at), b.total_price_currency, b.total_price_minor
+	FROM bookings b
+	WHERE b.status IN ('PAID', 'CONFIRMED')
+	  AND NOT EXISTS (SELECT 1 FROM booking_installments i WHERE i.booking_id = b.id)`
+
+func (r *reportRepo) BookingRevenue(ctx context.Context, from, to time.Time, trunc string, offsetSeconds int) ([]RevenueRow, error) {
+	var rows []RevenueRow
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT date_trunc(?, (bp.paid_at AT TIME ZONE 'UTC') + make_interval(secs => ?)) AS period,
+		       bp.currency, SUM(bp.amount) AS amount, COUNT(*) AS count
+		FROM (`+bookingPayments+`) bp
+		WHERE bp.paid_at >= ? AND bp.paid_at < ?
+		GROUP BY period, currency
+		ORDER BY period, currency`,
+		trunc, offsetSeconds, from, to).Scan(&rows).Error
+	return rows, err
+}
+
+func (r *reportRepo) ProductRevenue(ctx context.Context, from, to time.Time) ([]ProductRevenueRow, error) {
+	var rows []ProductRevenueRow
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT oi.product_id, MAX(oi.product_name) AS product_name, oi.subtotal_currency AS currency,
+		       SUM(oi.subtotal_minor) AS amount, SUM(oi.quantity) AS quantity, COUNT(DISTINCT oi.order_id) AS orders
+		FROM order_items oi
+		JOIN (`+completedOrders+`) co ON co.id = oi.order_id
+		WHERE co.completed_at >= ? AND co.completed_at < ?
+		GROUP BY oi.product_id, oi.subtotal_currency
+		ORDER BY amount DESC`,
+		from, to).Scan(&rows).Error
+	return rows, err
+}
+
+// CategoryRevenue: money received per package category.
+func (r *reportRepo) CategoryRevenue(ctx context.Context, from, to time.Time) ([]CategoryRevenueRow, error) {
+	var rows []CategoryRevenueRow
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT p.category, bp.currency, SUM(bp.amount) AS amount, COUNT(*) AS count
+		FROM (`+bookingPayments+`) bp
+		JOIN bookings b ON b.id = bp.booking_id
+		JOIN travel_packages p ON p.id = b.package_id
+		WHERE bp.paid_at >= ? AND bp.paid_at < ?
+		GROUP BY p.category, bp.currency`,
+		from, to).Scan(&rows).Error
+	return rows, err
+}
+
+// CategoryBookings: bookings made in the period that still hold seats.
+func (r *reportRepo) CategoryBookings(ctx context.Context, from, to time.Time) ([]CategoryRevenueRow, error) {
+	var rows []CategoryRevenueRow
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT p.category, b.total_price_currency AS currency, SUM(b.total_price_minor) AS amount,
+		       COUNT(*) AS count, SUM(b.pax_count) AS pax
+		FROM bookings b
+		JOIN travel_packages p ON p.id = b.package_id
+		WHERE b.status IN ? AND b.created_at >= ? AND b.created_at < ?
+		GROUP BY p.category, currency`,
+		[]entity.BookingStatus{entity.BookingPending, entity.BookingPaid, entity.BookingConfirmed}, from, to).Scan(&rows).Error
+	return rows, err
+}
+
+func (r *reportRepo) PackageOccupancy(ctx context.Context, departureFrom, departureTo *time.Time) ([]OccupancyRow, error) {
+	query := r.db.WithContext(ctx).
+		Table("travel_packages p").
+		Select(`p.id, p.name, p.category, p.departure_date, p.quota, p.available,
+			COALESCE(SUM(b.pax_count) FILTER (WHERE b.status = ?), 0) AS pending_pax,
+			COALESCE(SUM(b.pax_count) FILTER (WHERE b.status = ?), 0) AS paid_pax,
+			COALESCE(SUM(b.pax_count) FILTER (WHERE b.status = ?), 0) AS confirmed_pax`,
+			entity.BookingPending, entity.BookingPaid, entity.BookingConfirmed).
+		Joins("LEFT JOIN bookings b ON b.package_id = p.id").
+		Where("p.deleted_at IS NULL")
+
+	if departureFrom != nil {
+		query = query.Where("p.departure_date >= ?", *departureFrom)
+	}
+	if departureTo != nil {
+		query = query.Where("p.departure_date < ?", *departureTo)
+	}
+
+	var rows []OccupancyRow
+	err := query.Group("p.id").Order("p.departure_date asc, p.name asc").Scan(&rows).Error
+	return rows, err
+}
+
+// OutstandingOrders: orders still waiting for (a new) payment.
+func (r *reportRepo) OutstandingOrders(ctx context.Context) ([]OutstandingOrderRow, error) {
+	var rows []OutstandingOrderRow
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT o.id, u.full_name AS customer, u.phone_number AS phone, o.status,
+		       o.amount_currency AS currency, o.amount_minor AS amount, o.expires_at,
+		       (SELECT COUNT(*) FROM order_items oi WHERE oi.order_id = o.id) AS items
+		FROM orders o
+		JOIN users u ON u.id = o.user_id
+		WHERE o.status IN ?
+		ORDER BY o.expires_at ASC NULLS LAST`,
+		[]entity.OrderStatus{entity.OrderPending, entity.OrderRejected}).Scan(&rows).Error
+	return rows, err
+}
+
+// OutstandingBookings: live bookings whose paid installments do not cover
+// the total price yet. A PAID/CONFIRMED booking without a payment plan was
+// paid in full.
+func (r *reportRepo) OutstandingBookings(ctx context.Context) ([]OutstandingBookingRow, error) {
+	var rows []OutstandingBookingRow
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT * FROM (
+			SELECT b.id, u.full_name AS customer, u.phone_number AS phone, p.name AS package_name,
+			       p.departure_date, b.status, b.total_price_currency AS currency, b.total_price_minor AS total,
+			       CASE WHEN COUNT(i.id) = 0 AND b.status IN ? THEN b.total_price_minor
+			            ELSE COALESCE(SUM(i.amount_minor) FILTER (WHERE i.status = ?), 0) END AS paid,
+			       MIN(i.due_date) FILTER (WHERE i.status <> ?) AS next_due,
+			       b.expires_at
+			FROM bookings b
+			JOIN users u ON u.id = b.user_id
+			JOIN travel_packages p ON p.id = b.package_id
+			LEFT JOIN booking_installments i ON i.booking_id = b.id
+			WHERE b.status IN ?
+			GROUP BY b.id, u.id, p.id
+		) ob
+		WHERE ob.total > ob.paid
+		ORDER BY ob.next_due ASC NULLS LAST, ob.departure_date ASC`,
+		[]entity.BookingStatus{entity.BookingPaid, entity.BookingConfirmed},
+		entity.InstallmentPaid, entity.InstallmentPaid,
+		[]entity.BookingStatus{entity.BookingPending, entity.BookingPaid, entity.BookingConfirmed}).Scan(&rows).Error
+	return rows, err
+}
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"umrah-backend/internal/entity"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RoomingRepository interface {
+	WithTx(ctx context.Context, fn func(repo RoomingRepository) error) error
+
+	// Source data: paid/confirmed bookings with their passengers
+	GetRoomingBookings(ctx context.Context, packageID string) ([]entity.Booking, error)
+	FindPassengerByID(ctx context.Context, id string) (*entity.BookingPassenger, error)
+	FindBookingByID(ctx context.Context, id string) (*entity.Booking, error) // Locks the row
+
+	// Rooms
+	GetRooms(ctx context.Context, packageID string) ([]entity.Room, error)
+	FindRoomByID(ctx context.Context, id string) (*entity.Room, error)
+	CreateRoom(ctx context.Context, room *entity.Room) error
+	DeleteEmptyGeneratedRooms(ctx context.Context, packageID string) error
+
+	// Assignments
+	CreateAssignments(ctx context.Context, assignments []entity.RoomAssignment) error
+	FindAssignmentByPassenger(ctx context.Context, passengerID string) (*entity.RoomAssignment, error)
+	SaveAssignment(ctx context.Context, assignment *entity.RoomAssignment) error
+	DeleteAssignment(ctx context.Context, passengerID string) error // gorm.ErrRecordNotFound if not assigned
+	DeleteAutoAssignments(ctx context.Context, packageID string) error
+	DeleteStaleAssignments(ctx context.Context, packageID string, keepPassengerIDs []string) error
+}
+
+type roomingRepo struct {
+	db *gorm.DB
+}
+
+func NewRoomingRepository(db *gorm.DB) RoomingRepository {
+	return &roomingRepo{db: db}
+}
+
+func (r *roomingRepo) WithTx(ctx context.Context, fn func(repo RoomingRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&roomingRepo{db: tx})
+	})
+}
+
+// -----------------------------------------------------------
+// Implementation: Source Data
+// -----------------------------------------------------------
+
+func (r *roomingRepo) GetRoomingBookings(ctx context.Context, packageID string) ([]entity.Booking, error) {
+	var bookings []entity.Booking
+	err := r.db.WithContext(ctx).
+		Preload("Passengers", func(db *gorm.DB) *gorm.DB {
+			return db.Order("sequence asc")
+		}).
+		Where("package_id = ? AND status IN ?", packageID, []entity.BookingStatus{
+			entity.BookingPaid, entity.BookingConfirmed,
+		}).
+		Order("created_at asc").
+		Find(&bookings).Error
+	return bookings, err
+}
+
+func (r *roomingRepo) FindPassengerByID(ctx context.Context, id string) (*entity.BookingPassenger, error) {
+	var passenger entity.BookingPassenger
+	if err := r.db.WithContext(ctx).First(&passenger, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &passenger, nil
+}
+
+// FindBookingByID locks the booking so its status cannot change while a
+// passenger of it is being placed.
+func (r *roomingRepo) FindBookingByID(ctx context.Context, id string) (*entity.Booking, error) {
+	var booking entity.Booking
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&booking, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &booking, nil
+}
+
+// -----------------------------------------------------------
+// Implementation: Rooms
+// -----------------------------------------------------------
+
+func (r *roomingRepo) GetRooms(ctx context.Context, packageID string) ([]entity.Room, error) {
+	var rooms []entity.Room
+	err := r.db.WithContext(ctx).
+		Preload("Occupants.Passenger").
+		Where("package_id = ?", packageID).
+		Order("label asc").
+		Find(&rooms).Error
+	return rooms, err
+}
+
+func (r *roomingRepo) FindRoomByID(ctx context.Context, id string) (*entity.Room, error) {
+	var room entity.Room
+	err := r.db.WithContext(ctx).
+		Preload("Occupants").
+		First(&room, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &room, nil
+}
+
+func (r *roomingRepo) CreateRoom(ctx context.Context, room *entity.Room) error {
+	return r.db.WithContext(ctx).Create(room).Error
+}
+
+// DeleteEmptyGeneratedRooms drops the allocator's rooms that no longer have
+// anyone in them; rooms an admin created stay, even when empty.
+func (r *roomingRepo) DeleteEmptyGeneratedRooms(ctx context.Context, packageID string) error {
+	return r.db.WithContext(ctx).
+		Where("package_id = ? AND auto_generated = ? AND id NOT IN (?)", packageID, true,
+			r.db.Model(&entity.RoomAssignment{}).Select("room_id")).
+		Delete(&entity.Room{}).Error
+}
+
+// -----------------------------------------------------------
+// Implementation: Assignments
+// -----------------------------------------------------------
+
+func (r *roomingRepo) CreateAssignments(ctx context.Context, assignments []entity.RoomAssignment) error {
+	if len(assignments) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(&assignments).Error
+}
+
+func (r *roomingRepo) FindAssignmentByPassenger(ctx context.Context, passengerID string) (*entity.RoomAssignment, error) {
+	var assignment entity.RoomAssignment
+	err := r.db.WithContext(ctx).Where("passenger_id = ?", passengerID).First(&assignment).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // Not assigned yet
+		}
+		return nil, err
+	}
+	return &assignment, nil
+}
+
+func (r *roomingRepo) SaveAssignment(ctx context.Context, assignment *entity.RoomAssignment) error {
+	return r.db.WithContext(ctx).Save(assignment).Error
+}
+
+func (r *roomingRepo) DeleteAssignment(ctx context.Context, passengerID string) error {
+	result := r.db.WithContext(ctx).Where("passenger_id = ?", passengerID).Delete(&entity.RoomAssignment{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *roomingRepo) DeleteAutoAssignments(ctx context.Context, packageID string) error {
+	return r.db.WithContext(ctx).
+		Where("is_manual = ? AND room_id IN (?)", false,
+			r.db.Model(&entity.Room{}).Select("id").Where("package_id = ?", packageID)).
+		Delete(&entity.RoomAssignment{}).Error
+}
+
+// DeleteStaleAssignments drops assignments of passengers who are no longer
+// on a paid/confirmed booking (e.g. cancelled after an admin override).
+func (r *roomingRepo) DeleteStaleAssignments(ctx context.Context, packageID string, keepPassengerIDs []string) error {
+	query := r.db.WithContext(ctx).
+		Where("room_id IN (?)", r.db.Model(&entity.Room{}).Select("id").Where("package_id = ?", packageID))
+	if len(keepPassengerIDs) > 0 {
+		query = query.Where("passenger_id NOT IN ?", keepPassengerIDs)
+	}
+	return query.Delete(&entity.RoomAssignment{}).Error
+}
@@ -0,0 +1,501 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"umrah-backend/internal/entity"
+	"umrah-backend/internal/repository"
+	"umrah-backend/pkg/money"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAgentNotFound   = errors.New("agent not found")
+	ErrInvalidReferral = errors.New("invalid referral code")
+)
+
+// AgentService manages the sales agents, their commission rules and
+// statements. Bookings are attributed to an agent through its referral
+// code; commissions are earned when the booking is confirmed.
+type AgentService interface {
+	// Admin
+	CreateAgent(ctx context.Context, req entity.CreateAgentDTO) (*entity.Agent, error)
+	UpdateAgent(ctx context.Context, id string, req entity.UpdateAgentDTO) (*entity.Agent, error)
+	GetAgents(ctx context.Context) ([]entity.Agent, error)
+	GetAgent(ctx context.Context, id string) (*entity.Agent, error)
+	GetRules(ctx context.Context, packageID string) ([]entity.CommissionRule, error) // "" = defaults
+	SetRules(ctx context.Context, packageID string, req entity.SetCommissionRulesDTO) ([]entity.CommissionRule, error)
+	CreatePayout(ctx context.Context, adminID, agentID string, req entity.CreatePayoutDTO) (*entity.CommissionPayout, error)
+
+	// Agent (and admin) views
+	GetMyAgent(ctx context.Context, userID string) (*entity.Agent, error)
+	GetBookings(ctx context.Context, agentID string, filter entity.BookingFilter) ([]entity.Booking, int64, error)
+	GetStatement(ctx context.Context, agentID string, from, to *time.Time) (*entity.AgentStatement, error)
+
+	// Booking hooks
+	ResolveReferral(ctx context.Context, code, userID string) (*entity.Agent, error)
+	RecordCommissions(ctx context.Context, bookingID string) error // Idempotent
+	CancelCommissions(ctx context.Context, bookingID string) error
+}
+
+type agentService struct {
+	repo     repository.AgentRepository
+	pkgRepo  repository.PackageRepository
+	userRepo repository.UserRepository
+}
+
+func NewAgentService(repo repository.AgentRepository, pkgRepo repository.PackageRepository, userRepo repository.UserRepository) AgentService {
+	return &agentService{repo: repo, pkgRepo: pkgRepo, userRepo: userRepo}
+}
+
+func (s *agentService) CreateAgent(ctx context.Context, req entity.CreateAgentDTO) (*entity.Agent, error) {
+	users, err := s.userRepo.FindByIDs(ctx, []string{req.UserID})
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, errors.New("user not found")
+	}
+	if users[0].Role != entity.RoleJamaah && users[0].Role != entity.RoleAgent {
+		return nil, fmt.Errorf("a %s user cannot become an agent", users[0].Role)
+	}
+	if _, err := s.repo.FindAgentByUserID(ctx, req.UserID); err == nil {
+		return nil, errors.New("user is already an agent")
+	}
+
+	agent := &entity.Agent{
+		ID:                uuid.New(),
+		UserID:            uuid.MustParse(req.UserID),
+		ReferralCode:      strings.ToUpper(strings.TrimSpace(req.ReferralCode)),
+		IsActive:          true,
+		BankName:          req.BankName,
+		BankAccountNumber: req.BankAccountNumber,
+		BankAccountName:   req.BankAccountName,
+	}
+	if agent.ReferralCode == "" {
+		agent.ReferralCode = "AG" + shortID(agent.ID)
+	}
+	if req.ParentID != "" {
+		if err := s.checkParent(ctx, agent, req.ParentID); err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.repo.WithTx(ctx, func(repo repository.AgentRepository) error {
+		if err := repo.CreateAgent(ctx, agent); err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) || strings.Contains(err.Error(), "duplicate key") {
+				return fmt.Errorf("referral code %s is already taken", agent.ReferralCode)
+			}
+			return err
+		}
+		// The new role is in the user's token after the next login
+		return repo.SetUserRole(ctx, req.UserID, entity.RoleAgent)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetAgent(ctx, agent.ID.String())
+}
+
+// checkParent: only top-level agents can be master agents (two tiers).
+func (s *agentService) checkParent(ctx context.Context, agent *entity.Agent, parentID string) error {
+	if parentID == agent.ID.String() {
+		return errors.New("an agent cannot be its own master agent")
+	}
+	parent, err := s.repo.FindAgentByID(ctx, parentID)
+	if err != nil {
+		return fmt.Errorf("master agent: %w", ErrAgentNotFound)
+	}
+	if parent.ParentID != nil {
+		return errors.New("a sub-agent cannot be a master agent")
+	}
+	subAgents, err := s.repo.CountSubAgents(ctx, agent.ID.String())
+	if err != nil {
+		return err
+	}
+	if subAgents > 0 {
+		return errors.New("a master agent cannot sell under another master agent")
+	}
+	agent.ParentID = &parent.ID
+	return nil
+}
+
+func (s *agentService) UpdateAgent(ctx context.Context, id string, req entity.UpdateAgentDTO) (*entity.Agent, error) {
+	agent, err := s.GetAgent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case req.ClearParent:
+		agent.ParentID = nil
+	case req.ParentID != nil:
+		if err := s.checkParent(ctx, agent, *req.ParentID); err != nil {
+			return nil, err
+		}
+	}
+	if req.IsActive != nil {
+		agent.IsActive = *req.IsActive
+	}
+	if req.BankName != nil {
+		agent.BankName = *req.BankName
+	}
+	if req.BankAccountNumber != nil {
+		agent.BankAccountNumber = *req.BankAccountNumber
+	}
+	if req.BankAccountName != nil {
+		agent.BankAccountName = *req.BankAccountName
+	}
+	agent.UpdatedAt = time.Now()
+
+	if err := s.repo.UpdateAgent(ctx, agent); err != nil {
+		return nil, err
+	}
+	return agent, nil
+}
+
+func (s *agentService) GetAgents(ctx context.Context) ([]entity.Agent, error) {
+	return s.repo.GetAgents(ctx)
+}
+
+func (s *agentService) GetAgent(ctx context.Context, id string) (*entity.Agent, error) {
+	agent, err := s.repo.FindAgentByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAgentNotFound
+		}
+		return nil, err
+	}
+	return agent, nil
+}
+
+func (s *agentService) GetMyAgent(ctx context.Context, userID string) (*entity.Agent, error) {
+	agent, err := s.repo.FindAgentByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAgentNotFound
+		}
+		return nil, err
+	}
+	return agent, nil
+}
+
+func (s *agentService) GetRules(ctx context.Context, packageID string) ([]entity.CommissionRule, error) {
+	pkgID, _, err := s.rulePackage(ctx, packageID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetRules(ctx, pkgID)
+}
+
+func (s *agentService) SetRules(ctx context.Context, packageID string, req entity.SetCommissionRulesDTO) ([]entity.CommissionRule, error) {
+	pkgID, pkg, err := s.rulePackage(ctx, packageID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	seen := make(map[int]bool)
+	rules := make([]entity.CommissionRule, 0, len(req.Rules))
+	for _, r := range req.Rules {
+		if r.Level != entity.LevelAgent && r.Level != entity.LevelMaster {
+			return nil, errors.New("level must be 1 (agent) or 2 (master agent)")
+		}
+		if seen[r.Level] {
+			return nil, fmt.Errorf("level %d is set twice", r.Level)
+		}
+		seen[r.Level] = true
+
+		rule := entity.CommissionRule{ID: uuid.New(), PackageID: pkgID, Level: r.Level, Type: r.Type, Amount: money.Zero(money.Default), CreatedAt: now}
+		switch r.Type {
+		case entity.CommissionPercent:
+			if r.PercentBP <= 0 || r.PercentBP > 10000 {
+				return nil, errors.New("percent_bp must be between 1 and 10000")
+			}
+			rule.PercentBP = r.PercentBP
+		case entity.CommissionPerPax:
+			if r.Amount == nil || !r.Amount.IsPositive() {
+				return nil, errors.New("amount must be greater than zero")
+			}
+			if err := r.Amount.Validate(); err != nil {
+				return nil, fmt.Errorf("amount: %w", err)
+			}
+			if pkg != nil && !r.Amount.SameCurrency(pkg.PriceQuad) {
+				return nil, fmt.Errorf("%w: package is priced in %s", money.ErrCurrencyMismatch, pkg.PriceQuad.Currency)
+			}
+			rule.Amount = *r.Amount
+		default:
+			return nil, errors.New("type must be PERCENT or PER_PAX")
+		}
+		rules = append(rules, rule)
+	}
+
+	if err := s.repo.ReplaceRules(ctx, pkgID, rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// rulePackage resolves the package of a rule set; "" means the defaults.
+func (s *agentService) rulePackage(ctx context.Context, packageID string) (*uuid.UUID, *entity.TravelPackage, error) {
+	if packageID == "" {
+		return nil, nil, nil
+	}
+	pkg, err := s.pkgRepo.FindPackageByID(ctx, packageID)
+	if err != nil {
+		return nil, nil, errors.New("package not found")
+	}
+	return &pkg.ID, pkg, nil
+}
+
+func (s *agentService) ResolveReferral(ctx context.Context, code, userID string) (*entity.Agent, error) {
+	agent, err := s.repo.FindAgentByCode(ctx, strings.ToUpper(strings.TrimSpace(code)))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidReferral
+		}
+		return nil, err
+	}
+	if !agent.IsActive {
+		return nil, ErrInvalidReferral
+	}
+	if agent.UserID.String() == userID {
+		return nil, fmt.Errorf("%w: agents cannot refer their own bookings", ErrInvalidReferral)
+	}
+	return agent, nil
+}
+
+// RecordCommissions writes the commissions of a confirmed booking: one for
+// the referring agent and one for its master agent, each only when a rule
+// exists for that level. Calling it again changes nothing.
+func (s *agentService) RecordCommissions(ctx context.Context, bookingID string) error {
+	booking, err := s.pkgRepo.FindBookingByID(ctx, bookingID)
+	if err != nil {
+		return ErrBookingNotFound
+	}
+	if booking.AgentID == nil || booking.Status != entity.BookingConfirmed {
+		return nil
+	}
+
+	agent, err := s.repo.FindAgentByID(ctx, booking.AgentID.String())
+	if err != nil {
+		return err
+	}
+	pkg, err := s.pkgRepo.FindPackageByID(ctx, booking.PackageID.String())
+	if err != nil {
+		return errors.New("package not found")
+	}
+	rules, err := s.repo.FindApplicableRules(ctx, pkg.ID.String())
+	if err != nil {
+		return err
+	}
+
+	customer := ""
+	if users, err := s.userRepo.FindByIDs(ctx, []string{booking.UserID.String()}); err == nil && len(users) > 0 {
+		customer = users[0].FullName
+	}
+
+	earners := map[int]*entity.Agent{entity.LevelAgent: agent}
+	if agent.ParentID != nil {
+		if parent, err := s.repo.FindAgentByID(ctx, agent.ParentID.String()); err == nil {
+			earners[entity.LevelMaster] = parent
+		}
+	}
+
+	now := time.Now()
+	var commissions []entity.Commission
+	for _, level := range []int{entity.LevelAgent, entity.LevelMaster} {
+		earner := earners[level]
+		rule := pickCommissionRule(rules, level)
+		if earner == nil || !earner.IsActive || rule == nil {
+			continue
+		}
+		amount, err := commissionAmount(rule, booking)
+		if err != nil {
+			return err
+		}
+		if !amount.IsPositive() {
+			continue
+		}
+		commissions = append(commissions, entity.Commission{
+			ID:           uuid.New(),
+			AgentID:      earner.ID,
+			BookingID:    booking.ID,
+			Level:        level,
+			PackageName:  pkg.Name,
+			CustomerName: customer,
+			PaxCount:     booking.PaxCount,
+			Basis:        booking.TotalPrice,
+			Amount:       amount,
+			Status:       entity.CommissionEarned,
+			EarnedAt:     now,
+		})
+	}
+	return s.repo.CreateCommissions(ctx, commissions)
+}
+
+// pickCommissionRule: the package's own rule for a level wins over the default.
+func pickCommissionRule(rules []entity.CommissionRule, level int) *entity.CommissionRule {
+	var fallback *entity.CommissionRule
+	for i := range rules {
+		if rules[i].Level != level {
+			continue
+		}
+		if rules[i].PackageID != nil {
+			return &rules[i]
+		}
+		fallback = &rules[i]
+	}
+	return fallback
+}
+
+func commissionAmount(rule *entity.CommissionRule, booking *entity.Booking) (money.Money, error) {
+	switch rule.Type {
+	case entity.CommissionPercent:
+		return booking.TotalPrice.Percent(rule.PercentBP), nil
+	case entity.CommissionPerPax:
+		if !rule.Amount.SameCurrency(booking.TotalPrice) {
+			return money.Money{}, fmt.Errorf("%w: commission rule in %s, booking in %s", money.ErrCurrencyMismatch, rule.Amount.Currency, booking.TotalPrice.Currency)
+		}
+		return rule.Amount.Mul(int64(booking.PaxCount)), nil
+	default:
+		return money.Money{}, fmt.Errorf("unknown commission type %s", rule.Type)
+	}
+}
+
+func (s *agentService) CancelCommissions(ctx context.Context, bookingID string) error {
+	_, err := s.repo.CancelCommissions(ctx, bookingID, time.Now())
+	return err
+}
+
+// CreatePayout pays out every earned commission of the agent in one currency.
+func (s *agentService) CreatePayout(ctx context.Context, adminID, agentID string, req entity.CreatePayoutDTO) (*entity.CommissionPayout, error) {
+	agent, err := s.GetAgent(ctx, agentID)
+	if err != nil {
+		return nil, err
+	}
+	currency := money.Default
+	if req.Currency != "" {
+		if currency, err = money.ParseCurrency(req.Currency); err != nil {
+			return nil, err
+		}
+	}
+
+	var payout *entity.CommissionPayout
+	err = s.repo.WithTx(ctx, func(repo repository.AgentRepository) error {
+		earned, err := repo.FindEarnedCommissions(ctx, agent.ID.String(), string(currency))
+		if err != nil {
+			return err
+		}
+		if len(earned) == 0 {
+			return fmt.Errorf("no earned commission to pay out in %s", currency)
+		}
+
+		now := time.Now()
+		payout = &entity.CommissionPayout{
+			ID:        uuid.New(),
+			AgentID:   agent.ID,
+			Amount:    money.Zero(currency),
+			Count:     len(earned),
+			Reference: req.Reference,
+			Note:      req.Note,
+			PaidBy:    uuid.MustParse(adminID),
+			PaidAt:    now,
+		}
+		ids := make([]uuid.UUID, 0, len(earned))
+		for _, c := range earned {
+			payout.Amount.Amount += c.Amount.Amount
+			ids = append(ids, c.ID)
+		}
+
+		if err := repo.CreatePayout(ctx, payout); err != nil {
+			return err
+		}
+		return repo.MarkCommissionsPaid(ctx, ids, payout.ID, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return payout, nil
+}
+
+// GetBookings lists the bookings an agent referred. Customers are reduced
+// to name and phone: agents are the sales contact, not staff.
+func (s *agentService) GetBookings(ctx context.Context, agentID string, filter entity.BookingFilter) ([]entity.Booking, int64, error) {
+	filter.AgentID = agentID
+	bookings, total, err := s.pkgRepo.ListBookings(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range bookings {
+		if u := bookings[i].User; u != nil {
+			bookings[i].User = &entity.User{ID: u.ID, FullName: u.FullName, PhoneNumber: u.PhoneNumber}
+		}
+	}
+	return bookings, total, nil
+}
+
+func (s *agentService) GetStatement(ctx context.Context, agentID string, from, to *time.Time) (*entity.AgentStatement, error) {
+	agent, err := s.GetAgent(ctx, agentID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Commissions are recorded best-effort on confirmation; catch up on any
+	// that failed, like documents are re-issued when listed.
+	if ids, err := s.repo.FindUncommissionedBookingIDs(ctx, agent.ID.String()); err == nil {
+		for _, id := range ids {
+			if err := s.RecordCommissions(ctx, id); err != nil {
+				log.Printf("Failed to record commissions for booking %s: %v", id, err)
+			}
+		}
+	}
+
+	rows, err := s.repo.GetCommissionTotals(ctx, agent.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	commissions, err := s.repo.GetCommissions(ctx, agent.ID.String(), from, to)
+	if err != nil {
+		return nil, err
+	}
+	payouts, err := s.repo.GetPayouts(ctx, agent.ID.String(), from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &entity.AgentStatement{
+		Agent:       agent,
+		From:        from,
+		To:          to,
+		Totals:      []entity.CommissionTotal{},
+		Commissions: commissions,
+		Payouts:     payouts,
+	}
+	index := make(map[string]int)
+	for _, row := range rows {
+		i, ok := index[row.Currency]
+		if !ok {
+			i = len(statement.Totals)
+			index[row.Currency] = i
+			cur := money.Currency(row.Currency)
+			statement.Totals = append(statement.Totals, entity.CommissionTotal{Earned: money.Zero(cur), Paid: money.Zero(cur), Balance: money.Zero(cur)})
+		}
+		t := &statement.Totals[i]
+		switch entity.CommissionStatus(row.Status) {
+		case entity.CommissionEarned:
+			t.Earned.Amount += row.Amount
+			t.Balance.Amount += row.Amount
+		case entity.CommissionPaid:
+			t.Earned.Amount += row.Amount
+			t.Paid.Amount += row.Amount
+		}
+	}
+	return statement, nil
+}
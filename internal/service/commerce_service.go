@@ -2,104 +2,1402 @@ package service
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 	"umrah-backend/internal/entity"
 	"umrah-backend/internal/repository"
+	"umrah-backend/pkg/money"
+	"umrah-backend/pkg/notification"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// maxCartQuantity: per-product limit, same as the OrderItemDTO validation
+	maxCartQuantity = 100
+
+	// orderHoldDuration: how long an unpaid order keeps its stock reserved
+	orderHoldDuration = 24 * time.Hour
+)
+
+var (
+	ErrEmptyCart        = errors.New("cart is empty")
+	ErrCartItemNotFound = errors.New("product is not in the cart")
+	ErrOutOfStock       = errors.New("out of stock")
+	ErrOrderExpired     = errors.New("order has expired, please order again")
+	ErrOrderNotFound    = errors.New("order not found")
+
+	ErrInvalidOrderTransition = errors.New("invalid order status transition")
+	ErrRefundNotFound         = errors.New("refund request not found")
+	ErrInvalidCursor          = errors.New("invalid cursor")
+
+	ErrProductNotFound  = errors.New("product not found")
+	ErrCategoryNotFound = errors.New("category not found")
+)
+
+// OrderTransitionError is returned when an order cannot move to the
+// requested status. errors.Is(err, ErrInvalidOrderTransition) matches it.
+type OrderTransitionError struct {
+	From entity.OrderStatus
+	To   entity.OrderStatus
+}
+
+func (e *OrderTransitionError) Error() string {
+	return fmt.Sprintf("order is %s and cannot move to %s", e.From, e.To)
+}
+
+func (e *OrderTransitionError) Is(target error) bool {
+	return target == ErrInvalidOrderTransition
+}
+
+// orderActor: who causes a status change, recorded in the audit trail
+type orderActor struct {
+	Type entity.OrderActorType
+	ID   *uuid.UUID
+}
+
+func actorFromID(actorType entity.OrderActorType, id string) orderActor {
+	actor := orderActor{Type: actorType}
+	if parsed, err := uuid.Parse(id); err == nil {
+		actor.ID = &parsed
+	}
+	return actor
+}
+
+var (
+	gatewayActor = orderActor{Type: entity.ActorGateway}
+	systemActor  = orderActor{Type: entity.ActorSystem}
 )
 
 type CommerceService interface {
 	// Product
 	CreateProduct(ctx context.Context, req entity.Product) error
-	GetCatalog(ctx context.Context) ([]entity.Product, error)
+	GetCatalog(ctx context.Context, filter entity.ProductFilter, currency string) ([]entity.Product, error)
+	GetProduct(ctx context.Context, productID string, includeHidden bool) (*entity.Product, error)
+	UpdateProduct(ctx context.Context, productID string, req entity.UpdateProductDTO) (*entity.Product, error)                   // Admin only
+	DeleteProduct(ctx context.Context, productID string) error                                                                   // Admin only
+	UpdateVariant(ctx context.Context, productID, variantID string, req entity.UpdateVariantDTO) (*entity.ProductVariant, error) // Admin only
+	AddProductImage(ctx context.Context, productID, url string, sortOrder int) (*entity.ProductImage, error)                     // Admin only
+	DeleteProductImage(ctx context.Context, productID, imageID string) (*entity.ProductImage, error)                             // Admin only
+
+	// Product Categories
+	GetCategories(ctx context.Context) ([]entity.ProductCategory, error)
+	CreateCategory(ctx context.Context, req entity.ProductCategoryDTO) (*entity.ProductCategory, error)            // Admin only
+	UpdateCategory(ctx context.Context, id string, req entity.ProductCategoryDTO) (*entity.ProductCategory, error) // Admin only
+	DeleteCategory(ctx context.Context, id string) error                                                           // Admin only
+
+	// Cart
+	GetCart(ctx context.Context, userID string) (*entity.Cart, error)
+	AddToCart(ctx context.Context, userID string, req entity.AddCartItemDTO) (*entity.Cart, error)
+	UpdateCartItem(ctx context.Context, userID, productID, variantID string, quantity int) (*entity.Cart, error)
+	RemoveFromCart(ctx context.Context, userID, productID, variantID string) (*entity.Cart, error)
+	Checkout(ctx context.Context, userID, promoCode string) (*entity.Order, error) // Cart -> Order
+	QuoteCart(ctx context.Context, userID, promoCode string) (*entity.PromoQuote, error)
 
 	// Order Flow
-	CreateOrder(ctx context.Context, userID, productID string) (*entity.Order, error)
+	CreateOrder(ctx context.Context, userID string, items []entity.OrderItemDTO, promoCode string) (*entity.Order, error)
 	UploadPaymentProof(ctx context.Context, orderID, imageURL string, userID string) error
-	VerifyOrder(ctx context.Context, orderID string) error // Admin only
+	VerifyOrder(ctx context.Context, adminID, orderID string) error         // Admin only
+	RejectOrder(ctx context.Context, adminID, orderID, reason string) error // Admin only
+	GetOrderProofs(ctx context.Context, orderID, userID, role string) ([]entity.OrderProof, error)
+	GetOrderEvents(ctx context.Context, orderID string) ([]entity.OrderEvent, error) // Admin only
+
+	// Cancellation & Refunds
+	CancelOrder(ctx context.Context, userID, orderID, reason string) error // Unpaid orders only
+	RequestRefund(ctx context.Context, userID, orderID, reason string) (*entity.RefundRequest, error)
+	GetRefundRequests(ctx context.Context, status string) ([]entity.RefundRequest, error)                   // Admin only
+	ApproveRefund(ctx context.Context, adminID, requestID, note string) (*entity.RefundRequest, error)      // Admin only
+	DeclineRefund(ctx context.Context, adminID, requestID, note string) (*entity.RefundRequest, error)      // Admin only
+	CompleteRefund(ctx context.Context, adminID, requestID, proofURL string) (*entity.RefundRequest, error) // Admin only
+	// Payment gateway: run inside the caller's transaction (repo); the
+	// returned func sends receipts/notifications once it has committed
+	SettleOrderPayment(ctx context.Context, repo repository.CommerceRepository, orderID string) (func(), error)
+	RefundOrderPayment(ctx context.Context, repo repository.CommerceRepository, adminID, orderID, note string) (func(), error)
 	GetMyOrders(ctx context.Context, userID string) ([]entity.Order, error)
 	GetPendingOrders(ctx context.Context) ([]entity.Order, error)
+	ListOrders(ctx context.Context, filter entity.OrderFilter, cursor string) (*entity.OrderPage, error) // Admin queue
+	BulkVerifyOrders(ctx context.Context, adminID string, orderIDs []string) []entity.BulkVerifyResult   // Admin only
+	ExpireOrders(ctx context.Context) (int, error)                                                       // Background sweeper
 }
 
 type commerceService struct {
-	repo repository.CommerceRepository
+	repo     repository.CommerceRepository
+	currency CurrencyService
+	docs     DocumentService
+	promos   PromoService
+	fcm      *notification.FCMService
 }
 
-func NewCommerceService(repo repository.CommerceRepository) CommerceService {
-	return &commerceService{repo: repo}
+func NewCommerceService(repo repository.CommerceRepository, currency CurrencyService, docs DocumentService, promos PromoService, fcm *notification.FCMService) CommerceService {
+	return &commerceService{repo: repo, currency: currency, docs: docs, promos: promos, fcm: fcm}
 }
 
 func (s *commerceService) CreateProduct(ctx context.Context, req entity.Product) error {
+	if req.Price.Currency == "" {
+		req.Price.Currency = money.Default
+	}
+	if err := req.Price.Validate(); err != nil {
+		return err
+	}
+	if req.Stock != nil && *req.Stock < 0 {
+		return errors.New("stock must not be negative")
+	}
+
 	req.ID = uuid.New()
 	req.CreatedAt = time.Now()
+
+	// Variants inherit the product price unless priced explicitly
+	for i := range req.Variants {
+		v := &req.Variants[i]
+		if v.Name == "" {
+			return errors.New("variant name is required")
+		}
+		if v.Price.IsZero() {
+			v.Price = req.Price
+		}
+		if v.Price.Currency == "" {
+			v.Price.Currency = req.Price.Currency
+		}
+		if err := v.Price.Validate(); err != nil {
+			return err
+		}
+		if !v.Price.SameCurrency(req.Price) {
+			return fmt.Errorf("%w: variant %q must use the product currency", money.ErrCurrencyMismatch, v.Name)
+		}
+		if v.Stock != nil && *v.Stock < 0 {
+			return errors.New("stock must not be negative")
+		}
+		v.ID = uuid.New()
+		v.ProductID = req.ID
+		v.IsActive = true
+		v.CreatedAt = req.CreatedAt
+	}
+
 	return s.repo.CreateProduct(ctx, &req)
 }
 
-func (s *commerceService) GetCatalog(ctx context.Context) ([]entity.Product, error) {
-	return s.repo.GetActiveProducts(ctx)
+func (s *commerceService) GetCatalog(ctx context.Context, filter entity.ProductFilter, currency string) ([]entity.Product, error) {
+	var target money.Currency
+	if currency != "" {
+		c, err := money.ParseCurrency(currency)
+		if err != nil {
+			return nil, err
+		}
+		target = c
+	}
+
+	products, err := s.repo.GetProducts(ctx, filter)
+	if err != nil || target == "" {
+		return products, err
+	}
+
+	// Display conversion only; orders are always charged in the product currency
+	for i := range products {
+		converted, err := s.currency.Convert(ctx, products[i].Price, target)
+		if err != nil {
+			return nil, err
+		}
+		products[i].DisplayPrice = &converted
+	}
+	return products, nil
 }
 
-func (s *commerceService) CreateOrder(ctx context.Context, userID, productID string) (*entity.Order, error) {
-	// 1. Fetch Product to get REAL Price (Security)
+// GetProduct returns one product; hidden (inactive / out-of-window)
+// products are only visible to admins.
+func (s *commerceService) GetProduct(ctx context.Context, productID string, includeHidden bool) (*entity.Product, error) {
 	product, err := s.repo.FindProductByID(ctx, productID)
 	if err != nil {
-		return nil, errors.New("product not found")
+		return nil, ErrProductNotFound
+	}
+	if !includeHidden && !product.IsAvailable(time.Now()) {
+		return nil, ErrProductNotFound
 	}
+	return product, nil
+}
 
-	if !product.IsActive {
-		return nil, errors.New("product is not available")
+func (s *commerceService) UpdateProduct(ctx context.Context, productID string, req entity.UpdateProductDTO) (*entity.Product, error) {
+	product, err := s.repo.FindProductByID(ctx, productID)
+	if err != nil {
+		return nil, ErrProductNotFound
 	}
 
-	// 2. Create Order
-	order := &entity.Order{
+	fields := map[string]interface{}{"updated_at": time.Now()}
+	if req.Name != nil {
+		fields["name"] = *req.Name
+	}
+	if req.Description != nil {
+		fields["description"] = *req.Description
+	}
+	if req.Price != nil {
+		price := *req.Price
+		if price.Currency == "" {
+			price.Currency = product.Price.Currency
+		}
+		if err := price.Validate(); err != nil {
+			return nil, err
+		}
+		// Variants share the product currency (one currency per order)
+		for _, v := range product.Variants {
+			if !v.Price.SameCurrency(price) {
+				return nil, fmt.Errorf("%w: variants are priced in %s", money.ErrCurrencyMismatch, v.Price.Currency)
+			}
+		}
+		fields["price_minor"] = price.Amount
+		fields["price_currency"] = price.Currency
+	}
+	if req.IsActive != nil {
+		fields["is_active"] = *req.IsActive
+	}
+	if req.UnlimitedStock {
+		fields["stock"] = nil
+	} else if req.Stock != nil {
+		fields["stock"] = *req.Stock
+	}
+	if req.ClearCategory {
+		fields["category_id"] = nil
+	} else if req.CategoryID != nil {
+		if _, err := s.repo.FindCategoryByID(ctx, *req.CategoryID); err != nil {
+			return nil, ErrCategoryNotFound
+		}
+		fields["category_id"] = *req.CategoryID
+	}
+	if req.SortOrder != nil {
+		fields["sort_order"] = *req.SortOrder
+	}
+
+	from, until := product.AvailableFrom, product.AvailableUntil
+	if req.AvailableFrom != nil {
+		if from, err = parseOptionalTime(*req.AvailableFrom, "available_from"); err != nil {
+			return nil, err
+		}
+		fields["available_from"] = from
+	}
+	if req.AvailableUntil != nil {
+		if until, err = parseOptionalTime(*req.AvailableUntil, "available_until"); err != nil {
+			return nil, err
+		}
+		fields["available_until"] = until
+	}
+	if from != nil && until != nil && !until.After(*from) {
+		return nil, errors.New("available_until must be after available_from")
+	}
+
+	if err := s.repo.UpdateProductFields(ctx, productID, fields); err != nil {
+		return nil, err
+	}
+	return s.repo.FindProductByID(ctx, productID)
+}
+
+// parseOptionalTime: "" clears the value (nil), otherwise RFC3339.
+func parseOptionalTime(raw, field string) (*time.Time, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s format (use RFC3339, e.g. 2026-12-01T00:00:00+07:00)", field)
+	}
+	return &t, nil
+}
+
+// DeleteProduct soft-deletes the product: it disappears from the catalog
+// and carts, while past orders keep their line snapshots.
+func (s *commerceService) DeleteProduct(ctx context.Context, productID string) error {
+	if err := s.repo.DeleteProduct(ctx, productID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrProductNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *commerceService) UpdateVariant(ctx context.Context, productID, variantID string, req entity.UpdateVariantDTO) (*entity.ProductVariant, error) {
+	variant, err := s.repo.FindVariantByID(ctx, variantID)
+	if err != nil || variant.ProductID.String() != productID {
+		return nil, ErrProductNotFound
+	}
+
+	fields := map[string]interface{}{}
+	if req.Name != nil {
+		fields["name"] = *req.Name
+	}
+	if req.SKU != nil {
+		fields["sku"] = *req.SKU
+	}
+	if req.Price != nil {
+		price := *req.Price
+		if price.Currency == "" {
+			price.Currency = variant.Price.Currency
+		}
+		if err := price.Validate(); err != nil {
+			return nil, err
+		}
+		if !price.SameCurrency(variant.Price) {
+			return nil, fmt.Errorf("%w: variant must use the product currency", money.ErrCurrencyMismatch)
+		}
+		fields["price_minor"] = price.Amount
+	}
+	if req.UnlimitedStock {
+		fields["stock"] = nil
+	} else if req.Stock != nil {
+		fields["stock"] = *req.Stock
+	}
+	if req.IsActive != nil {
+		fields["is_active"] = *req.IsActive
+	}
+
+	if len(fields) > 0 {
+		if err := s.repo.UpdateVariantFields(ctx, variantID, fields); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.FindVariantByID(ctx, variantID)
+}
+
+func (s *commerceService) AddProductImage(ctx context.Context, productID, url string, sortOrder int) (*entity.ProductImage, error) {
+	product, err := s.repo.FindProductByID(ctx, productID)
+	if err != nil {
+		return nil, ErrProductNotFound
+	}
+
+	image := &entity.ProductImage{
 		ID:        uuid.New(),
-		UserID:    uuid.MustParse(userID),
 		ProductID: product.ID,
-		Amount:    product.Price, // Snapshot price
-		Status:    entity.OrderPending,
+		URL:       url,
+		SortOrder: sortOrder,
 		CreatedAt: time.Now(),
 	}
+	if err := s.repo.CreateProductImage(ctx, image); err != nil {
+		return nil, err
+	}
+	return image, nil
+}
 
-	if err := s.repo.CreateOrder(ctx, order); err != nil {
+func (s *commerceService) DeleteProductImage(ctx context.Context, productID, imageID string) (*entity.ProductImage, error) {
+	image, err := s.repo.DeleteProductImage(ctx, productID, imageID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductNotFound
+		}
 		return nil, err
 	}
+	return image, nil
+}
+
+// -----------------------------------------------------------
+// Product Categories
+// -----------------------------------------------------------
+
+func (s *commerceService) GetCategories(ctx context.Context) ([]entity.ProductCategory, error) {
+	return s.repo.GetCategories(ctx)
+}
+
+func (s *commerceService) CreateCategory(ctx context.Context, req entity.ProductCategoryDTO) (*entity.ProductCategory, error) {
+	slug := slugify(req.Slug)
+	if slug == "" {
+		slug = slugify(req.Name)
+	}
+	if slug == "" {
+		return nil, errors.New("category slug must contain letters or digits")
+	}
 
+	now := time.Now()
+	category := &entity.ProductCategory{
+		ID:        uuid.New(),
+		Name:      req.Name,
+		Slug:      slug,
+		SortOrder: req.SortOrder,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.repo.CreateCategory(ctx, category); err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+func (s *commerceService) UpdateCategory(ctx context.Context, id string, req entity.ProductCategoryDTO) (*entity.ProductCategory, error) {
+	category, err := s.repo.FindCategoryByID(ctx, id)
+	if err != nil {
+		return nil, ErrCategoryNotFound
+	}
+
+	category.Name = req.Name
+	category.SortOrder = req.SortOrder
+	if slug := slugify(req.Slug); slug != "" {
+		category.Slug = slug
+	}
+	category.UpdatedAt = time.Now()
+
+	if err := s.repo.UpdateCategory(ctx, category); err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+func (s *commerceService) DeleteCategory(ctx context.Context, id string) error {
+	if _, err := s.repo.FindCategoryByID(ctx, id); err != nil {
+		return ErrCategoryNotFound
+	}
+	return s.repo.WithTx(ctx, func(repo repository.CommerceRepository) error {
+		return repo.DeleteCategory(ctx, id)
+	})
+}
+
+// slugify: "SIM & Data" -> "sim-data"
+func slugify(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(s)) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// CreateOrder prices every line from the product table (never from the
+// client) and stores the total as the order Amount.
+func (s *commerceService) CreateOrder(ctx context.Context, userID string, items []entity.OrderItemDTO, promoCode string) (*entity.Order, error) {
+	var order *entity.Order
+	err := redeemPromo(ctx, s.promos, userID, promoCode, entity.PromoForOrders, entity.DocRefOrder, func(promo *entity.PromoCode) (uuid.UUID, money.Money, error) {
+		err := s.repo.WithTx(ctx, func(repo repository.CommerceRepository) error {
+			var err error
+			order, err = s.placeOrder(ctx, repo, userID, items, promo)
+			return err
+		})
+		if err != nil {
+			return uuid.Nil, money.Money{}, err
+		}
+		return order.ID, order.Discount, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	issueDocument(ctx, s.docs.IssueOrderInvoice, order.ID.String())
 	return order, nil
 }
 
-func (s *commerceService) UploadPaymentProof(ctx context.Context, orderID, imageURL string, userID string) error {
-	// 1. Find Order
-	order, err := s.repo.FindOrderByID(ctx, orderID)
+// Checkout turns the cart into one order and empties the cart.
+func (s *commerceService) Checkout(ctx context.Context, userID, promoCode string) (*entity.Order, error) {
+	var order *entity.Order
+	err := redeemPromo(ctx, s.promos, userID, promoCode, entity.PromoForOrders, entity.DocRefOrder, func(promo *entity.PromoCode) (uuid.UUID, money.Money, error) {
+		err := s.repo.WithTx(ctx, func(repo repository.CommerceRepository) error {
+			cart, err := repo.GetCartItems(ctx, userID)
+			if err != nil {
+				return err
+			}
+			if len(cart) == 0 {
+				return ErrEmptyCart
+			}
+
+			items := make([]entity.OrderItemDTO, 0, len(cart))
+			for _, c := range cart {
+				item := entity.OrderItemDTO{ProductID: c.ProductID.String(), Quantity: c.Quantity}
+				if c.VariantID != nil {
+					item.VariantID = c.VariantID.String()
+				}
+				items = append(items, item)
+			}
+
+			order, err = s.placeOrder(ctx, repo, userID, items, promo)
+			if err != nil {
+				return err
+			}
+			return repo.ClearCart(ctx, userID)
+		})
+		if err != nil {
+			return uuid.Nil, money.Money{}, err
+		}
+		return order.ID, order.Discount, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	issueDocument(ctx, s.docs.IssueOrderInvoice, order.ID.String())
+	return order, nil
+}
+
+// orderLine: one merged (product, variant) line of an order request
+type orderLine struct {
+	productID string
+	variantID string
+	quantity  int
+}
+
+// placeOrder prices the lines server-side; promo (may be nil) is taken off
+// the lines it covers and snapshotted on the order.
+func (s *commerceService) placeOrder(ctx context.Context, repo repository.CommerceRepository, userID string, items []entity.OrderItemDTO, promo *entity.PromoCode) (*entity.Order, error) {
+	if len(items) == 0 {
+		return nil, errors.New("order must contain at least one item")
+	}
+
+	// 1. Merge duplicate lines (same product & variant twice -> one line)
+	var lines []*orderLine
+	merged := make(map[string]*orderLine, len(items))
+	var productIDs []string
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			return nil, errors.New("quantity must be at least 1")
+		}
+		key := item.ProductID + "/" + item.VariantID
+		if line, ok := merged[key]; ok {
+			line.quantity += item.Quantity
+			continue
+		}
+		line := &orderLine{productID: item.ProductID, variantID: item.VariantID, quantity: item.Quantity}
+		merged[key] = line
+		lines = append(lines, line)
+		productIDs = append(productIDs, item.ProductID)
+	}
+
+	// 2. Fetch Products to get REAL Price (Security)
+	products, err := repo.FindProductsByIDs(ctx, productIDs)
 	if err != nil {
-		return errors.New("order not found")
+		return nil, err
+	}
+	byID := make(map[string]entity.Product, len(products))
+	for _, p := range products {
+		byID[p.ID.String()] = p
 	}
 
-	// 2. Verify Ownership
-	if order.UserID.String() != userID {
-		return errors.New("unauthorized")
+	// 3. Build lines with price snapshots and reserve stock
+	now := time.Now()
+	expiresAt := now.Add(orderHoldDuration)
+	order := &entity.Order{
+		ID:        uuid.New(),
+		UserID:    uuid.MustParse(userID),
+		Status:    entity.OrderPending,
+		ExpiresAt: &expiresAt,
+		CreatedAt: now,
 	}
 
-	// 3. Update
-	order.ProofImage = imageURL
-	order.Status = entity.OrderPaid // Auto-move to PAID waiting verification
-	order.UpdatedAt = time.Now()
+	var promoLines []entity.PromoLine
+	for i, line := range lines {
+		product, ok := byID[line.productID]
+		if !ok {
+			return nil, fmt.Errorf("product %s not found", line.productID)
+		}
+		if !product.IsAvailable(now) {
+			return nil, fmt.Errorf("product %q is not available", product.Name)
+		}
+		variant, err := pickVariant(&product, line.variantID)
+		if err != nil {
+			return nil, err
+		}
 
-	return s.repo.UpdateOrder(ctx, order)
+		item := entity.OrderItem{
+			ID:          uuid.New(),
+			OrderID:     order.ID,
+			ProductID:   product.ID,
+			ProductName: product.Name,
+			Quantity:    line.quantity,
+			UnitPrice:   product.Price, // Snapshot price
+			CreatedAt:   now,
+		}
+
+		// Atomic reservation; fails the whole order (tx rollback) if short
+		if variant != nil {
+			err = repo.DecreaseVariantStock(ctx, variant.ID.String(), line.quantity)
+			item.VariantID = &variant.ID
+			item.VariantName = variant.Name
+			item.UnitPrice = variant.Price
+		} else {
+			err = repo.DecreaseProductStock(ctx, product.ID.String(), line.quantity)
+		}
+		if errors.Is(err, repository.ErrInsufficientStock) {
+			return nil, fmt.Errorf("%w: %s", ErrOutOfStock, lineName(item))
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		item.Subtotal = item.UnitPrice.Mul(int64(line.quantity))
+		if i == 0 {
+			order.Amount = money.Zero(item.UnitPrice.Currency)
+		}
+		// One proof / one charge per order, so all lines must share a currency
+		order.Amount, err = order.Amount.Add(item.Subtotal)
+		if err != nil {
+			return nil, fmt.Errorf("%w: products in one order must use the same currency", money.ErrCurrencyMismatch)
+		}
+
+		order.Items = append(order.Items, item)
+		promoLines = append(promoLines, entity.PromoLine{ProductID: &product.ID, ProductCategoryID: product.CategoryID, Amount: item.Subtotal})
+	}
+
+	order.Discount = money.Zero(order.Amount.Currency)
+	if promo != nil {
+		discount, err := computeDiscount(promo, promoLines)
+		if err != nil {
+			return nil, err
+		}
+		if order.Amount, err = order.Amount.Sub(discount); err != nil {
+			return nil, err
+		}
+		order.PromoCode = promo.Code
+		order.Discount = discount
+	}
+
+	if err := repo.CreateOrder(ctx, order); err != nil {
+		return nil, err
+	}
+	if err := recordOrderEvent(ctx, repo, order.ID, "", entity.OrderPending, actorFromID(entity.ActorUser, userID), "order created", now); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// transitionOrder is the only place an existing order changes status:
+// it enforces the transition table, saves the order and writes the audit row.
+// Set any other fields on order before calling it.
+func transitionOrder(ctx context.Context, repo repository.CommerceRepository, order *entity.Order, next entity.OrderStatus, actor orderActor, note string) error {
+	if !order.Status.CanTransitionTo(next) {
+		return &OrderTransitionError{From: order.Status, To: next}
+	}
+
+	now := time.Now()
+	from := order.Status
+	order.Status = next
+	order.UpdatedAt = now
+	if err := repo.UpdateOrder(ctx, order); err != nil {
+		return err
+	}
+	return recordOrderEvent(ctx, repo, order.ID, from, next, actor, note, now)
+}
+
+func recordOrderEvent(ctx context.Context, repo repository.CommerceRepository, orderID uuid.UUID, from, to entity.OrderStatus, actor orderActor, note string, at time.Time) error {
+	return repo.CreateEvent(ctx, &entity.OrderEvent{
+		ID:         uuid.New(),
+		OrderID:    orderID,
+		FromStatus: from,
+		ToStatus:   to,
+		ActorType:  actor.Type,
+		ActorID:    actor.ID,
+		Note:       note,
+		CreatedAt:  at,
+	})
+}
+
+// pickVariant resolves the variant of an order/cart line. Products with
+// variants must name one; products without must not.
+func pickVariant(product *entity.Product, variantID string) (*entity.ProductVariant, error) {
+	if variantID == "" {
+		if len(product.Variants) > 0 {
+			return nil, fmt.Errorf("please choose a variant of %q", product.Name)
+		}
+		return nil, nil
+	}
+	for i := range product.Variants {
+		if product.Variants[i].ID.String() == variantID {
+			return &product.Variants[i], nil
+		}
+	}
+	return nil, fmt.Errorf("variant %s of %q is not available", variantID, product.Name)
 }
 
-func (s *commerceService) VerifyOrder(ctx context.Context, orderID string) error {
+func lineName(item entity.OrderItem) string {
+	if item.VariantName != "" {
+		return item.ProductName + " (" + item.VariantName + ")"
+	}
+	return item.ProductName
+}
+
+// releaseStock gives the reserved units of an order back. Call it inside
+// WithTx together with the status change (expiry, cancellation).
+func releaseStock(ctx context.Context, repo repository.CommerceRepository, order *entity.Order) error {
+	for _, item := range order.Items {
+		var err error
+		if item.VariantID != nil {
+			err = repo.IncreaseVariantStock(ctx, item.VariantID.String(), item.Quantity)
+		} else {
+			err = repo.IncreaseProductStock(ctx, item.ProductID.String(), item.Quantity)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExpireOrders is called periodically by the expiry worker.
+// Unpaid or rejected orders past ExpiresAt become EXPIRED and their stock is released.
+func (s *commerceService) ExpireOrders(ctx context.Context) (int, error) {
+	now := time.Now()
+	ids, err := s.repo.FindExpiredOrderIDs(ctx, now, expireBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	var lastErr error
+	for _, id := range ids {
+		var released *entity.Order
+		err := s.repo.WithTx(ctx, func(repo repository.CommerceRepository) error {
+			order, err := repo.FindOrderByID(ctx, id)
+			if err != nil {
+				return err
+			}
+
+			// Re-check under the row lock: a proof may have been uploaded meanwhile.
+			if !isOrderExpired(order, now) || order.Status == entity.OrderExpired {
+				return nil
+			}
+
+			if err := releaseStock(ctx, repo, order); err != nil {
+				return err
+			}
+			if err := transitionOrder(ctx, repo, order, entity.OrderExpired, systemActor, "not paid in time, stock released"); err != nil {
+				return err
+			}
+			released = order
+			return nil
+		})
+		if err != nil {
+			lastErr = fmt.Errorf("failed to expire order %s: %v", id, err)
+			continue
+		}
+		if released == nil {
+			continue
+		}
+		expired++
+		if released.PromoCode != "" {
+			releasePromo(ctx, s.promos, entity.DocRefOrder, released.ID)
+		}
+	}
+
+	return expired, lastErr
+}
+
+// isOrderExpired: EXPIRED, or still unpaid past its deadline (sweeper not run yet)
+func isOrderExpired(order *entity.Order, now time.Time) bool {
+	switch order.Status {
+	case entity.OrderExpired:
+		return true
+	case entity.OrderPending, entity.OrderRejected:
+		return order.ExpiresAt != nil && !order.ExpiresAt.After(now)
+	default:
+		return false
+	}
+}
+
+func (s *commerceService) UploadPaymentProof(ctx context.Context, orderID, imageURL string, userID string) error {
+	// Locked so the expiry sweeper cannot release the stock mid-upload
+	return s.repo.WithTx(ctx, func(repo repository.CommerceRepository) error {
+		// 1. Find Order
+		order, err := repo.FindOrderByID(ctx, orderID)
+		if err != nil {
+			return ErrOrderNotFound
+		}
+
+		// 2. Verify Ownership
+		if order.UserID.String() != userID {
+			return errors.New("unauthorized")
+		}
+		if isOrderExpired(order, time.Now()) {
+			return ErrOrderExpired
+		}
+
+		// 3. Only unpaid, waiting or rejected orders accept a (new) proof
+		if !order.Status.CanTransitionTo(entity.OrderPaid) {
+			return &OrderTransitionError{From: order.Status, To: entity.OrderPaid}
+		}
+
+		// 4. A proof still waiting for review is replaced, not lost
+		now := time.Now()
+		open, err := repo.FindOpenProof(ctx, orderID)
+		if err != nil {
+			return err
+		}
+		if open != nil {
+			open.Status = entity.ProofSuperseded
+			if err := repo.UpdateProof(ctx, open); err != nil {
+				return err
+			}
+		}
+		if err := repo.CreateProof(ctx, &entity.OrderProof{
+			ID:        uuid.New(),
+			OrderID:   order.ID,
+			ImageURL:  imageURL,
+			Status:    entity.ProofSubmitted,
+			CreatedAt: now,
+		}); err != nil {
+			return err
+		}
+
+		// 5. Update: auto-move to PAID waiting verification
+		note := "proof uploaded"
+		if order.Status == entity.OrderPaid {
+			note = "proof replaced before review"
+		}
+		order.ProofImage = imageURL
+		order.RejectionReason = ""
+
+		return transitionOrder(ctx, repo, order, entity.OrderPaid, actorFromID(entity.ActorUser, userID), note)
+	})
+}
+
+func (s *commerceService) VerifyOrder(ctx context.Context, adminID, orderID string) error {
 	// Admin Action
+	adminUUID, err := uuid.Parse(adminID)
+	if err != nil {
+		return errors.New("invalid admin id")
+	}
+
+	err = s.repo.WithTx(ctx, func(repo repository.CommerceRepository) error {
+		order, err := repo.FindOrderByID(ctx, orderID)
+		if err != nil {
+			return ErrOrderNotFound
+		}
+		// Only a submitted proof can be verified; gateway payments settle themselves
+		if order.Status != entity.OrderPaid {
+			return &OrderTransitionError{From: order.Status, To: entity.OrderCompleted}
+		}
+
+		if err := reviewOpenProof(ctx, repo, orderID, entity.ProofAccepted, "", &adminUUID, time.Now()); err != nil {
+			return err
+		}
+
+		return transitionOrder(ctx, repo, order, entity.OrderCompleted, orderActor{Type: entity.ActorAdmin, ID: &adminUUID}, "payment proof verified")
+	})
+	if err != nil {
+		return err
+	}
+
+	issueDocument(ctx, s.docs.IssueOrderReceipt, orderID)
+	return nil
+}
+
+// RejectOrder sends a PAID order back to the buyer with a reason. The stock
+// stays reserved for another hold period so they can upload a new proof.
+func (s *commerceService) RejectOrder(ctx context.Context, adminID, orderID, reason string) error {
+	adminUUID, err := uuid.Parse(adminID)
+	if err != nil {
+		return errors.New("invalid admin id")
+	}
+
+	var order *entity.Order
+	err = s.repo.WithTx(ctx, func(repo repository.CommerceRepository) error {
+		order, err = repo.FindOrderByID(ctx, orderID)
+		if err != nil {
+			return ErrOrderNotFound
+		}
+		if !order.Status.CanTransitionTo(entity.OrderRejected) {
+			return &OrderTransitionError{From: order.Status, To: entity.OrderRejected}
+		}
+
+		now := time.Now()
+		if err := reviewOpenProof(ctx, repo, orderID, entity.ProofRejected, reason, &adminUUID, now); err != nil {
+			return err
+		}
+
+		expiresAt := now.Add(orderHoldDuration)
+		order.RejectionReason = reason
+		order.ExpiresAt = &expiresAt
+		return transitionOrder(ctx, repo, order, entity.OrderRejected, orderActor{Type: entity.ActorAdmin, ID: &adminUUID}, reason)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.notifyBuyer(order, "ORDER_REJECTED", "Payment proof rejected",
+		fmt.Sprintf("%s. Please upload a new transfer proof.", reason))
+	return nil
+}
+
+// notifyBuyer sends a push to the order owner (best-effort, call it after
+// the transaction committed). order.User must be preloaded.
+func (s *commerceService) notifyBuyer(order *entity.Order, kind, title, body string) {
+	if order == nil || order.User == nil || order.User.FCMToken == "" {
+		return
+	}
+	go s.fcm.SendPush([]string{order.User.FCMToken}, title, body,
+		map[string]string{"type": kind, "order_id": order.ID.String()},
+	)
+}
+
+// reviewOpenProof closes the proof waiting for review (if any) with a verdict.
+func reviewOpenProof(ctx context.Context, repo repository.CommerceRepository, orderID string, status entity.ProofStatus, reason string, reviewer *uuid.UUID, now time.Time) error {
+	proof, err := repo.FindOpenProof(ctx, orderID)
+	if err != nil || proof == nil {
+		return err
+	}
+	proof.Status = status
+	proof.Reason = reason
+	proof.ReviewedBy = reviewer
+	proof.ReviewedAt = &now
+	return repo.UpdateProof(ctx, proof)
+}
+
+func (s *commerceService) GetOrderProofs(ctx context.Context, orderID, userID, role string) ([]entity.OrderProof, error) {
 	order, err := s.repo.FindOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, ErrOrderNotFound
+	}
+	if order.UserID.String() != userID && role != entity.RoleAdmin && role != entity.RoleMutawwif {
+		return nil, errors.New("unauthorized")
+	}
+	return s.repo.GetProofs(ctx, orderID)
+}
+
+func (s *commerceService) GetOrderEvents(ctx context.Context, orderID string) ([]entity.OrderEvent, error) {
+	return s.repo.GetEvents(ctx, orderID)
+}
+
+// SettleOrderPayment completes an order paid through a payment gateway.
+// The gateway already confirmed the money, so no proof/verification is needed.
+// Runs in a savepoint of the caller's transaction: a failed settlement
+// leaves the order untouched without aborting the caller.
+func (s *commerceService) SettleOrderPayment(ctx context.Context, repo repository.CommerceRepository, orderID string) (func(), error) {
+	err := repo.WithTx(ctx, func(repo repository.CommerceRepository) error {
+		order, err := repo.FindOrderByID(ctx, orderID)
+		if err != nil {
+			return ErrOrderNotFound
+		}
+
+		// Redelivered callbacks never get here (the transaction is already
+		// PAID), so a completed order was paid by another charge or proof.
+		if order.Status == entity.OrderCompleted {
+			return ErrAlreadyPaid
+		}
+		// Paid after the deadline: stock may already be resold
+		if isOrderExpired(order, time.Now()) {
+			return ErrOrderExpired
+		}
+
+		// A proof under review is no longer needed once the gateway confirms
+		if err := reviewOpenProof(ctx, repo, orderID, entity.ProofSuperseded, "", nil, time.Now()); err != nil {
+			return err
+		}
+		return transitionOrder(ctx, repo, order, entity.OrderCompleted, gatewayActor, "paid via payment gateway")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return func() { issueDocument(ctx, s.docs.IssueOrderReceipt, orderID) }, nil
+}
+
+// RefundOrderPayment closes an order whose gateway payment was refunded,
+// by an admin or by the provider itself (empty adminID). Orders the
+// payment never reached (closed before it landed) stay as they are. A
+// buyer's open refund request goes through its own flow instead.
+func (s *commerceService) RefundOrderPayment(ctx context.Context, repo repository.CommerceRepository, adminID, orderID, note string) (func(), error) {
+	var order *entity.Order
+	err := repo.WithTx(ctx, func(repo repository.CommerceRepository) error {
+		var err error
+		order, err = repo.FindOrderByID(ctx, orderID)
+		if err != nil {
+			return ErrOrderNotFound
+		}
+
+		switch order.Status {
+		case entity.OrderExpired, entity.OrderCancelled:
+			order = nil
+			return nil
+		case entity.OrderRefundRequested:
+			return errors.New("order has an open refund request, complete it from the refund requests")
+		}
+		actor := gatewayActor
+		if adminID != "" {
+			actor = actorFromID(entity.ActorAdmin, adminID)
+		}
+		return transitionOrder(ctx, repo, order, entity.OrderRefunded, actor, note)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return func() {
+		if order != nil {
+			s.notifyBuyer(order, "ORDER_REFUNDED", "Refund sent", fmt.Sprintf("%s has been refunded to you.", order.Amount))
+		}
+	}, nil
+}
+
+// -----------------------------------------------------------
+// Cancellation & Refunds
+// -----------------------------------------------------------
+
+// CancelOrder lets the buyer drop an order nobody has paid for yet
+// (PENDING or REJECTED); the reserved stock goes back on sale.
+func (s *commerceService) CancelOrder(ctx context.Context, userID, orderID, reason string) error {
+	var order *entity.Order
+	err := s.repo.WithTx(ctx, func(repo repository.CommerceRepository) error {
+		var err error
+		order, err = repo.FindOrderByID(ctx, orderID)
+		if err != nil {
+			return ErrOrderNotFound
+		}
+		if order.UserID.String() != userID {
+			return errors.New("unauthorized")
+		}
+		if !order.Status.CanTransitionTo(entity.OrderCancelled) {
+			return &OrderTransitionError{From: order.Status, To: entity.OrderCancelled}
+		}
+
+		if err := releaseStock(ctx, repo, order); err != nil {
+			return err
+		}
+
+		note := "cancelled by buyer"
+		if reason != "" {
+			note += ": " + reason
+		}
+		return transitionOrder(ctx, repo, order, entity.OrderCancelled, actorFromID(entity.ActorUser, userID), note)
+	})
 	if err != nil {
 		return err
 	}
 
-	order.Status = entity.OrderCompleted
-	order.UpdatedAt = time.Now()
+	// Never paid, so the promo code use is given back
+	if order.PromoCode != "" {
+		releasePromo(ctx, s.promos, entity.DocRefOrder, order.ID)
+	}
+	return nil
+}
+
+// RequestRefund opens a refund request for a completed order (proof
+// verified or paid through the gateway). The order waits in
+// REFUND_REQUESTED until an admin approves (and transfers) or declines.
+func (s *commerceService) RequestRefund(ctx context.Context, userID, orderID, reason string) (*entity.RefundRequest, error) {
+	var refund *entity.RefundRequest
+	err := s.repo.WithTx(ctx, func(repo repository.CommerceRepository) error {
+		order, err := repo.FindOrderByID(ctx, orderID)
+		if err != nil {
+			return ErrOrderNotFound
+		}
+		if order.UserID.String() != userID {
+			return errors.New("unauthorized")
+		}
+		if order.Status == entity.OrderPaid {
+			return errors.New("payment proof is still under review, the order cannot be refunded yet")
+		}
+		if !order.Status.CanTransitionTo(entity.OrderRefundRequested) {
+			return &OrderTransitionError{From: order.Status, To: entity.OrderRefundRequested}
+		}
+
+		now := time.Now()
+		refund = &entity.RefundRequest{
+			ID:             uuid.New(),
+			OrderID:        order.ID,
+			UserID:         order.UserID,
+			Reason:         reason,
+			Amount:         order.Amount,
+			Status:         entity.RefundRequested,
+			PreviousStatus: order.Status,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		}
+		if err := repo.CreateRefundRequest(ctx, refund); err != nil {
+			return err
+		}
+
+		return transitionOrder(ctx, repo, order, entity.OrderRefundRequested, actorFromID(entity.ActorUser, userID), "refund requested: "+reason)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+func (s *commerceService) GetRefundRequests(ctx context.Context, status string) ([]entity.RefundRequest, error) {
+	return s.repo.GetRefundRequests(ctx, status)
+}
+
+// ApproveRefund accepts the request; the order stays REFUND_REQUESTED until
+// the transfer proof is uploaded with CompleteRefund.
+func (s *commerceService) ApproveRefund(ctx context.Context, adminID, requestID, note string) (*entity.RefundRequest, error) {
+	var order *entity.Order
+	refund, err := s.reviewRefund(ctx, adminID, requestID, func(repo repository.CommerceRepository, refund *entity.RefundRequest, o *entity.Order, admin orderActor) error {
+		if refund.Status != entity.RefundRequested {
+			return fmt.Errorf("refund request is %s and cannot be approved", refund.Status)
+		}
+		// The order does not move: the request itself records who approved it and when
+		refund.Status = entity.RefundApproved
+		refund.AdminNote = note
+		order = o
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifyBuyer(order, "REFUND_APPROVED", "Refund approved", "Your refund has been approved and will be transferred soon.")
+	return refund, nil
+}
+
+// DeclineRefund rejects the request and puts the order back to COMPLETED,
+// the only status a refund can be requested from. That is a restore, not a
+// state machine move: REFUND_REQUESTED only leads to REFUNDED for everyone else.
+func (s *commerceService) DeclineRefund(ctx context.Context, adminID, requestID, note string) (*entity.RefundRequest, error) {
+	var order *entity.Order
+	refund, err := s.reviewRefund(ctx, adminID, requestID, func(repo repository.CommerceRepository, refund *entity.RefundRequest, o *entity.Order, admin orderActor) error {
+		if refund.Status != entity.RefundRequested && refund.Status != entity.RefundApproved {
+			return fmt.Errorf("refund request is %s and cannot be declined", refund.Status)
+		}
+		if o.Status != entity.OrderRefundRequested {
+			return &OrderTransitionError{From: o.Status, To: entity.OrderCompleted}
+		}
+		refund.Status = entity.RefundDeclined
+		refund.AdminNote = note
+		order = o
+
+		now := time.Now()
+		o.Status = entity.OrderCompleted
+		o.UpdatedAt = now
+		if err := repo.UpdateOrder(ctx, o); err != nil {
+			return err
+		}
+		return recordOrderEvent(ctx, repo, o.ID, entity.OrderRefundRequested, o.Status, admin, "refund declined: "+note, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	body := "Your refund request was declined."
+	if note != "" {
+		body = fmt.Sprintf("Your refund request was declined: %s", note)
+	}
+	s.notifyBuyer(order, "REFUND_DECLINED", "Refund declined", body)
+	return refund, nil
+}
+
+// CompleteRefund records the admin's transfer receipt and closes the order
+// as REFUNDED.
+func (s *commerceService) CompleteRefund(ctx context.Context, adminID, requestID, proofURL string) (*entity.RefundRequest, error) {
+	var order *entity.Order
+	refund, err := s.reviewRefund(ctx, adminID, requestID, func(repo repository.CommerceRepository, refund *entity.RefundRequest, o *entity.Order, admin orderActor) error {
+		if refund.Status != entity.RefundRequested && refund.Status != entity.RefundApproved {
+			return fmt.Errorf("refund request is %s and cannot be completed", refund.Status)
+		}
+		now := time.Now()
+		refund.Status = entity.RefundCompleted
+		refund.RefundProof = proofURL
+		refund.RefundedAt = &now
+		order = o
+		return transitionOrder(ctx, repo, o, entity.OrderRefunded, admin, "refund transferred")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifyBuyer(order, "ORDER_REFUNDED", "Refund sent", fmt.Sprintf("%s has been transferred back to you.", refund.Amount))
+	return refund, nil
+}
+
+// reviewRefund loads and locks the request and its order, lets decide()
+// change them, then stamps the reviewer and saves the request.
+func (s *commerceService) reviewRefund(ctx context.Context, adminID, requestID string, decide func(repo repository.CommerceRepository, refund *entity.RefundRequest, order *entity.Order, admin orderActor) error) (*entity.RefundRequest, error) {
+	adminUUID, err := uuid.Parse(adminID)
+	if err != nil {
+		return nil, errors.New("invalid admin id")
+	}
+	admin := orderActor{Type: entity.ActorAdmin, ID: &adminUUID}
+
+	var refund *entity.RefundRequest
+	err = s.repo.WithTx(ctx, func(repo repository.CommerceRepository) error {
+		var err error
+		refund, err = repo.FindRefundRequestByID(ctx, requestID)
+		if err != nil {
+			return ErrRefundNotFound
+		}
+		order, err := repo.FindOrderByID(ctx, refund.OrderID.String())
+		if err != nil {
+			return ErrOrderNotFound
+		}
+
+		if err := decide(repo, refund, order, admin); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		refund.ReviewedBy = &adminUUID
+		refund.ReviewedAt = &now
+		refund.UpdatedAt = now
+		return repo.UpdateRefundRequest(ctx, refund)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+// -----------------------------------------------------------
+// Cart
+// -----------------------------------------------------------
+
+func (s *commerceService) GetCart(ctx context.Context, userID string) (*entity.Cart, error) {
+	items, err := s.repo.GetCartItems(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	cart := &entity.Cart{Items: items}
+	for _, item := range items {
+		price, ok := cartItemPrice(item)
+		if !ok {
+			continue
+		}
+		subtotal := price.Mul(int64(item.Quantity))
+		if cart.Total == nil {
+			total := money.Zero(subtotal.Currency)
+			cart.Total = &total
+		}
+		total, err := cart.Total.Add(subtotal)
+		if err != nil {
+			return nil, err
+		}
+		cart.Total = &total
+	}
+	return cart, nil
+}
+
+// QuoteCart previews what a promo code takes off the current cart.
+// Nothing is reserved: the code is checked again at checkout.
+func (s *commerceService) QuoteCart(ctx context.Context, userID, promoCode string) (*entity.PromoQuote, error) {
+	cart, err := s.GetCart(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if cart.Total == nil {
+		return nil, ErrEmptyCart
+	}
+
+	promo, err := s.promos.Check(ctx, userID, promoCode, entity.PromoForOrders)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]entity.PromoLine, 0, len(cart.Items))
+	for _, item := range cart.Items {
+		price, ok := cartItemPrice(item)
+		if !ok {
+			continue
+		}
+		line := entity.PromoLine{ProductID: &item.ProductID, Amount: price.Mul(int64(item.Quantity))}
+		if item.Product != nil {
+			line.ProductCategoryID = item.Product.CategoryID
+		}
+		lines = append(lines, line)
+	}
+
+	discount, err := computeDiscount(promo, lines)
+	if err != nil {
+		return nil, err
+	}
+	total, err := cart.Total.Sub(discount)
+	if err != nil {
+		return nil, err
+	}
+	return &entity.PromoQuote{Code: promo.Code, Subtotal: *cart.Total, Discount: discount, Total: total}, nil
+}
 
-	return s.repo.UpdateOrder(ctx, order)
+// cartItemPrice: current unit price of a cart line (variant price wins)
+func cartItemPrice(item entity.CartItem) (money.Money, bool) {
+	if item.Variant != nil {
+		return item.Variant.Price, true
+	}
+	if item.Product != nil {
+		return item.Product.Price, true
+	}
+	return money.Money{}, false
+}
+
+func (s *commerceService) AddToCart(ctx context.Context, userID string, req entity.AddCartItemDTO) (*entity.Cart, error) {
+	product, err := s.repo.FindProductByID(ctx, req.ProductID)
+	if err != nil {
+		return nil, errors.New("product not found")
+	}
+	if !product.IsAvailable(time.Now()) {
+		return nil, errors.New("product is not available")
+	}
+	variant, err := pickVariant(product, req.VariantID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reject early instead of failing at checkout
+	items, err := s.repo.GetCartItems(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if price, ok := cartItemPrice(item); ok && !price.SameCurrency(product.Price) {
+			return nil, fmt.Errorf("%w: cart already contains %s products", money.ErrCurrencyMismatch, price.Currency)
+		}
+	}
+
+	existing, err := s.repo.FindCartItem(ctx, userID, req.ProductID, req.VariantID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if existing == nil {
+		existing = &entity.CartItem{
+			ID:        uuid.New(),
+			UserID:    uuid.MustParse(userID),
+			ProductID: product.ID,
+			CreatedAt: now,
+		}
+		if variant != nil {
+			existing.VariantID = &variant.ID
+		}
+	}
+	existing.Quantity += req.Quantity
+	if existing.Quantity > maxCartQuantity {
+		return nil, fmt.Errorf("quantity per product is limited to %d", maxCartQuantity)
+	}
+	existing.UpdatedAt = now
+
+	// Stock is only reserved at checkout; the cart is a wish list
+	if err := s.repo.SaveCartItem(ctx, existing); err != nil {
+		return nil, err
+	}
+	return s.GetCart(ctx, userID)
+}
+
+func (s *commerceService) UpdateCartItem(ctx context.Context, userID, productID, variantID string, quantity int) (*entity.Cart, error) {
+	if quantity <= 0 {
+		return s.RemoveFromCart(ctx, userID, productID, variantID)
+	}
+	if quantity > maxCartQuantity {
+		return nil, fmt.Errorf("quantity per product is limited to %d", maxCartQuantity)
+	}
+
+	item, err := s.repo.FindCartItem(ctx, userID, productID, variantID)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, ErrCartItemNotFound
+	}
+
+	item.Quantity = quantity
+	item.UpdatedAt = time.Now()
+	if err := s.repo.SaveCartItem(ctx, item); err != nil {
+		return nil, err
+	}
+	return s.GetCart(ctx, userID)
+}
+
+func (s *commerceService) RemoveFromCart(ctx context.Context, userID, productID, variantID string) (*entity.Cart, error) {
+	if err := s.repo.DeleteCartItem(ctx, userID, productID, variantID); err != nil {
+		return nil, err
+	}
+	return s.GetCart(ctx, userID)
 }
 
 func (s *commerceService) GetMyOrders(ctx context.Context, userID string) ([]entity.Order, error) {
@@ -109,3 +1407,71 @@ func (s *commerceService) GetMyOrders(ctx context.Context, userID string) ([]ent
 func (s *commerceService) GetPendingOrders(ctx context.Context) ([]entity.Order, error) {
 	return s.repo.GetPendingOrders(ctx)
 }
+
+// ListOrders returns one page of the admin queue. cursor is the opaque
+// NextCursor of the previous page ("" for the first page).
+func (s *commerceService) ListOrders(ctx context.Context, filter entity.OrderFilter, cursor string) (*entity.OrderPage, error) {
+	if cursor != "" {
+		after, id, err := decodeOrderCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		filter.AfterTime, filter.AfterID = &after, id
+	}
+
+	// Fetch one extra row to know whether another page exists
+	limit := filter.Limit
+	filter.Limit = limit + 1
+	orders, err := s.repo.ListOrders(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &entity.OrderPage{Items: orders}
+	if len(orders) > limit {
+		page.Items = orders[:limit]
+		last := page.Items[limit-1]
+		page.NextCursor = encodeOrderCursor(last.CreatedAt, last.ID.String())
+	}
+	return page, nil
+}
+
+// BulkVerifyOrders verifies each order in its own transaction, so one bad
+// order does not block the rest of the batch.
+func (s *commerceService) BulkVerifyOrders(ctx context.Context, adminID string, orderIDs []string) []entity.BulkVerifyResult {
+	results := make([]entity.BulkVerifyResult, 0, len(orderIDs))
+	for _, id := range orderIDs {
+		result := entity.BulkVerifyResult{OrderID: id, Verified: true}
+		if err := s.VerifyOrder(ctx, adminID, id); err != nil {
+			result.Verified = false
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// Cursor format: base64("<created_at RFC3339Nano>|<order id>")
+func encodeOrderCursor(createdAt time.Time, id string) string {
+	raw := createdAt.UTC().Format(time.RFC3339Nano) + "|" + id
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeOrderCursor(cursor string) (time.Time, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	parts := strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	if _, err := uuid.Parse(parts[1]); err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	return createdAt, parts[1], nil
+}
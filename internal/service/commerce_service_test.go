@@ -0,0 +1,57 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestOrderCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name      string
+		createdAt time.Time
+		id        string
+	}{
+		{"utc", time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC), "5f0c7c1e-0f5d-4c39-9a57-2a4d3b8e6a11"},
+		{"nanoseconds", time.Date(2024, 3, 1, 8, 30, 0, 123456789, time.UTC), "0b8f3d3e-7a2c-4d0e-b1b4-9a6f2c1d5e77"},
+		{"local time zone", time.Date(2024, 3, 1, 15, 30, 0, 0, time.FixedZone("WIB", 7*3600)), "9c1e6f52-3d4b-4a8e-8f0a-1b2c3d4e5f60"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			createdAt, id, err := decodeOrderCursor(encodeOrderCursor(tt.createdAt, tt.id))
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if !createdAt.Equal(tt.createdAt) {
+				t.Errorf("created_at = %v, want %v", createdAt, tt.createdAt)
+			}
+			if id != tt.id {
+				t.Errorf("id = %q, want %q", id, tt.id)
+			}
+		})
+	}
+}
+
+func TestDecodeOrderCursorRejectsGarbage(t *testing.T) {
+	encode := func(raw string) string { return base64.RawURLEncoding.EncodeToString([]byte(raw)) }
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"not base64", "!!not-a-cursor!!"},
+		{"empty", ""},
+		{"truncated", encodeOrderCursor(time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC), "5f0c7c1e-0f5d-4c39-9a57-2a4d3b8e6a11")[:40]},
+		{"no separator", encode("2024-03-01T08:30:00Z")},
+		{"bad time", encode("yesterday|5f0c7c1e-0f5d-4c39-9a57-2a4d3b8e6a11")},
+		{"bad id", encode("2024-03-01T08:30:00Z|1 OR 1=1")},
+		{"empty id", encode("2024-03-01T08:30:00Z|")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := decodeOrderCursor(tt.cursor); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("decodeOrderCursor(%q) error = %v, want %v", tt.cursor, err, ErrInvalidCursor)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+	"umrah-backend/internal/entity"
+	"umrah-backend/internal/repository"
+	"umrah-backend/pkg/money"
+
+	"github.com/google/uuid"
+)
+
+var ErrRateNotFound = errors.New("exchange rate not available")
+
+type CurrencyService interface {
+	SetRate(ctx context.Context, adminID string, req entity.SetExchangeRateDTO) (*entity.ExchangeRate, error) // Admin only
+	GetRates(ctx context.Context) ([]entity.ExchangeRate, error)
+
+	// Convert uses the direct rate, its inverse, or a cross rate via IDR.
+	Convert(ctx context.Context, m money.Money, to money.Currency) (money.Money, error)
+}
+
+type currencyService struct {
+	repo repository.CurrencyRepository
+}
+
+func NewCurrencyService(repo repository.CurrencyRepository) CurrencyService {
+	return &currencyService{repo: repo}
+}
+
+func (s *currencyService) SetRate(ctx context.Context, adminID string, req entity.SetExchangeRateDTO) (*entity.ExchangeRate, error) {
+	base, err := money.ParseCurrency(req.Base)
+	if err != nil {
+		return nil, err
+	}
+	quote, err := money.ParseCurrency(req.Quote)
+	if err != nil {
+		return nil, err
+	}
+	if base == quote {
+		return nil, fmt.Errorf("%w: base and quote currency must differ", money.ErrInvalidCurrency)
+	}
+
+	rate, err := money.ParseRate(req.Rate)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	record := &entity.ExchangeRate{
+		ID:        uuid.New(),
+		Base:      base,
+		Quote:     quote,
+		Rate:      rate.FloatString(10),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if adminUUID, err := uuid.Parse(adminID); err == nil {
+		record.UpdatedBy = &adminUUID
+	}
+
+	if err := s.repo.UpsertRate(ctx, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+func (s *currencyService) GetRates(ctx context.Context) ([]entity.ExchangeRate, error) {
+	return s.repo.GetRates(ctx)
+}
+
+func (s *currencyService) Convert(ctx context.Context, m money.Money, to money.Currency) (money.Money, error) {
+	if m.Currency == to {
+		return m, nil
+	}
+
+	rate, err := s.rate(ctx, m.Currency, to)
+	if err == nil {
+		return m.Convert(to, rate), nil
+	}
+	if !errors.Is(err, ErrRateNotFound) {
+		return money.Money{}, err
+	}
+
+	// Cross rate through the home currency, e.g. SAR -> IDR -> USD
+	if m.Currency == money.Default || to == money.Default {
+		return money.Money{}, fmt.Errorf("%w: %s -> %s", ErrRateNotFound, m.Currency, to)
+	}
+	toHome, err := s.rate(ctx, m.Currency, money.Default)
+	if err != nil {
+		return money.Money{}, err
+	}
+	fromHome, err := s.rate(ctx, money.Default, to)
+	if err != nil {
+		return money.Money{}, err
+	}
+	return m.Convert(to, new(big.Rat).Mul(toHome, fromHome)), nil
+}
+
+// rate looks up base->quote directly, or inverts quote->base.
+func (s *currencyService) rate(ctx context.Context, base, quote money.Currency) (*big.Rat, error) {
+	direct, err := s.repo.FindRate(ctx, base, quote)
+	if err != nil {
+		return nil, err
+	}
+	if direct != nil {
+		return money.ParseRate(direct.Rate)
+	}
+
+	inverse, err := s.repo.FindRate(ctx, quote, base)
+	if err != nil {
+		return nil, err
+	}
+	if inverse != nil {
+		r, err := money.ParseRate(inverse.Rate)
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Rat).Inv(r), nil
+	}
+
+	return nil, fmt.Errorf("%w: %s -> %s", ErrRateNotFound, base, quote)
+}
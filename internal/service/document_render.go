@@ -0,0 +1,237 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"umrah-backend/internal/entity"
+	"umrah-backend/pkg/money"
+	"umrah-backend/pkg/pdf"
+)
+
+// documentView is the printable content of an invoice or receipt.
+type documentView struct {
+	Type      entity.DocumentType
+	Number    string
+	Reference string
+	IssuedAt  time.Time
+	DueAt     *time.Time
+	BillTo    []string
+	Lines     []documentLine
+	Totals    []documentTotal
+	Notes     []string
+	Stamp     string // e.g. "PAID"
+}
+
+type documentLine struct {
+	Description string
+	Quantity    int
+	UnitPrice   money.Money
+	Amount      money.Money
+}
+
+type documentTotal struct {
+	Label  string
+	Amount money.Money
+	Strong bool
+}
+
+var documentTitles = map[entity.DocumentType]string{
+	entity.DocInvoice: "INVOICE",
+	entity.DocReceipt: "OFFICIAL RECEIPT",
+}
+
+// Defaults when the agency branding is not configured
+const (
+	defaultBrandName  = "Umrah Travel"
+	defaultBrandColor = "#0F6E4F"
+)
+
+// Page layout (points)
+const (
+	docMargin     = 48.0
+	docLineHeight = 16.0
+	docPageBottom = pdf.PageHeight - 80
+)
+
+// Table columns: description | qty | unit price | amount (right edges for numbers)
+var (
+	colDescription = docMargin
+	colQty         = 320.0
+	colUnit        = 450.0
+	colAmount      = pdf.PageWidth - docMargin
+	colTotalLabel  = colAmount - 150
+)
+
+func renderDocument(brand Branding, v *documentView) []byte {
+	name := brand.Name
+	if name == "" {
+		name = defaultBrandName
+	}
+	accent, err := pdf.ParseHexColor(brand.Color)
+	if err != nil {
+		accent, _ = pdf.ParseHexColor(defaultBrandColor)
+	}
+
+	doc := pdf.New(fmt.Sprintf("%s %s", documentTitles[v.Type], v.Number))
+	page := doc.AddPage()
+	y := renderHeader(page, brand, name, accent, v)
+
+	// Line items, continued on new pages when long
+	drawTableHead := func(p *pdf.Page, y float64) float64 {
+		p.SetColor(accent)
+		p.Rect(docMargin, y, pdf.PageWidth-2*docMargin, 20)
+		p.SetColor(pdf.White)
+		p.SetFont(pdf.Bold, 9)
+		p.Text(colDescription+6, y+13.5, "DESCRIPTION")
+		p.TextRight(colQty, y+13.5, "QTY")
+		p.TextRight(colUnit, y+13.5, "UNIT PRICE")
+		p.TextRight(colAmount-6, y+13.5, "AMOUNT")
+		return y + 20 + docLineHeight
+	}
+	y = drawTableHead(page, y)
+
+	for _, line := range v.Lines {
+		page.SetFont(pdf.Regular, 10)
+		desc := page.Wrap(line.Description, colQty-colDescription-40)
+		if y+float64(len(desc))*docLineHeight > docPageBottom {
+			page = doc.AddPage()
+			y = drawTableHead(page, docMargin)
+		}
+
+		page.SetColor(pdf.Black)
+		page.SetFont(pdf.Regular, 10)
+		for i, text := range desc {
+			page.Text(colDescription+6, y+float64(i)*docLineHeight, text)
+		}
+		page.TextRight(colQty, y, fmt.Sprintf("%d", line.Quantity))
+		page.TextRight(colUnit, y, line.UnitPrice.String())
+		page.TextRight(colAmount-6, y, line.Amount.String())
+
+		y += float64(len(desc))*docLineHeight + 4
+		page.SetColor(pdf.Color{R: 220, G: 220, B: 220})
+		page.Line(docMargin, y-docLineHeight+8, colAmount, y-docLineHeight+8, 0.5)
+		y += 4
+	}
+
+	// Totals block, kept together
+	if y+float64(len(v.Totals)+1)*docLineHeight > docPageBottom {
+		page = doc.AddPage()
+		y = docMargin
+	}
+	y += 6
+	for _, total := range v.Totals {
+		page.SetColor(pdf.Black)
+		if total.Strong {
+			page.SetFont(pdf.Bold, 11)
+		} else {
+			page.SetFont(pdf.Regular, 10)
+		}
+		page.TextRight(colTotalLabel, y, total.Label)
+		page.TextRight(colAmount-6, y, total.Amount.String())
+		y += docLineHeight + 2
+	}
+
+	if v.Stamp != "" {
+		page.SetColor(accent)
+		page.SetFont(pdf.Bold, 28)
+		page.Text(docMargin, y-8, v.Stamp)
+	}
+
+	// Notes
+	y += docLineHeight
+	page.SetColor(pdf.Gray)
+	page.SetFont(pdf.Regular, 9)
+	for _, note := range v.Notes {
+		for _, text := range page.Wrap(note, pdf.PageWidth-2*docMargin) {
+			if y > docPageBottom {
+				page = doc.AddPage()
+				page.SetColor(pdf.Gray)
+				page.SetFont(pdf.Regular, 9)
+				y = docMargin
+			}
+			page.Text(docMargin, y, text)
+			y += 12
+		}
+	}
+
+	footer := "This document is generated electronically and is valid without a signature."
+	page.SetColor(pdf.Gray)
+	page.SetFont(pdf.Regular, 8)
+	page.Text(docMargin, pdf.PageHeight-40, footer)
+	page.TextRight(colAmount, pdf.PageHeight-40, v.Number)
+
+	return doc.Bytes()
+}
+
+// renderHeader draws the agency band, document title and parties, and
+// returns where the line items start.
+func renderHeader(page *pdf.Page, brand Branding, name string, accent pdf.Color, v *documentView) float64 {
+	page.SetColor(accent)
+	page.Rect(0, 0, pdf.PageWidth, 90)
+
+	page.SetColor(pdf.White)
+	page.SetFont(pdf.Bold, 20)
+	page.Text(docMargin, 42, name)
+
+	page.SetFont(pdf.Regular, 9)
+	var contact []string
+	for _, part := range []string{brand.Phone, brand.Email, brand.Website} {
+		if part != "" {
+			contact = append(contact, part)
+		}
+	}
+	if brand.Address != "" {
+		page.Text(docMargin, 60, brand.Address)
+	}
+	if len(contact) > 0 {
+		page.Text(docMargin, 73, strings.Join(contact, "  |  "))
+	}
+	if brand.License != "" {
+		page.TextRight(pdf.PageWidth-docMargin, 73, brand.License)
+	}
+
+	// Title and document facts
+	y := 130.0
+	page.SetColor(accent)
+	page.SetFont(pdf.Bold, 18)
+	page.Text(docMargin, y, documentTitles[v.Type])
+
+	facts := [][2]string{
+		{"Number", v.Number},
+		{"Date", v.IssuedAt.Format("02 Jan 2006")},
+		{"Reference", v.Reference},
+	}
+	if v.DueAt != nil {
+		facts = append(facts, [2]string{"Due", v.DueAt.In(v.IssuedAt.Location()).Format("02 Jan 2006 15:04 MST")})
+	}
+	factY := y
+	for _, f := range facts {
+		page.SetColor(pdf.Gray)
+		page.SetFont(pdf.Regular, 9)
+		page.Text(320, factY, f[0])
+		page.SetColor(pdf.Black)
+		page.SetFont(pdf.Bold, 9)
+		page.Text(380, factY, f[1])
+		factY += 14
+	}
+
+	// Bill to
+	billY := y + 28
+	page.SetColor(pdf.Gray)
+	page.SetFont(pdf.Regular, 9)
+	page.Text(docMargin, billY, "BILLED TO")
+	page.SetColor(pdf.Black)
+	page.SetFont(pdf.Regular, 10)
+	for i, line := range v.BillTo {
+		if i == 0 {
+			page.SetFont(pdf.Bold, 11)
+		} else {
+			page.SetFont(pdf.Regular, 10)
+		}
+		billY += 15
+		page.Text(docMargin, billY, line)
+	}
+
+	return max(billY, factY) + 24
+}
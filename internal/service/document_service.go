@@ -0,0 +1,475 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"umrah-backend/internal/entity"
+	"umrah-backend/internal/repository"
+	"umrah-backend/pkg/money"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrDocumentNotFound = errors.New("document not found")
+
+// Branding is printed on every invoice and receipt.
+type Branding struct {
+	Name     string
+	Address  string
+	Phone    string
+	Email    string
+	Website  string
+	License  string // Izin PPIU number
+	Color    string // Header color, "#RRGGBB"
+	Location *time.Location
+}
+
+type DocumentService interface {
+	// Issue* are idempotent: an existing document is returned as is.
+	IssueOrderInvoice(ctx context.Context, orderID string) (*entity.Document, error)
+	IssueOrderReceipt(ctx context.Context, orderID string) (*entity.Document, error)
+	IssueBookingInvoice(ctx context.Context, bookingID string) (*entity.Document, error)
+	IssueInstallmentReceipt(ctx context.Context, installmentID string) (*entity.Document, error)
+
+	// Owner or staff. Documents that should exist but are missing (e.g.
+	// issuing failed right after payment) are issued on the fly.
+	GetOrderDocuments(ctx context.Context, orderID, userID, role string) ([]entity.Document, error)
+	GetBookingDocuments(ctx context.Context, bookingID, userID, role string) ([]entity.Document, error)
+
+	// OpenDocument returns the document and the path of its PDF,
+	// re-rendering the file if it went missing from storage.
+	OpenDocument(ctx context.Context, documentID, userID, role string) (*entity.Document, string, error)
+}
+
+type documentService struct {
+	repo         repository.DocumentRepository
+	commerceRepo repository.CommerceRepository
+	pkgRepo      repository.PackageRepository
+	userRepo     repository.UserRepository
+	brand        Branding
+	storageDir   string
+}
+
+func NewDocumentService(
+	repo repository.DocumentRepository,
+	commerceRepo repository.CommerceRepository,
+	pkgRepo repository.PackageRepository,
+	userRepo repository.UserRepository,
+	brand Branding,
+	storageDir string,
+) DocumentService {
+	if brand.Location == nil {
+		brand.Location = time.Local
+	}
+	return &documentService{
+		repo:         repo,
+		commerceRepo: commerceRepo,
+		pkgRepo:      pkgRepo,
+		userRepo:     userRepo,
+		brand:        brand,
+		storageDir:   storageDir,
+	}
+}
+
+// Number prefixes: INV-2026-000042, RCP-2026-000007
+var documentPrefixes = map[entity.DocumentType]string{
+	entity.DocInvoice: "INV",
+	entity.DocReceipt: "RCP",
+}
+
+// viewBuilder loads the source of a document, fills in its user and
+// amount and returns the printable content.
+type viewBuilder func(ctx context.Context, doc *entity.Document) (*documentView, error)
+
+// builder picks the view builder of a document kind.
+func (s *documentService) builder(docType entity.DocumentType, refType entity.DocumentReference) (viewBuilder, error) {
+	switch {
+	case docType == entity.DocInvoice && refType == entity.DocRefOrder:
+		return s.orderInvoiceView, nil
+	case docType == entity.DocReceipt && refType == entity.DocRefOrder:
+		return s.orderReceiptView, nil
+	case docType == entity.DocInvoice && refType == entity.DocRefBooking:
+		return s.bookingInvoiceView, nil
+	case docType == entity.DocReceipt && refType == entity.DocRefInstallment:
+		return s.installmentReceiptView, nil
+	default:
+		return nil, fmt.Errorf("no %s for %s references", docType, refType)
+	}
+}
+
+// issue allocates the next number, renders the PDF and stores the
+// document in one transaction. The source is loaded before a number is
+// taken, so invalid requests never consume one.
+func (s *documentService) issue(ctx context.Context, docType entity.DocumentType, refType entity.DocumentReference, refID string) (*entity.Document, error) {
+	if existing, err := s.repo.FindDocument(ctx, docType, refType, refID); err != nil || existing != nil {
+		return existing, err
+	}
+
+	build, err := s.builder(docType, refType)
+	if err != nil {
+		return nil, err
+	}
+	refUUID, err := uuid.Parse(refID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s id", strings.ToLower(string(refType)))
+	}
+
+	now := time.Now()
+	doc := &entity.Document{
+		ID:            uuid.New(),
+		Type:          docType,
+		ReferenceType: refType,
+		ReferenceID:   refUUID,
+		IssuedAt:      now,
+		CreatedAt:     now,
+	}
+	view, err := build(ctx, doc)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.WithTx(ctx, func(tx repository.DocumentRepository) error {
+		prefix := fmt.Sprintf("%s-%d", documentPrefixes[docType], now.In(s.brand.Location).Year())
+		seq, err := tx.NextNumber(ctx, prefix)
+		if err != nil {
+			return err
+		}
+		doc.Number = fmt.Sprintf("%s-%06d", prefix, seq)
+		doc.FilePath = filepath.Join(s.storageDir, doc.Number+".pdf")
+
+		if err := s.writeFile(doc, view); err != nil {
+			return err
+		}
+		return tx.CreateDocument(ctx, doc)
+	})
+	if err != nil {
+		// Lost a race against a concurrent issuer: theirs is the document
+		if existing, findErr := s.repo.FindDocument(ctx, docType, refType, refID); findErr == nil && existing != nil {
+			return existing, nil
+		}
+		return nil, err
+	}
+	return doc, nil
+}
+
+func (s *documentService) writeFile(doc *entity.Document, view *documentView) error {
+	view.Number = doc.Number
+	view.IssuedAt = doc.IssuedAt.In(s.brand.Location)
+	if err := os.MkdirAll(s.storageDir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(doc.FilePath, renderDocument(s.brand, view), 0644)
+}
+
+func (s *documentService) IssueOrderInvoice(ctx context.Context, orderID string) (*entity.Document, error) {
+	return s.issue(ctx, entity.DocInvoice, entity.DocRefOrder, orderID)
+}
+
+func (s *documentService) IssueOrderReceipt(ctx context.Context, orderID string) (*entity.Document, error) {
+	return s.issue(ctx, entity.DocReceipt, entity.DocRefOrder, orderID)
+}
+
+func (s *documentService) IssueBookingInvoice(ctx context.Context, bookingID string) (*entity.Document, error) {
+	return s.issue(ctx, entity.DocInvoice, entity.DocRefBooking, bookingID)
+}
+
+func (s *documentService) IssueInstallmentReceipt(ctx context.Context, installmentID string) (*entity.Document, error) {
+	return s.issue(ctx, entity.DocReceipt, entity.DocRefInstallment, installmentID)
+}
+
+// -----------------------------------------------------------
+// Orders
+// -----------------------------------------------------------
+
+func (s *documentService) orderInvoiceView(ctx context.Context, doc *entity.Document) (*documentView, error) {
+	order, err := s.commerceRepo.FindOrderByID(ctx, doc.ReferenceID.String())
+	if err != nil {
+		return nil, ErrOrderNotFound
+	}
+	doc.UserID, doc.Amount = order.UserID, order.Amount
+
+	view := s.orderView(order, entity.DocInvoice)
+	if order.ExpiresAt != nil {
+		view.DueAt = order.ExpiresAt
+		view.Notes = append(view.Notes, "Please pay before the due date, unpaid orders are cancelled automatically.")
+	}
+	return view, nil
+}
+
+// orderReceiptView: receipts exist only for orders whose payment was confirmed.
+func (s *documentService) orderReceiptView(ctx context.Context, doc *entity.Document) (*documentView, error) {
+	order, err := s.commerceRepo.FindOrderByID(ctx, doc.ReferenceID.String())
+	if err != nil {
+		return nil, ErrOrderNotFound
+	}
+	if order.Status != entity.OrderCompleted && order.Status != entity.OrderRefundRequested && order.Status != entity.OrderRefunded {
+		return nil, fmt.Errorf("order is %s, receipts are issued for completed orders", order.Status)
+	}
+	doc.UserID, doc.Amount = order.UserID, order.Amount
+
+	view := s.orderView(order, entity.DocReceipt)
+	view.Totals = append(view.Totals, documentTotal{Label: "Amount received", Amount: order.Amount})
+	view.Stamp = "PAID"
+	return view, nil
+}
+
+func (s *documentService) orderView(order *entity.Order, docType entity.DocumentType) *documentView {
+	view := &documentView{
+		Type:      docType,
+		Reference: "Order #" + shortID(order.ID),
+	}
+	if order.User != nil {
+		view.BillTo = []string{order.User.FullName, order.User.PhoneNumber}
+	}
+
+	for _, item := range order.Items {
+		name := item.ProductName
+		if item.VariantName != "" {
+			name += " - " + item.VariantName
+		}
+		view.Lines = append(view.Lines, documentLine{
+			Description: name,
+			Quantity:    item.Quantity,
+			UnitPrice:   item.UnitPrice,
+			Amount:      item.Subtotal,
+		})
+	}
+	view.Totals = discountedTotals(order.Amount, order.Discount, order.PromoCode)
+	return view
+}
+
+// discountedTotals: a plain Total, or Subtotal / Discount / Total when a
+// promo code was applied (total is already net of the discount).
+func discountedTotals(total, discount money.Money, code string) []documentTotal {
+	if !discount.IsPositive() {
+		return []documentTotal{{Label: "Total", Amount: total, Strong: true}}
+	}
+	subtotal, err := total.Add(discount)
+	if err != nil {
+		return []documentTotal{{Label: "Total", Amount: total, Strong: true}}
+	}
+	return []documentTotal{
+		{Label: "Subtotal", Amount: subtotal},
+		{Label: "Discount (" + code + ")", Amount: money.New(-discount.Amount, discount.Currency)},
+		{Label: "Total", Amount: total, Strong: true},
+	}
+}
+
+// -----------------------------------------------------------
+// Bookings
+// -----------------------------------------------------------
+
+func (s *documentService) bookingInvoiceView(ctx context.Context, doc *entity.Document) (*documentView, error) {
+	booking, pkg, err := s.loadBooking(ctx, doc.ReferenceID.String())
+	if err != nil {
+		return nil, err
+	}
+	doc.UserID, doc.Amount = booking.UserID, booking.TotalPrice
+
+	return &documentView{
+		Type:      entity.DocInvoice,
+		Reference: "Booking #" + shortID(booking.ID),
+		BillTo:    s.bookingBillTo(ctx, booking),
+		DueAt:     booking.ExpiresAt,
+		Lines:     []documentLine{bookingLine(booking, pkg)},
+		Totals:    discountedTotals(booking.TotalPrice, booking.Discount, booking.PromoCode),
+		Notes:     []string{"Seats are held until the due date. Pay the down payment or the full amount to keep them."},
+	}, nil
+}
+
+func (s *documentService) installmentReceiptView(ctx context.Context, doc *entity.Document) (*documentView, error) {
+	installment, err := s.pkgRepo.FindInstallmentByID(ctx, doc.ReferenceID.String())
+	if err != nil {
+		return nil, ErrInstallmentNotFound
+	}
+	if installment.Status != entity.InstallmentPaid {
+		return nil, fmt.Errorf("installment is %s, receipts are issued for PAID installments", installment.Status)
+	}
+	booking, pkg, err := s.loadBooking(ctx, installment.BookingID.String())
+	if err != nil {
+		return nil, err
+	}
+	installments, err := s.pkgRepo.GetInstallments(ctx, booking.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	balance := computeBalance(booking, installments)
+
+	doc.UserID, doc.Amount = booking.UserID, installment.Amount
+	doc.BookingID = &booking.ID
+
+	label := "Full payment"
+	switch installment.Kind {
+	case entity.KindDownPayment:
+		label = "Down payment"
+	case entity.KindInstallment:
+		label = fmt.Sprintf("Installment %d", installment.Sequence-1)
+	}
+
+	view := &documentView{
+		Type:      entity.DocReceipt,
+		Reference: "Booking #" + shortID(booking.ID),
+		BillTo:    s.bookingBillTo(ctx, booking),
+		Stamp:     "PAID",
+		Lines: []documentLine{
+			bookingLine(booking, pkg),
+			{Description: label + " for the booking above", Quantity: 1, UnitPrice: installment.Amount, Amount: installment.Amount},
+		},
+		Totals: []documentTotal{
+			{Label: "Amount received", Amount: installment.Amount, Strong: true},
+			{Label: "Total paid to date", Amount: balance.Paid},
+			{Label: "Outstanding balance", Amount: balance.Outstanding},
+		},
+	}
+	if installment.PaidAt != nil {
+		view.Notes = append(view.Notes, "Payment received on "+installment.PaidAt.In(s.brand.Location).Format("02 Jan 2006 15:04 MST")+".")
+	}
+	return view, nil
+}
+
+func (s *documentService) loadBooking(ctx context.Context, bookingID string) (*entity.Booking, *entity.TravelPackage, error) {
+	booking, err := s.pkgRepo.FindBookingByID(ctx, bookingID)
+	if err != nil {
+		return nil, nil, ErrBookingNotFound
+	}
+	pkg, err := s.pkgRepo.FindPackageByID(ctx, booking.PackageID.String())
+	if err != nil {
+		return nil, nil, errors.New("package not found")
+	}
+	return booking, pkg, nil
+}
+
+// bookingBillTo: the account holder, plus the lead pilgrim once the
+// manifest is filled in.
+func (s *documentService) bookingBillTo(ctx context.Context, booking *entity.Booking) []string {
+	var lines []string
+	if users, err := s.userRepo.FindByIDs(ctx, []string{booking.UserID.String()}); err == nil && len(users) > 0 {
+		lines = append(lines, users[0].FullName)
+	}
+	if passengers, err := s.pkgRepo.GetPassengers(ctx, booking.ID.String()); err == nil && len(passengers) > 0 {
+		lines = append(lines, "Lead pilgrim: "+passengers[0].FullName)
+	}
+	return lines
+}
+
+// bookingLine is priced before the discount, like an order line.
+func bookingLine(booking *entity.Booking, pkg *entity.TravelPackage) documentLine {
+	pax := int64(max(booking.PaxCount, 1))
+	gross := booking.TotalPrice
+	if booking.Discount.SameCurrency(gross) {
+		gross.Amount += booking.Discount.Amount
+	}
+	return documentLine{
+		Description: fmt.Sprintf("%s (%s room), departure %s", pkg.Name, strings.ToLower(booking.RoomType), pkg.DepartureDate.Format("02 Jan 2006")),
+		Quantity:    booking.PaxCount,
+		UnitPrice:   money.New(gross.Amount/pax, gross.Currency),
+		Amount:      gross,
+	}
+}
+
+// -----------------------------------------------------------
+// Access
+// -----------------------------------------------------------
+
+func (s *documentService) GetOrderDocuments(ctx context.Context, orderID, userID, role string) ([]entity.Document, error) {
+	order, err := s.commerceRepo.FindOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, ErrOrderNotFound
+	}
+	if order.UserID.String() != userID && role != entity.RoleAdmin && role != entity.RoleMutawwif {
+		return nil, errors.New("unauthorized")
+	}
+
+	if _, err := s.IssueOrderInvoice(ctx, orderID); err != nil {
+		return nil, err
+	}
+	if order.Status == entity.OrderCompleted {
+		if _, err := s.IssueOrderReceipt(ctx, orderID); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.GetOrderDocuments(ctx, orderID)
+}
+
+func (s *documentService) GetBookingDocuments(ctx context.Context, bookingID, userID, role string) ([]entity.Document, error) {
+	booking, err := s.pkgRepo.FindBookingByID(ctx, bookingID)
+	if err != nil {
+		return nil, ErrBookingNotFound
+	}
+	if !canAccessBooking(booking, userID, role) {
+		return nil, errors.New("unauthorized")
+	}
+
+	if _, err := s.IssueBookingInvoice(ctx, bookingID); err != nil {
+		return nil, err
+	}
+	installments, err := s.pkgRepo.GetInstallments(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	for _, inst := range installments {
+		if inst.Status != entity.InstallmentPaid {
+			continue
+		}
+		if _, err := s.IssueInstallmentReceipt(ctx, inst.ID.String()); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.GetBookingDocuments(ctx, bookingID)
+}
+
+func (s *documentService) OpenDocument(ctx context.Context, documentID, userID, role string) (*entity.Document, string, error) {
+	doc, err := s.repo.FindDocumentByID(ctx, documentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", ErrDocumentNotFound
+		}
+		return nil, "", err
+	}
+	if doc.UserID.String() != userID && role != entity.RoleAdmin && role != entity.RoleMutawwif {
+		return nil, "", ErrDocumentNotFound
+	}
+
+	if _, err := os.Stat(doc.FilePath); errors.Is(err, os.ErrNotExist) {
+		if err := s.rerender(ctx, doc); err != nil {
+			return nil, "", fmt.Errorf("failed to restore %s: %v", doc.Number, err)
+		}
+	}
+	return doc, doc.FilePath, nil
+}
+
+// rerender rebuilds a lost PDF from the current data, keeping the
+// original number and issue date.
+func (s *documentService) rerender(ctx context.Context, doc *entity.Document) error {
+	build, err := s.builder(doc.Type, doc.ReferenceType)
+	if err != nil {
+		return err
+	}
+	scratch := *doc
+	view, err := build(ctx, &scratch)
+	if err != nil {
+		return err
+	}
+	return s.writeFile(doc, view)
+}
+
+// shortID: the first block of a UUID, as customers see it in the app
+func shortID(id uuid.UUID) string {
+	return strings.ToUpper(id.String()[:8])
+}
+
+// issueDocument is called by other services after their transaction
+// committed. It is best-effort: a document that failed here is issued
+// again when the owner lists the documents.
+func issueDocument(ctx context.Context, issue func(context.Context, string) (*entity.Document, error), refID string) {
+	if _, err := issue(ctx, refID); err != nil {
+		log.Printf("Failed to issue document for %s: %v", refID, err)
+	}
+}
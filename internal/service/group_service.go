@@ -2,8 +2,10 @@ package service
 
 import (
 	"context" // [FIX] Wajib import context
+	"crypto/rand"
 	"errors"
 	"fmt"
+	"math/big"
 	"time"
 	"umrah-backend/internal/entity"
 	"umrah-backend/internal/repository"
@@ -16,14 +18,24 @@ type GroupService interface {
 	CreateGroup(ctx context.Context, userID string, userRole string, req entity.CreateGroupDTO) (*entity.Group, error)
 	JoinGroup(ctx context.Context, userID string, req entity.JoinGroupDTO) (*entity.Group, error)
 	GetGroupMembers(ctx context.Context, groupID string) ([]entity.GroupMember, error)
+
+	// Package-linked groups: pilgrims are enrolled from confirmed bookings,
+	// no join code needed.
+	CreateGroupFromPackage(ctx context.Context, userID, userRole, packageID string, req entity.CreatePackageGroupDTO) (*entity.Group, error)
+	SyncPackageMembers(ctx context.Context, packageID string) (int, error)
+	EnrollPackageMember(ctx context.Context, packageID, userID string) error
+	RemovePackageMember(ctx context.Context, packageID, userID string) error   // Unless another booking is still confirmed
+	GeneratePackageRundown(ctx context.Context, packageID string) (int, error) // Re-applies the package's template rundown
 }
 
 type groupService struct {
-	repo repository.GroupRepository
+	repo        repository.GroupRepository
+	pkgRepo     repository.PackageRepository
+	itineraries ItineraryService
 }
 
-func NewGroupService(repo repository.GroupRepository) GroupService {
-	return &groupService{repo: repo}
+func NewGroupService(repo repository.GroupRepository, pkgRepo repository.PackageRepository, itineraries ItineraryService) GroupService {
+	return &groupService{repo: repo, pkgRepo: pkgRepo, itineraries: itineraries}
 }
 
 func (s *groupService) CreateGroup(ctx context.Context, userID string, userRole string, req entity.CreateGroupDTO) (*entity.Group, error) {
@@ -114,3 +126,208 @@ func (s *groupService) GetGroupMembers(ctx context.Context, groupID string) ([]e
 	// [FIX] Pass ctx
 	return s.repo.GetMembers(ctx, groupID)
 }
+
+func (s *groupService) CreateGroupFromPackage(ctx context.Context, userID, userRole, packageID string, req entity.CreatePackageGroupDTO) (*entity.Group, error) {
+	if userRole != entity.RoleMutawwif && userRole != entity.RoleAdmin {
+		return nil, errors.New("unauthorized: only mutawwif can create groups")
+	}
+
+	pkg, err := s.pkgRepo.FindPackageByID(ctx, packageID)
+	if err != nil {
+		return nil, errors.New("package not found")
+	}
+
+	if existing, err := s.repo.FindByPackageID(ctx, packageID); err == nil {
+		return nil, fmt.Errorf("package already has group %s", existing.ID)
+	}
+
+	leaderID := userID
+	if req.MutawwifID != "" {
+		leaderID = req.MutawwifID
+	}
+	mutawwifUUID, err := uuid.Parse(leaderID)
+	if err != nil {
+		return nil, errors.New("invalid mutawwif ID")
+	}
+
+	name := req.Name
+	if name == "" {
+		name = pkg.Name
+	}
+
+	joinCode, err := s.generateJoinCode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	group := &entity.Group{
+		ID:         uuid.New(),
+		Name:       name,
+		MutawwifID: mutawwifUUID,
+		JoinCode:   joinCode,
+		StartDate:  pkg.DepartureDate,
+		EndDate:    pkg.ReturnDate,
+		PackageID:  &pkg.ID,
+	}
+
+	if err := s.repo.Create(ctx, group); err != nil {
+		return nil, fmt.Errorf("database error: %v", err)
+	}
+
+	leader := &entity.GroupMember{
+		ID:      uuid.New(),
+		GroupID: group.ID,
+		UserID:  mutawwifUUID,
+		Status:  "ACTIVE",
+	}
+	if err := s.repo.Join(ctx, leader); err != nil {
+		return nil, fmt.Errorf("group created but failed to add mutawwif as member: %v", err)
+	}
+
+	if _, err := s.SyncPackageMembers(ctx, packageID); err != nil {
+		return nil, fmt.Errorf("group created but failed to enroll pilgrims: %v", err)
+	}
+
+	if _, err := s.itineraries.GenerateRundown(ctx, group); err != nil {
+		return nil, fmt.Errorf("group created but failed to generate rundown: %v", err)
+	}
+
+	return group, nil
+}
+
+func (s *groupService) GeneratePackageRundown(ctx context.Context, packageID string) (int, error) {
+	group, err := s.repo.FindByPackageID(ctx, packageID)
+	if err != nil {
+		return 0, errors.New("package has no group yet")
+	}
+	return s.itineraries.GenerateRundown(ctx, group)
+}
+
+// SyncPackageMembers enrolls every user with a CONFIRMED booking on the
+// package who is not yet a member. Returns how many were added.
+func (s *groupService) SyncPackageMembers(ctx context.Context, packageID string) (int, error) {
+	if _, err := s.repo.FindByPackageID(ctx, packageID); err != nil {
+		return 0, errors.New("package has no group yet")
+	}
+
+	bookings, _, err := s.pkgRepo.ListBookings(ctx, entity.BookingFilter{PackageID: packageID})
+	if err != nil {
+		return 0, err
+	}
+
+	confirmed := make(map[uuid.UUID]bool)
+	released := make(map[uuid.UUID]bool)
+	for _, b := range bookings {
+		switch {
+		case b.Status == entity.BookingConfirmed:
+			confirmed[b.UserID] = true
+		case !b.Status.HoldsSeats():
+			released[b.UserID] = true
+		}
+	}
+
+	added := 0
+	for userID := range confirmed {
+		joined, err := s.enroll(ctx, packageID, userID.String())
+		if err != nil {
+			return added, err
+		}
+		if joined {
+			added++
+		}
+	}
+
+	// Pilgrims whose bookings were all cancelled, expired or refunded leave
+	// the group (catches up when the removal hook failed).
+	for userID := range released {
+		if confirmed[userID] {
+			continue
+		}
+		if err := s.RemovePackageMember(ctx, packageID, userID.String()); err != nil {
+			return added, err
+		}
+	}
+
+	return added, nil
+}
+
+func (s *groupService) RemovePackageMember(ctx context.Context, packageID, userID string) error {
+	group, err := s.repo.FindByPackageID(ctx, packageID)
+	if err != nil {
+		return nil // No group for this package, nothing to do
+	}
+
+	bookings, _, err := s.pkgRepo.ListBookings(ctx, entity.BookingFilter{
+		PackageID: packageID,
+		UserID:    userID,
+		Status:    entity.BookingConfirmed,
+	})
+	if err != nil {
+		return err
+	}
+	if len(bookings) > 0 {
+		return nil // Still travelling on another booking
+	}
+
+	if _, err := s.repo.RemoveMember(ctx, group.ID.String(), userID); err != nil {
+		return fmt.Errorf("failed to leave group: %v", err)
+	}
+	return nil
+}
+
+// EnrollPackageMember adds one pilgrim to the package group, if the group exists.
+func (s *groupService) EnrollPackageMember(ctx context.Context, packageID, userID string) error {
+	_, err := s.enroll(ctx, packageID, userID)
+	return err
+}
+
+func (s *groupService) enroll(ctx context.Context, packageID, userID string) (bool, error) {
+	group, err := s.repo.FindByPackageID(ctx, packageID)
+	if err != nil {
+		return false, nil // No group for this package (yet), nothing to do
+	}
+
+	isMember, err := s.repo.IsMember(ctx, group.ID.String(), userID)
+	if err != nil {
+		return false, fmt.Errorf("error checking membership: %v", err)
+	}
+	if isMember {
+		return false, nil
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return false, errors.New("invalid user ID")
+	}
+
+	member := &entity.GroupMember{
+		ID:      uuid.New(),
+		GroupID: group.ID,
+		UserID:  userUUID,
+		Status:  "ACTIVE",
+	}
+	if err := s.repo.Join(ctx, member); err != nil {
+		return false, fmt.Errorf("failed to join group: %v", err)
+	}
+	return true, nil
+}
+
+// generateJoinCode still gives package groups a code, so a pilgrim whose
+// booking was made by a relative can join with it manually.
+func (s *groupService) generateJoinCode(ctx context.Context) (string, error) {
+	const alphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789" // no 0/O, 1/I
+	for attempt := 0; attempt < 5; attempt++ {
+		code := make([]byte, 8)
+		for i := range code {
+			n, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+			if err != nil {
+				return "", err
+			}
+			code[i] = alphabet[n.Int64()]
+		}
+		if _, err := s.repo.FindByCode(ctx, string(code)); err != nil {
+			return string(code), nil
+		}
+	}
+	return "", errors.New("failed to generate a unique join code")
+}
@@ -3,6 +3,8 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log"
 	"time"
 	"umrah-backend/internal/entity"
 	"umrah-backend/internal/repository"
@@ -13,6 +15,7 @@ import (
 type ItineraryService interface {
 	CreateItinerary(ctx context.Context, req entity.Itinerary) error
 	GetRundown(ctx context.Context, groupID string) ([]entity.Itinerary, error)
+	GenerateRundown(ctx context.Context, group *entity.Group) (int, error) // From the package's template
 
 	// Core Logic: Attendance
 	ScanAttendance(ctx context.Context, userID, itineraryID string) error
@@ -20,11 +23,16 @@ type ItineraryService interface {
 }
 
 type itineraryService struct {
-	repo repository.ItineraryRepository
+	repo     repository.ItineraryRepository
+	pkgRepo  repository.PackageRepository
+	location *time.Location // Agency timezone, the departure date is a calendar day there
 }
 
-func NewItineraryService(repo repository.ItineraryRepository) ItineraryService {
-	return &itineraryService{repo: repo}
+func NewItineraryService(repo repository.ItineraryRepository, pkgRepo repository.PackageRepository, location *time.Location) ItineraryService {
+	if location == nil {
+		location = time.Local
+	}
+	return &itineraryService{repo: repo, pkgRepo: pkgRepo, location: location}
 }
 
 func (s *itineraryService) CreateItinerary(ctx context.Context, req entity.Itinerary) error {
@@ -36,6 +44,111 @@ func (s *itineraryService) GetRundown(ctx context.Context, groupID string) ([]en
 	return s.repo.GetItineraryByGroup(ctx, groupID)
 }
 
+// GenerateRundown copies the package's template rundown into the group,
+// day 1 being the group's start date. The first run copies every item;
+// later runs (after the template changed) only replace the generated items
+// that have not started yet, so past items and their attendance stay.
+func (s *itineraryService) GenerateRundown(ctx context.Context, group *entity.Group) (int, error) {
+	if group.PackageID == nil {
+		return 0, errors.New("group is not linked to a package")
+	}
+	days, err := s.pkgRepo.GetItineraryTemplate(ctx, group.PackageID.String())
+	if err != nil {
+		return 0, err
+	}
+	generated, err := s.repo.CountTemplateItems(ctx, group.ID.String())
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	var after time.Time // First run: everything
+	if generated > 0 {
+		after = now
+	}
+
+	year, month, day := group.StartDate.In(s.location).Date()
+	var items []entity.Itinerary
+	for _, d := range days {
+		loc := itineraryLocation(d.Timezone)
+		for _, item := range d.Items {
+			start, end, err := templateItemTimes(year, month, day+d.DayNumber-1, item, loc)
+			if err != nil {
+				return 0, err
+			}
+			if !start.After(after) {
+				continue
+			}
+			templateItemID := item.ID
+			items = append(items, entity.Itinerary{
+				ID:             uuid.New(),
+				GroupID:        group.ID,
+				Title:          item.Title,
+				Description:    item.Description,
+				Location:       item.Location,
+				Latitude:       item.Latitude,
+				Longitude:      item.Longitude,
+				StartTime:      start,
+				EndTime:        end,
+				TemplateItemID: &templateItemID,
+				CreatedAt:      now,
+			})
+		}
+	}
+
+	if err := s.repo.ReplaceTemplateItems(ctx, group.ID.String(), after, items); err != nil {
+		return 0, err
+	}
+	return len(items), nil
+}
+
+// templateItemTimes places a template item on its calendar day. An end
+// before the start runs past midnight.
+func templateItemTimes(year int, month time.Month, day int, item entity.PackageItineraryItem, loc *time.Location) (time.Time, time.Time, error) {
+	startHour, startMin, err := parseClock(item.StartTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	start := time.Date(year, month, day, startHour, startMin, 0, 0, loc)
+	if item.EndTime == "" {
+		return start, start, nil
+	}
+
+	endHour, endMin, err := parseClock(item.EndTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	end := time.Date(year, month, day, endHour, endMin, 0, 0, loc)
+	if end.Before(start) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return start, end, nil
+}
+
+// parseClock parses a rundown clock time, "HH:MM" (24h).
+func parseClock(raw string) (int, int, error) {
+	t, err := time.Parse("15:04", raw)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid time %q (use HH:MM)", raw)
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// itineraryLocation resolves the timezone of a template day. Names are
+// checked when the template is saved; the fallback only covers a host
+// without timezone data.
+func itineraryLocation(name string) *time.Location {
+	if name == "" {
+		name = entity.DefaultItineraryTimezone
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		log.Printf("⚠️ Warning: unknown timezone %q, using UTC+3", name)
+		return time.FixedZone("AST", 3*60*60)
+	}
+	return loc
+}
+
 func (s *itineraryService) ScanAttendance(ctx context.Context, userID, itineraryID string) error {
 	// 1. Check if user already scanned
 	existing, err := s.repo.GetAttendanceByUserAndItinerary(ctx, userID, itineraryID)
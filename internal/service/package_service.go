@@ -3,47 +3,945 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
 	"time"
 	"umrah-backend/internal/entity"
 	"umrah-backend/internal/repository"
+	"umrah-backend/pkg/money"
+	"umrah-backend/pkg/notification"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Used when a package is created without its own payment hold window
+const DefaultPaymentHoldHours = 24
+
+// Used when a package is created without its own waitlist claim window
+const DefaultWaitlistClaimHours = 12
+
+// Saudi visa rule: passport must stay valid this long after departure
+const passportValidityMonths = 6
+
+// Cicilan must be fully paid this many days before departure
+const finalPaymentDaysBeforeDeparture = 30
+
+// Max bookings expired per sweeper run
+const expireBatchSize = 100
+
+var (
+	ErrPackageNotFound          = errors.New("package not found")
+	ErrPackageArchived          = errors.New("package is archived")
+	ErrNotEnoughSeats           = errors.New("booking failed: not enough seats available")
+	ErrWaitlistEntryNotFound    = errors.New("waitlist entry not found")
+	ErrNoWaitlistOffer          = errors.New("no open offer on this waitlist entry")
+	ErrAlreadyOnWaitlist        = errors.New("you are already on the waitlist of this package")
+	ErrBookingNotFound          = errors.New("booking not found")
+	ErrInstallmentNotFound      = errors.New("installment not found")
+	ErrInvalidBookingTransition = errors.New("invalid booking status transition")
 )
 
 type PackageService interface {
 	CreatePackage(ctx context.Context, req entity.TravelPackage) error
-	GetList(ctx context.Context, category string) ([]entity.TravelPackage, error)
-	BookPackage(ctx context.Context, userID, packageID, roomType string, pax int) (*entity.Booking, error)
+	GetList(ctx context.Context, search entity.PackageSearch, currency string) (*entity.PackageSearchResult, error)
+
+	// Admin Package Lifecycle
+	GetPackageAdmin(ctx context.Context, packageID string) (*entity.TravelPackage, error) // Any status
+	UpdatePackage(ctx context.Context, packageID string, req entity.UpdatePackageDTO) (*entity.TravelPackage, error)
+	ClonePackage(ctx context.Context, packageID string, req entity.ClonePackageDTO) (*entity.TravelPackage, error)
+	ArchivePackage(ctx context.Context, packageID string) (*entity.TravelPackage, error)
+	PublishScheduledPackages(ctx context.Context) (int, error)
+	BookPackage(ctx context.Context, userID string, req entity.BookPackageDTO) (*entity.Booking, error)
+	QuoteBooking(ctx context.Context, userID string, req entity.BookingQuoteDTO) (*entity.PromoQuote, error)
+
+	// Package Content (itinerary template, inclusions, flights, hotels)
+	GetPackage(ctx context.Context, packageID, currency string) (*entity.TravelPackage, error)
+	SetItinerary(ctx context.Context, packageID string, req entity.SetItineraryDTO) (*entity.TravelPackage, error)
+	SetInclusions(ctx context.Context, packageID string, req entity.SetInclusionsDTO) (*entity.TravelPackage, error)
+	SetFlights(ctx context.Context, packageID string, req entity.SetFlightsDTO) (*entity.TravelPackage, error)
+	SetHotels(ctx context.Context, packageID string, req entity.SetHotelsDTO) (*entity.TravelPackage, error)
+
+	// Package Versions (terms history; bookings keep the version they were sold under)
+	GetPackageVersions(ctx context.Context, packageID string) ([]entity.PackageVersion, error)           // Admin
+	GetBookingTerms(ctx context.Context, bookingID, userID, role string) (*entity.PackageVersion, error) // Owner or staff
+
+	// Booking Lifecycle
+	TransitionBooking(ctx context.Context, bookingID string, next entity.BookingStatus) (*entity.Booking, error)
+	CancelBooking(ctx context.Context, bookingID, userID string) (*entity.Booking, error)
+	ExpireBookings(ctx context.Context) (int, error)
+
+	// Passenger Manifest (owner or staff)
+	GetPassengers(ctx context.Context, bookingID, userID, role string) ([]entity.BookingPassenger, error)
+	SetPassengers(ctx context.Context, bookingID, userID, role string, req entity.UpdatePassengersDTO) ([]entity.BookingPassenger, error)
+
+	// Payment Schedule (owner or staff)
+	CreatePaymentPlan(ctx context.Context, bookingID, userID, role string, req entity.CreatePaymentPlanDTO) (*entity.BookingBalance, error)
+	GetBalance(ctx context.Context, bookingID, userID, role string) (*entity.BookingBalance, error)
+	UploadInstallmentProof(ctx context.Context, bookingID, installmentID, userID, imageURL string) error
+	VerifyInstallment(ctx context.Context, installmentID, adminID string) (*entity.BookingBalance, error) // Admin only
+	// Payment gateway: run inside the caller's transaction (tx); the
+	// returned func issues receipts/enrollment once it has committed
+	SettleInstallmentPayment(ctx context.Context, tx repository.PackageRepository, installmentID string) (func(), error)
+	RefundInstallmentPayment(ctx context.Context, tx repository.PackageRepository, installmentID string) (func(), error)
+
+	// Admin Booking Management
+	ListBookings(ctx context.Context, filter entity.BookingFilter) ([]entity.Booking, int64, error)
+	ApproveBooking(ctx context.Context, bookingID string) (*entity.Booking, error)
+	AdminCancelBooking(ctx context.Context, bookingID, reason string) (*entity.Booking, error)
+	GetManifest(ctx context.Context, packageID string) ([]entity.Booking, error)
+
+	// Waitlist (sold-out packages)
+	JoinWaitlist(ctx context.Context, userID string, req entity.JoinWaitlistDTO) (*entity.WaitlistEntry, error)
+	LeaveWaitlist(ctx context.Context, entryID, userID string) (*entity.WaitlistEntry, error)
+	ClaimWaitlistOffer(ctx context.Context, entryID, userID string, req entity.ClaimWaitlistDTO) (*entity.Booking, error)
+	GetMyWaitlist(ctx context.Context, userID string) ([]entity.WaitlistEntry, error)
+	GetWaitlist(ctx context.Context, packageID string) ([]entity.WaitlistEntry, error) // Admin
+	ExpireWaitlistOffers(ctx context.Context) (int, error)
 }
 
 type packageService struct {
-	repo repository.PackageRepository
+	repo     repository.PackageRepository
+	groups   GroupService
+	currency CurrencyService
+	docs     DocumentService
+	promos   PromoService
+	agents   AgentService
+	fcm      *notification.FCMService
 }
 
-func NewPackageService(repo repository.PackageRepository) PackageService {
-	return &packageService{repo: repo}
+func NewPackageService(repo repository.PackageRepository, groups GroupService, currency CurrencyService, docs DocumentService, promos PromoService, agents AgentService, fcm *notification.FCMService) PackageService {
+	return &packageService{repo: repo, groups: groups, currency: currency, docs: docs, promos: promos, agents: agents, fcm: fcm}
 }
 
 func (s *packageService) CreatePackage(ctx context.Context, req entity.TravelPackage) error {
 	req.ID = uuid.New()
 	req.CreatedAt = time.Now()
 	req.Available = req.Quota
-	return s.repo.CreatePackage(ctx, &req)
+	if req.PaymentHoldHours <= 0 {
+		req.PaymentHoldHours = DefaultPaymentHoldHours
+	}
+	if req.WaitlistClaimHours <= 0 {
+		req.WaitlistClaimHours = DefaultWaitlistClaimHours
+	}
+	if err := validatePackagePrices(&req); err != nil {
+		return err
+	}
+	req.ArchivedAt = nil
+	if req.PublishAt != nil && req.UnpublishAt != nil && !req.UnpublishAt.After(*req.PublishAt) {
+		return errors.New("unpublish_at must be after publish_at")
+	}
+
+	// A package scheduled for later stays unlisted until the sweeper publishes it
+	scheduled := req.PublishAt != nil && req.PublishAt.After(req.CreatedAt)
+
+	return s.repo.WithTx(ctx, func(tx repository.PackageRepository) error {
+		if err := tx.CreatePackage(ctx, &req); err != nil {
+			return err
+		}
+		if scheduled {
+			// is_active has a database default, so false is set after the insert
+			if err := tx.UpdatePackageFields(ctx, req.ID.String(), map[string]interface{}{"is_active": false}); err != nil {
+				return err
+			}
+		}
+		_, err := recordVersion(ctx, tx, req.ID.String(), versionCreated)
+		return err
+	})
 }
 
-func (s *packageService) GetList(ctx context.Context, category string) ([]entity.TravelPackage, error) {
-	return s.repo.GetPackages(ctx, category)
+func (s *packageService) GetPackageAdmin(ctx context.Context, packageID string) (*entity.TravelPackage, error) {
+	pkg, err := s.repo.FindPackageDetail(ctx, packageID)
+	if err != nil {
+		return nil, ErrPackageNotFound
+	}
+	return pkg, nil
 }
 
-func (s *packageService) BookPackage(ctx context.Context, userID, packageID, roomType string, pax int) (*entity.Booking, error) {
-	// 1. Get Package Data (Read Only, for Pricing)
+// UpdatePackage changes the given fields of a package. Booked seats are
+// kept across quota changes; the new terms are recorded as a version.
+func (s *packageService) UpdatePackage(ctx context.Context, packageID string, req entity.UpdatePackageDTO) (*entity.TravelPackage, error) {
+	seatsAdded := false
+
+	err := s.repo.WithTx(ctx, func(tx repository.PackageRepository) error {
+		pkg, err := tx.LockPackageTerms(ctx, packageID)
+		if err != nil {
+			return ErrPackageNotFound
+		}
+		if pkg.ArchivedAt != nil {
+			return ErrPackageArchived
+		}
+
+		fields := map[string]interface{}{"updated_at": time.Now()}
+		if req.Name != nil {
+			fields["name"] = *req.Name
+		}
+		if req.Description != nil {
+			fields["description"] = *req.Description
+		}
+		if req.Category != nil {
+			fields["category"] = *req.Category
+		}
+		if req.SubCategory != nil {
+			fields["sub_category"] = *req.SubCategory
+		}
+		if req.Tags != nil {
+			fields["tags"] = *req.Tags
+		}
+		if req.HotelMakkah != nil {
+			fields["hotel_makkah"] = *req.HotelMakkah
+		}
+		if req.RatingMakkah != nil {
+			fields["rating_makkah"] = *req.RatingMakkah
+		}
+		if req.HotelMadinah != nil {
+			fields["hotel_madinah"] = *req.HotelMadinah
+		}
+		if req.RatingMadinah != nil {
+			fields["rating_madinah"] = *req.RatingMadinah
+		}
+		if req.AirlineName != nil {
+			fields["airline_name"] = *req.AirlineName
+		}
+		if req.AirlineClass != nil {
+			fields["airline_class"] = *req.AirlineClass
+		}
+		if req.DepartureCity != nil {
+			fields["departure_city"] = *req.DepartureCity
+		}
+		if req.DurationDays != nil {
+			fields["duration_days"] = *req.DurationDays
+		}
+		if req.PaymentHoldHours != nil {
+			fields["payment_hold_hours"] = *req.PaymentHoldHours
+		}
+		if req.WaitlistClaimHours != nil {
+			fields["waitlist_claim_hours"] = *req.WaitlistClaimHours
+		}
+
+		// Prices: a tier sent without a currency keeps the package currency
+		if req.PriceQuad != nil || req.PriceTriple != nil || req.PriceDouble != nil {
+			prices := *pkg
+			prices.PriceQuad = updatedPrice(prices.PriceQuad, req.PriceQuad)
+			prices.PriceTriple = updatedPrice(prices.PriceTriple, req.PriceTriple)
+			prices.PriceDouble = updatedPrice(prices.PriceDouble, req.PriceDouble)
+			if err := validatePackagePrices(&prices); err != nil {
+				return err
+			}
+			fields["price_quad_minor"] = prices.PriceQuad.Amount
+			fields["price_quad_currency"] = prices.PriceQuad.Currency
+			fields["price_triple_minor"] = prices.PriceTriple.Amount
+			fields["price_triple_currency"] = prices.PriceTriple.Currency
+			fields["price_double_minor"] = prices.PriceDouble.Amount
+			fields["price_double_currency"] = prices.PriceDouble.Currency
+		}
+
+		departure, returning := pkg.DepartureDate, pkg.ReturnDate
+		if req.DepartureDate != nil {
+			departure = *req.DepartureDate
+			fields["departure_date"] = departure
+		}
+		if req.ReturnDate != nil {
+			returning = *req.ReturnDate
+			fields["return_date"] = returning
+		}
+		if (req.DepartureDate != nil || req.ReturnDate != nil) && !returning.After(departure) {
+			return errors.New("return_date must be after departure_date")
+		}
+
+		// Seats already booked (or held by waitlist offers) stay taken
+		if req.Quota != nil {
+			taken := pkg.Quota - pkg.Available
+			if *req.Quota < taken {
+				return fmt.Errorf("quota cannot be less than the %d seats already booked", taken)
+			}
+			fields["quota"] = *req.Quota
+			fields["available"] = *req.Quota - taken
+			seatsAdded = *req.Quota > pkg.Quota
+		}
+
+		if req.IsActive != nil {
+			fields["is_active"] = *req.IsActive
+		}
+		publishAt, unpublishAt := pkg.PublishAt, pkg.UnpublishAt
+		if req.PublishAt != nil {
+			if publishAt, err = parseOptionalTime(*req.PublishAt, "publish_at"); err != nil {
+				return err
+			}
+			fields["publish_at"] = publishAt
+		}
+		if req.UnpublishAt != nil {
+			if unpublishAt, err = parseOptionalTime(*req.UnpublishAt, "unpublish_at"); err != nil {
+				return err
+			}
+			fields["unpublish_at"] = unpublishAt
+		}
+		if publishAt != nil && unpublishAt != nil && !unpublishAt.After(*publishAt) {
+			return errors.New("unpublish_at must be after publish_at")
+		}
+
+		if err := tx.UpdatePackageFields(ctx, packageID, fields); err != nil {
+			return err
+		}
+		_, err = recordVersion(ctx, tx, packageID, versionUpdated)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if seatsAdded {
+		s.offerWaitlist(ctx, packageID)
+	}
+	return s.repo.FindPackageDetail(ctx, packageID)
+}
+
+// updatedPrice applies a tier price update; nil leaves the tier as it is.
+func updatedPrice(tier money.Money, update *entity.PriceUpdateDTO) money.Money {
+	if update == nil {
+		return tier
+	}
+	currency := money.Currency(strings.ToUpper(strings.TrimSpace(string(update.Currency))))
+	if currency == "" {
+		currency = tier.Currency
+	}
+	return money.New(update.Amount, currency)
+}
+
+// ClonePackage creates the next departure of a package: same terms and
+// content, with every flight and hotel date moved by as many days as the
+// departure. The clone is unlisted until it is published.
+func (s *packageService) ClonePackage(ctx context.Context, packageID string, req entity.ClonePackageDTO) (*entity.TravelPackage, error) {
+	src, err := s.repo.FindPackageDetail(ctx, packageID)
+	if err != nil {
+		return nil, ErrPackageNotFound
+	}
+
+	now := time.Now()
+	if !req.DepartureDate.After(now) {
+		return nil, errors.New("departure_date must be in the future")
+	}
+	publishAt, err := parseOptionalTime(req.PublishAt, "publish_at")
+	if err != nil {
+		return nil, err
+	}
+	unpublishAt, err := parseOptionalTime(req.UnpublishAt, "unpublish_at")
+	if err != nil {
+		return nil, err
+	}
+	if publishAt != nil && unpublishAt != nil && !unpublishAt.After(*publishAt) {
+		return nil, errors.New("unpublish_at must be after publish_at")
+	}
+
+	// Whole days, so that clock times (flights) and dates (hotels) are kept
+	days := int(math.Round(req.DepartureDate.Sub(src.DepartureDate).Hours() / 24))
+
+	clone := *src
+	clone.ID = uuid.New()
+	clone.DepartureDate = req.DepartureDate
+	clone.ReturnDate = src.ReturnDate.AddDate(0, 0, days)
+	clone.Available = clone.Quota
+	clone.IsActive = false
+	clone.PublishAt = publishAt
+	clone.UnpublishAt = unpublishAt
+	clone.ArchivedAt = nil
+	clone.ItineraryDays, clone.Inclusions, clone.Flights, clone.Hotels = nil, nil, nil, nil
+	clone.CreatedAt = now
+	clone.UpdatedAt = now
+	if req.Name != nil {
+		clone.Name = *req.Name
+	}
+	if req.Quota != nil {
+		clone.Quota = *req.Quota
+		clone.Available = *req.Quota
+	}
+
+	itinerary := make([]entity.PackageItineraryDay, 0, len(src.ItineraryDays))
+	for _, d := range src.ItineraryDays {
+		day := d
+		day.ID = uuid.New()
+		day.PackageID = clone.ID
+		day.Items = make([]entity.PackageItineraryItem, 0, len(d.Items))
+		for _, item := range d.Items {
+			item.ID = uuid.New()
+			item.DayID = day.ID
+			day.Items = append(day.Items, item)
+		}
+		itinerary = append(itinerary, day)
+	}
+	inclusions := make([]entity.PackageInclusion, 0, len(src.Inclusions))
+	for _, inc := range src.Inclusions {
+		inc.ID = uuid.New()
+		inc.PackageID = clone.ID
+		inclusions = append(inclusions, inc)
+	}
+	flights := make([]entity.PackageFlight, 0, len(src.Flights))
+	for _, f := range src.Flights {
+		f.ID = uuid.New()
+		f.PackageID = clone.ID
+		f.DepartAt = f.DepartAt.AddDate(0, 0, days)
+		f.ArriveAt = f.ArriveAt.AddDate(0, 0, days)
+		flights = append(flights, f)
+	}
+	hotels := make([]entity.PackageHotel, 0, len(src.Hotels))
+	for _, h := range src.Hotels {
+		h.ID = uuid.New()
+		h.PackageID = clone.ID
+		h.CheckIn = h.CheckIn.AddDate(0, 0, days)
+		h.CheckOut = h.CheckOut.AddDate(0, 0, days)
+		hotels = append(hotels, h)
+	}
+
+	id := clone.ID.String()
+	err = s.repo.WithTx(ctx, func(tx repository.PackageRepository) error {
+		if err := tx.CreatePackage(ctx, &clone); err != nil {
+			return err
+		}
+		// is_active has a database default, so false is set after the insert
+		if err := tx.UpdatePackageFields(ctx, id, map[string]interface{}{"is_active": false}); err != nil {
+			return err
+		}
+		if err := tx.ReplaceItinerary(ctx, id, itinerary); err != nil {
+			return err
+		}
+		if err := tx.ReplaceInclusions(ctx, id, inclusions); err != nil {
+			return err
+		}
+		if err := tx.ReplaceFlights(ctx, id, flights); err != nil {
+			return err
+		}
+		if err := tx.ReplaceHotels(ctx, id, hotels); err != nil {
+			return err
+		}
+		_, err := recordVersion(ctx, tx, id, versionCloned)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.FindPackageDetail(ctx, id)
+}
+
+// ArchivePackage retires a package: it is unlisted for good, its schedule
+// is dropped and its waitlist closed (held seats are released). Existing
+// bookings are not touched.
+func (s *packageService) ArchivePackage(ctx context.Context, packageID string) (*entity.TravelPackage, error) {
+	err := s.repo.WithTx(ctx, func(tx repository.PackageRepository) error {
+		// Waitlist rows first, in the same order as offerSeats, to avoid deadlocks
+		entries, err := tx.GetOpenWaitlistEntries(ctx, packageID)
+		if err != nil {
+			return err
+		}
+		pkg, err := tx.LockPackageTerms(ctx, packageID)
+		if err != nil {
+			return ErrPackageNotFound
+		}
+		if pkg.ArchivedAt != nil {
+			return ErrPackageArchived
+		}
+
+		now := time.Now()
+		err = tx.UpdatePackageFields(ctx, packageID, map[string]interface{}{
+			"is_active":    false,
+			"archived_at":  now,
+			"publish_at":   nil,
+			"unpublish_at": nil,
+			"updated_at":   now,
+		})
+		if err != nil {
+			return err
+		}
+
+		for i := range entries {
+			entry := &entries[i]
+			if entry.Status == entity.WaitlistOffered {
+				if err := tx.IncreaseQuota(ctx, packageID, entry.PaxCount); err != nil {
+					return fmt.Errorf("failed to release seats: %v", err)
+				}
+			}
+			entry.Status = entity.WaitlistCancelled
+			entry.UpdatedAt = now
+			if err := tx.UpdateWaitlistEntry(ctx, entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.FindPackageDetail(ctx, packageID)
+}
+
+// PublishScheduledPackages applies the publish and unpublish times that
+// have come. Returns how many packages changed.
+func (s *packageService) PublishScheduledPackages(ctx context.Context) (int, error) {
+	now := time.Now()
+	published, err := s.repo.PublishDuePackages(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+	unpublished, err := s.repo.UnpublishDuePackages(ctx, now)
+	return int(published + unpublished), err
+}
+
+// validatePackagePrices: all room tiers must be priced in one currency,
+// otherwise a booking's total could not be compared between tiers.
+func validatePackagePrices(pkg *entity.TravelPackage) error {
+	tiers := []*money.Money{&pkg.PriceQuad, &pkg.PriceTriple, &pkg.PriceDouble}
+	for _, tier := range tiers {
+		if tier.Currency == "" {
+			tier.Currency = money.Default
+		}
+		if err := tier.Validate(); err != nil {
+			return err
+		}
+	}
+	if !pkg.PriceQuad.SameCurrency(pkg.PriceTriple) || !pkg.PriceQuad.SameCurrency(pkg.PriceDouble) {
+		return fmt.Errorf("%w: all room prices must use the same currency", money.ErrCurrencyMismatch)
+	}
+	return nil
+}
+
+func (s *packageService) GetList(ctx context.Context, search entity.PackageSearch, currency string) (*entity.PackageSearchResult, error) {
+	var target money.Currency
+	if currency != "" {
+		c, err := money.ParseCurrency(currency)
+		if err != nil {
+			return nil, err
+		}
+		target = c
+	}
+	if search.PriceCurrency == "" {
+		search.PriceCurrency = money.Default
+	}
+	if search.MinPrice != nil && search.MaxPrice != nil && *search.MinPrice > *search.MaxPrice {
+		return nil, errors.New("min_price cannot be greater than max_price")
+	}
+	if search.MinDuration > 0 && search.MaxDuration > 0 && search.MinDuration > search.MaxDuration {
+		return nil, errors.New("min_duration cannot be greater than max_duration")
+	}
+
+	packages, total, err := s.repo.SearchPackages(ctx, search)
+	if err != nil {
+		return nil, err
+	}
+	facets, err := s.repo.GetPackageFacets(ctx, search)
+	if err != nil {
+		return nil, err
+	}
+	if packages == nil {
+		packages = []entity.TravelPackage{}
+	}
+	result := &entity.PackageSearchResult{Data: packages, Total: total, Page: search.Page, Limit: search.Limit, Facets: facets}
+	if target == "" {
+		return result, nil
+	}
+
+	for i := range packages {
+		if err := s.setDisplayPrices(ctx, &packages[i], target); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// setDisplayPrices: display conversion only; bookings are always charged in
+// the package currency.
+func (s *packageService) setDisplayPrices(ctx context.Context, pkg *entity.TravelPackage, target money.Currency) error {
+	prices := map[string]money.Money{
+		"QUAD":   pkg.PriceQuad,
+		"TRIPLE": pkg.PriceTriple,
+		"DOUBLE": pkg.PriceDouble,
+	}
+	display := make(map[string]money.Money, len(prices))
+	for tier, price := range prices {
+		converted, err := s.currency.Convert(ctx, price, target)
+		if err != nil {
+			return err
+		}
+		display[tier] = converted
+	}
+	pkg.DisplayPrices = display
+	return nil
+}
+
+// GetPackage returns an active package with its itinerary, inclusions,
+// flights and hotels.
+func (s *packageService) GetPackage(ctx context.Context, packageID, currency string) (*entity.TravelPackage, error) {
+	pkg, err := s.repo.FindPackageDetail(ctx, packageID)
+	if err != nil || !pkg.IsActive {
+		return nil, ErrPackageNotFound
+	}
+	if currency != "" {
+		target, err := money.ParseCurrency(currency)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.setDisplayPrices(ctx, pkg, target); err != nil {
+			return nil, err
+		}
+	}
+	return pkg, nil
+}
+
+// SetItinerary replaces the package's template rundown. Groups created
+// afterwards get it automatically; existing groups via GeneratePackageRundown.
+func (s *packageService) SetItinerary(ctx context.Context, packageID string, req entity.SetItineraryDTO) (*entity.TravelPackage, error) {
 	pkg, err := s.repo.FindPackageByID(ctx, packageID)
+	if err != nil {
+		return nil, ErrPackageNotFound
+	}
+	if pkg.ArchivedAt != nil {
+		return nil, ErrPackageArchived
+	}
+
+	seen := make(map[int]bool)
+	days := make([]entity.PackageItineraryDay, 0, len(req.Days))
+	for _, d := range req.Days {
+		if seen[d.DayNumber] {
+			return nil, fmt.Errorf("day %d is set twice", d.DayNumber)
+		}
+		seen[d.DayNumber] = true
+		if pkg.DurationDays > 0 && d.DayNumber > pkg.DurationDays {
+			return nil, fmt.Errorf("day %d is after the end of a %d-day package", d.DayNumber, pkg.DurationDays)
+		}
+		tz := d.Timezone
+		if tz == "" {
+			tz = entity.DefaultItineraryTimezone
+		}
+		if _, err := time.LoadLocation(tz); err != nil {
+			return nil, fmt.Errorf("day %d: unknown timezone %q", d.DayNumber, tz)
+		}
+
+		day := entity.PackageItineraryDay{
+			ID:          uuid.New(),
+			PackageID:   pkg.ID,
+			DayNumber:   d.DayNumber,
+			Title:       d.Title,
+			Description: d.Description,
+			City:        d.City,
+			Timezone:    tz,
+			Items:       make([]entity.PackageItineraryItem, 0, len(d.Items)),
+		}
+		for i, item := range d.Items {
+			if _, _, err := parseClock(item.StartTime); err != nil {
+				return nil, fmt.Errorf("day %d: %w", d.DayNumber, err)
+			}
+			if item.EndTime != "" {
+				if _, _, err := parseClock(item.EndTime); err != nil {
+					return nil, fmt.Errorf("day %d: %w", d.DayNumber, err)
+				}
+			}
+			day.Items = append(day.Items, entity.PackageItineraryItem{
+				ID:          uuid.New(),
+				DayID:       day.ID,
+				Sequence:    i + 1,
+				StartTime:   item.StartTime,
+				EndTime:     item.EndTime,
+				Title:       item.Title,
+				Description: item.Description,
+				Location:    item.Location,
+				Latitude:    item.Latitude,
+				Longitude:   item.Longitude,
+			})
+		}
+		days = append(days, day)
+	}
+
+	if err := s.repo.ReplaceItinerary(ctx, packageID, days); err != nil {
+		return nil, err
+	}
+	return s.repo.FindPackageDetail(ctx, packageID)
+}
+
+func (s *packageService) SetInclusions(ctx context.Context, packageID string, req entity.SetInclusionsDTO) (*entity.TravelPackage, error) {
+	pkg, err := s.repo.FindPackageByID(ctx, packageID)
+	if err != nil {
+		return nil, ErrPackageNotFound
+	}
+	if pkg.ArchivedAt != nil {
+		return nil, ErrPackageArchived
+	}
+
+	items := make([]entity.PackageInclusion, 0, len(req.Items))
+	for i, item := range req.Items {
+		items = append(items, entity.PackageInclusion{ID: uuid.New(), PackageID: pkg.ID, Kind: item.Kind, Text: item.Text, Sequence: i + 1})
+	}
+
+	if err := s.repo.ReplaceInclusions(ctx, packageID, items); err != nil {
+		return nil, err
+	}
+	return s.repo.FindPackageDetail(ctx, packageID)
+}
+
+func (s *packageService) SetFlights(ctx context.Context, packageID string, req entity.SetFlightsDTO) (*entity.TravelPackage, error) {
+	pkg, err := s.repo.FindPackageByID(ctx, packageID)
+	if err != nil {
+		return nil, ErrPackageNotFound
+	}
+	if pkg.ArchivedAt != nil {
+		return nil, ErrPackageArchived
+	}
+
+	flights := make([]entity.PackageFlight, 0, len(req.Flights))
+	for i, f := range req.Flights {
+		departAt, err := time.Parse(time.RFC3339, f.DepartAt)
+		if err != nil {
+			return nil, fmt.Errorf("flight %d: invalid depart_at format (use RFC3339 with the local offset, e.g. 2026-11-01T10:30:00+07:00)", i+1)
+		}
+		arriveAt, err := time.Parse(time.RFC3339, f.ArriveAt)
+		if err != nil {
+			return nil, fmt.Errorf("flight %d: invalid arrive_at format (use RFC3339 with the local offset, e.g. 2026-11-01T16:45:00+03:00)", i+1)
+		}
+		if !arriveAt.After(departAt) {
+			return nil, fmt.Errorf("flight %d: arrive_at must be after depart_at", i+1)
+		}
+		flights = append(flights, entity.PackageFlight{
+			ID:           uuid.New(),
+			PackageID:    pkg.ID,
+			Sequence:     i + 1,
+			Direction:    f.Direction,
+			Airline:      f.Airline,
+			FlightNumber: strings.ToUpper(f.FlightNumber),
+			FromAirport:  strings.ToUpper(f.FromAirport),
+			ToAirport:    strings.ToUpper(f.ToAirport),
+			DepartAt:     departAt,
+			ArriveAt:     arriveAt,
+		})
+	}
+
+	err = s.repo.WithTx(ctx, func(tx repository.PackageRepository) error {
+		// Archive may have run since the check above
+		locked, err := tx.LockPackageTerms(ctx, packageID)
+		if err != nil {
+			return ErrPackageNotFound
+		}
+		if locked.ArchivedAt != nil {
+			return ErrPackageArchived
+		}
+
+		if err := tx.ReplaceFlights(ctx, packageID, flights); err != nil {
+			return err
+		}
+		_, err = recordVersion(ctx, tx, packageID, versionFlightsUpdated)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.FindPackageDetail(ctx, packageID)
+}
+
+func (s *packageService) SetHotels(ctx context.Context, packageID string, req entity.SetHotelsDTO) (*entity.TravelPackage, error) {
+	pkg, err := s.repo.FindPackageByID(ctx, packageID)
+	if err != nil {
+		return nil, ErrPackageNotFound
+	}
+	if pkg.ArchivedAt != nil {
+		return nil, ErrPackageArchived
+	}
+
+	hotels := make([]entity.PackageHotel, 0, len(req.Hotels))
+	for i, h := range req.Hotels {
+		checkIn, err := time.Parse("2006-01-02", h.CheckIn)
+		if err != nil {
+			return nil, fmt.Errorf("hotel %d: invalid check_in format (use YYYY-MM-DD)", i+1)
+		}
+		checkOut, err := time.Parse("2006-01-02", h.CheckOut)
+		if err != nil {
+			return nil, fmt.Errorf("hotel %d: invalid check_out format (use YYYY-MM-DD)", i+1)
+		}
+		if !checkOut.After(checkIn) {
+			return nil, fmt.Errorf("hotel %d: check_out must be after check_in", i+1)
+		}
+		hotels = append(hotels, entity.PackageHotel{
+			ID:        uuid.New(),
+			PackageID: pkg.ID,
+			Sequence:  i + 1,
+			City:      h.City,
+			Name:      h.Name,
+			Rating:    h.Rating,
+			CheckIn:   checkIn,
+			CheckOut:  checkOut,
+			Nights:    int(checkOut.Sub(checkIn).Hours() / 24),
+		})
+	}
+
+	err = s.repo.WithTx(ctx, func(tx repository.PackageRepository) error {
+		// Archive may have run since the check above
+		locked, err := tx.LockPackageTerms(ctx, packageID)
+		if err != nil {
+			return ErrPackageNotFound
+		}
+		if locked.ArchivedAt != nil {
+			return ErrPackageArchived
+		}
+
+		if err := tx.ReplaceHotels(ctx, packageID, hotels); err != nil {
+			return err
+		}
+		_, err = recordVersion(ctx, tx, packageID, versionHotelsUpdated)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.FindPackageDetail(ctx, packageID)
+}
+
+func (s *packageService) BookPackage(ctx context.Context, userID string, req entity.BookPackageDTO) (*entity.Booking, error) {
+	return s.placeBooking(ctx, userID, req, "")
+}
+
+// placeBooking creates a PENDING booking. With claimEntryID the seats come
+// from the waitlist offer instead of the package's free seats.
+func (s *packageService) placeBooking(ctx context.Context, userID string, req entity.BookPackageDTO, claimEntryID string) (*entity.Booking, error) {
+	pax := req.PaxCount
+	if pax <= 0 {
+		return nil, errors.New("pax_count must be at least 1")
+	}
+
+	// 1. Get Package Data (Read Only, for Pricing)
+	pkg, err := s.repo.FindPackageByID(ctx, req.PackageID)
 	if err != nil {
 		return nil, errors.New("package not found")
 	}
+	// Open waitlist offers stay claimable while the package is unlisted
+	if !pkg.IsActive && claimEntryID == "" {
+		return nil, errors.New("package is not open for booking")
+	}
 
 	// 2. Calculate Price
-	var pricePerPax float64
+	totalPrice, err := bookingPrice(pkg, req.RoomType, pax)
+	if err != nil {
+		return nil, err
+	}
+
+	// The referring agent earns commission once the booking is confirmed
+	var agent *entity.Agent
+	if req.ReferralCode != "" {
+		if agent, err = s.agents.ResolveReferral(ctx, req.ReferralCode, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	// 3. Create Booking Object
+	// Seats are only held until the package's payment window runs out.
+	now := time.Now()
+	holdHours := pkg.PaymentHoldHours
+	if holdHours <= 0 {
+		holdHours = DefaultPaymentHoldHours
+	}
+	expiresAt := now.Add(time.Duration(holdHours) * time.Hour)
+
+	booking := &entity.Booking{
+		ID:         uuid.New(),
+		UserID:     uuid.MustParse(userID),
+		PackageID:  pkg.ID,
+		PaxCount:   pax,
+		RoomType:   req.RoomType,
+		TotalPrice: totalPrice,
+		Discount:   money.Zero(totalPrice.Currency),
+		Status:     entity.BookingPending,
+		ExpiresAt:  &expiresAt,
+		CreatedAt:  now,
+	}
+	if agent != nil {
+		booking.AgentID = &agent.ID
+		booking.ReferralCode = agent.ReferralCode
+	}
+
+	// 4. [ATOMIC] Deduct Quota + Save Booking in ONE transaction
+	// Jika insert booking gagal, pengurangan kuota ikut di-rollback,
+	// jadi tidak ada lagi kursi yang "hilang".
+	// The promo code (if any) is reserved first and given back on failure.
+	err = redeemPromo(ctx, s.promos, userID, req.PromoCode, entity.PromoForBookings, entity.DocRefBooking, func(promo *entity.PromoCode) (uuid.UUID, money.Money, error) {
+		if promo != nil {
+			discount, err := computeDiscount(promo, []entity.PromoLine{bookingPromoLine(pkg, totalPrice)})
+			if err != nil {
+				return uuid.Nil, money.Money{}, err
+			}
+			if booking.TotalPrice, err = totalPrice.Sub(discount); err != nil {
+				return uuid.Nil, money.Money{}, err
+			}
+			booking.PromoCode = promo.Code
+			booking.Discount = discount
+		}
+
+		err := s.repo.WithTx(ctx, func(tx repository.PackageRepository) error {
+			if claimEntryID != "" {
+				if err := claimOffer(ctx, tx, claimEntryID, booking); err != nil {
+					return err
+				}
+			} else {
+				// Freed seats build up for the head of the waitlist, a
+				// newcomer must not take them first
+				waiting, err := tx.CountWaiting(ctx, pkg.ID.String())
+				if err != nil {
+					return err
+				}
+				if waiting > 0 {
+					return fmt.Errorf("%w, %d already waiting", ErrNotEnoughSeats, waiting)
+				}
+				if err := tx.DecreaseQuota(ctx, pkg.ID.String(), pax); err != nil {
+					return ErrNotEnoughSeats
+				}
+			}
+
+			// Link the booking to the terms it was priced under
+			version, err := recordVersion(ctx, tx, pkg.ID.String(), versionAtBooking)
+			if err != nil {
+				return err
+			}
+			if !pricedUnder(pkg, version) {
+				return ErrPackageTermsChanged
+			}
+			booking.PackageVersionID = &version.ID
+			return tx.CreateBooking(ctx, booking)
+		})
+		return booking.ID, booking.Discount, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	issueDocument(ctx, s.docs.IssueBookingInvoice, booking.ID.String())
+	return booking, nil
+}
+
+// QuoteBooking previews what a promo code takes off a booking. Nothing is
+// reserved: the code is checked again when booking.
+func (s *packageService) QuoteBooking(ctx context.Context, userID string, req entity.BookingQuoteDTO) (*entity.PromoQuote, error) {
+	pkg, err := s.repo.FindPackageByID(ctx, req.PackageID)
+	if err != nil {
+		return nil, errors.New("package not found")
+	}
+	totalPrice, err := bookingPrice(pkg, req.RoomType, req.PaxCount)
+	if err != nil {
+		return nil, err
+	}
+
+	promo, err := s.promos.Check(ctx, userID, req.PromoCode, entity.PromoForBookings)
+	if err != nil {
+		return nil, err
+	}
+	discount, err := computeDiscount(promo, []entity.PromoLine{bookingPromoLine(pkg, totalPrice)})
+	if err != nil {
+		return nil, err
+	}
+	total, err := totalPrice.Sub(discount)
+	if err != nil {
+		return nil, err
+	}
+	return &entity.PromoQuote{Code: promo.Code, Subtotal: totalPrice, Discount: discount, Total: total}, nil
+}
+
+// bookingPrice: room tier price x pax, before any discount
+func bookingPrice(pkg *entity.TravelPackage, roomType string, pax int) (money.Money, error) {
+	var pricePerPax money.Money
 	switch roomType {
 	case "QUAD":
 		pricePerPax = pkg.PriceQuad
@@ -52,37 +950,1107 @@ func (s *packageService) BookPackage(ctx context.Context, userID, packageID, roo
 	case "DOUBLE":
 		pricePerPax = pkg.PriceDouble
 	default:
-		return nil, errors.New("invalid room type")
+		return money.Money{}, errors.New("invalid room type")
 	}
+	return pricePerPax.Mul(int64(pax)), nil
+}
 
-	totalPrice := pricePerPax * float64(pax)
+func bookingPromoLine(pkg *entity.TravelPackage, amount money.Money) entity.PromoLine {
+	return entity.PromoLine{PackageID: &pkg.ID, PackageCategory: pkg.Category, Amount: amount}
+}
 
-	// 3. Create Booking Object
-	booking := &entity.Booking{
-		ID:         uuid.New(),
-		UserID:     uuid.MustParse(userID),
-		PackageID:  pkg.ID,
-		PaxCount:   pax,
-		RoomType:   roomType,
-		TotalPrice: totalPrice,
-		Status:     "PENDING",
-		CreatedAt:  time.Now(),
+// afterReleased runs side effects of a committed move out of a seat-holding
+// status: the promo code use is given back when the booking died before
+// anything was paid (PENDING -> CANCELLED / EXPIRED), unpaid agent
+// commissions are voided, the pilgrim leaves the package group and the freed
+// seats are offered to the waitlist. Best-effort, like afterConfirmed.
+func (s *packageService) afterReleased(ctx context.Context, booking *entity.Booking, from entity.BookingStatus) {
+	if !from.HoldsSeats() || booking.Status.HoldsSeats() {
+		return
+	}
+	if booking.PromoCode != "" && from == entity.BookingPending {
+		releasePromo(ctx, s.promos, entity.DocRefBooking, booking.ID)
+	}
+	if booking.AgentID != nil {
+		if err := s.agents.CancelCommissions(ctx, booking.ID.String()); err != nil {
+			log.Printf("Failed to cancel commissions of booking %s: %v", booking.ID, err)
+		}
 	}
+	if err := s.groups.RemovePackageMember(ctx, booking.PackageID.String(), booking.UserID.String()); err != nil {
+		log.Printf("Failed to remove booking %s from package group: %v", booking.ID, err)
+	}
+	s.offerWaitlist(ctx, booking.PackageID.String())
+}
+
+// TransitionBooking moves a booking through the lifecycle state machine.
+// Leaving a seat-holding status (PENDING/PAID/CONFIRMED) returns the seats
+// to the package in the same transaction as the status change.
+func (s *packageService) TransitionBooking(ctx context.Context, bookingID string, next entity.BookingStatus) (*entity.Booking, error) {
+	var result *entity.Booking
+	var from entity.BookingStatus
+
+	err := s.repo.WithTx(ctx, func(tx repository.PackageRepository) error {
+		booking, err := tx.FindBookingByID(ctx, bookingID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrBookingNotFound
+			}
+			return err
+		}
 
-	// 4. [ATOMIC] Deduct Quota FIRST
-	// Kita kurangi dulu kuotanya menggunakan Query UPDATE atomic.
-	// Jika return error (rows affected 0), berarti kuota habis.
-	if err := s.repo.DecreaseQuota(ctx, pkg.ID.String(), pax); err != nil {
-		return nil, errors.New("booking failed: not enough seats available")
+		from = booking.Status
+		if err := s.applyTransition(ctx, tx, booking, next); err != nil {
+			return err
+		}
+
+		result = booking
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// 5. Save Booking
-	// Jika save booking gagal, idealnya kita rollback kuota (increase).
-	// Tapi untuk MVP, urutan ini lebih aman daripada Overselling.
-	if err := s.repo.CreateBooking(ctx, booking); err != nil {
-		// TODO: Rollback quota here (IncreaseQuota)
+	s.afterReleased(ctx, result, from)
+	return result, nil
+}
+
+// CancelBooking lets the owner of a booking cancel it before it is refunded.
+func (s *packageService) CancelBooking(ctx context.Context, bookingID, userID string) (*entity.Booking, error) {
+	var result *entity.Booking
+	var from entity.BookingStatus
+
+	err := s.repo.WithTx(ctx, func(tx repository.PackageRepository) error {
+		booking, err := tx.FindBookingByID(ctx, bookingID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrBookingNotFound
+			}
+			return err
+		}
+
+		if booking.UserID.String() != userID {
+			return errors.New("unauthorized")
+		}
+
+		from = booking.Status
+		if err := s.applyTransition(ctx, tx, booking, entity.BookingCancelled); err != nil {
+			return err
+		}
+
+		result = booking
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	return booking, nil
+	s.afterReleased(ctx, result, from)
+	return result, nil
+}
+
+// ExpireBookings moves unpaid PENDING bookings past their hold to EXPIRED
+// and gives their seats back. Each booking runs in its own transaction so
+// one failure does not block the rest of the batch.
+func (s *packageService) ExpireBookings(ctx context.Context) (int, error) {
+	now := time.Now()
+	ids, err := s.repo.FindExpiredBookingIDs(ctx, now, expireBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	var lastErr error
+	for _, id := range ids {
+		var released *entity.Booking
+		err := s.repo.WithTx(ctx, func(tx repository.PackageRepository) error {
+			booking, err := tx.FindBookingByID(ctx, id)
+			if err != nil {
+				return err
+			}
+
+			// Re-check under the row lock: the customer may have paid meanwhile.
+			if booking.Status != entity.BookingPending || booking.ExpiresAt == nil || booking.ExpiresAt.After(now) {
+				return nil
+			}
+
+			if err := s.applyTransition(ctx, tx, booking, entity.BookingExpired); err != nil {
+				return err
+			}
+			released = booking
+			return nil
+		})
+		if err != nil {
+			lastErr = fmt.Errorf("failed to expire booking %s: %v", id, err)
+			continue
+		}
+		if released != nil {
+			expired++ // Counted once committed
+			s.afterReleased(ctx, released, entity.BookingPending)
+		}
+	}
+
+	return expired, lastErr
+}
+
+func (s *packageService) GetPassengers(ctx context.Context, bookingID, userID, role string) ([]entity.BookingPassenger, error) {
+	booking, err := s.repo.FindBookingByID(ctx, bookingID)
+	if err != nil {
+		return nil, ErrBookingNotFound
+	}
+	if !canAccessBooking(booking, userID, role) {
+		return nil, errors.New("unauthorized")
+	}
+	return s.repo.GetPassengers(ctx, bookingID)
+}
+
+// SetPassengers replaces the passenger list of a booking. The list is
+// editable until the booking is CONFIRMED and may never exceed PaxCount.
+func (s *packageService) SetPassengers(ctx context.Context, bookingID, userID, role string, req entity.UpdatePassengersDTO) ([]entity.BookingPassenger, error) {
+	var passengers []entity.BookingPassenger
+
+	err := s.repo.WithTx(ctx, func(tx repository.PackageRepository) error {
+		booking, err := tx.FindBookingByID(ctx, bookingID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrBookingNotFound
+			}
+			return err
+		}
+		if !canAccessBooking(booking, userID, role) {
+			return errors.New("unauthorized")
+		}
+		if booking.Status != entity.BookingPending && booking.Status != entity.BookingPaid {
+			return fmt.Errorf("passengers can no longer be edited (booking is %s)", booking.Status)
+		}
+		if len(req.Passengers) > booking.PaxCount {
+			return fmt.Errorf("booking is for %d pax, got %d passengers", booking.PaxCount, len(req.Passengers))
+		}
+
+		pkg, err := tx.FindPackageByID(ctx, booking.PackageID.String())
+		if err != nil {
+			return errors.New("package not found")
+		}
+
+		passengers, err = buildPassengers(booking, pkg, req.Passengers)
+		if err != nil {
+			return err
+		}
+
+		// The same pilgrim (passport and gender unchanged) keeps their ID,
+		// and with it their place in the rooming list
+		current, err := tx.GetPassengers(ctx, bookingID)
+		if err != nil {
+			return err
+		}
+		byPassport := make(map[string]entity.BookingPassenger, len(current))
+		for _, p := range current {
+			byPassport[p.PassportNumber] = p
+		}
+		for i := range passengers {
+			if old, ok := byPassport[passengers[i].PassportNumber]; ok && old.Gender == passengers[i].Gender {
+				passengers[i].ID = old.ID
+				passengers[i].CreatedAt = old.CreatedAt
+			}
+		}
+
+		return tx.ReplacePassengers(ctx, bookingID, passengers)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return passengers, nil
+}
+
+func buildPassengers(booking *entity.Booking, pkg *entity.TravelPackage, items []entity.PassengerDTO) ([]entity.BookingPassenger, error) {
+	now := time.Now()
+	minExpiry := pkg.DepartureDate.AddDate(0, passportValidityMonths, 0)
+	seen := make(map[string]bool)
+
+	passengers := make([]entity.BookingPassenger, 0, len(items))
+	for i, p := range items {
+		passport := strings.ToUpper(strings.TrimSpace(p.PassportNumber))
+		if seen[passport] {
+			return nil, fmt.Errorf("passenger %d: duplicate passport number %s", i+1, passport)
+		}
+		seen[passport] = true
+
+		expiry, err := time.Parse("2006-01-02", p.PassportExpiry)
+		if err != nil {
+			return nil, fmt.Errorf("passenger %d: invalid passport_expiry format (use YYYY-MM-DD)", i+1)
+		}
+		if expiry.Before(minExpiry) {
+			return nil, fmt.Errorf("passenger %d: passport must be valid until at least %s", i+1, minExpiry.Format("2006-01-02"))
+		}
+
+		birth, err := time.Parse("2006-01-02", p.BirthDate)
+		if err != nil {
+			return nil, fmt.Errorf("passenger %d: invalid birth_date format (use YYYY-MM-DD)", i+1)
+		}
+		if !birth.Before(now) {
+			return nil, fmt.Errorf("passenger %d: birth_date must be in the past", i+1)
+		}
+
+		passengers = append(passengers, entity.BookingPassenger{
+			ID:             uuid.New(),
+			BookingID:      booking.ID,
+			Sequence:       i + 1,
+			FullName:       strings.TrimSpace(p.FullName),
+			PassportNumber: passport,
+			PassportExpiry: expiry,
+			BirthDate:      birth,
+			Gender:         p.Gender,
+			MahramRelation: p.MahramRelation,
+			MahramName:     strings.TrimSpace(p.MahramName),
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		})
+	}
+
+	return passengers, nil
+}
+
+// canAccessBooking: the booking owner, or ADMIN/MUTAWWIF staff
+func canAccessBooking(booking *entity.Booking, userID, role string) bool {
+	return booking.UserID.String() == userID || role == entity.RoleAdmin || role == entity.RoleMutawwif
+}
+
+// CreatePaymentPlan (re)builds the payment schedule of a booking: a down
+// payment due within the hold window, then equal monthly installments that
+// must all fall before the final payment deadline.
+func (s *packageService) CreatePaymentPlan(ctx context.Context, bookingID, userID, role string, req entity.CreatePaymentPlanDTO) (*entity.BookingBalance, error) {
+	var balance *entity.BookingBalance
+
+	err := s.repo.WithTx(ctx, func(tx repository.PackageRepository) error {
+		booking, err := tx.FindBookingByID(ctx, bookingID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrBookingNotFound
+			}
+			return err
+		}
+		if !canAccessBooking(booking, userID, role) {
+			return errors.New("unauthorized")
+		}
+		if booking.Status != entity.BookingPending {
+			return fmt.Errorf("payment plan can only be set on a PENDING booking (booking is %s)", booking.Status)
+		}
+
+		existing, err := tx.GetInstallments(ctx, bookingID)
+		if err != nil {
+			return err
+		}
+		for _, inst := range existing {
+			if inst.Status != entity.InstallmentUnpaid {
+				return errors.New("payment plan already has a submitted payment and cannot be changed")
+			}
+		}
+
+		pkg, err := tx.FindPackageByID(ctx, booking.PackageID.String())
+		if err != nil {
+			return errors.New("package not found")
+		}
+
+		installments, err := buildInstallments(booking, pkg, req)
+		if err != nil {
+			return err
+		}
+		if err := tx.ReplaceInstallments(ctx, bookingID, installments); err != nil {
+			return err
+		}
+
+		balance = computeBalance(booking, installments)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return balance, nil
+}
+
+func buildInstallments(booking *entity.Booking, pkg *entity.TravelPackage, req entity.CreatePaymentPlanDTO) ([]entity.BookingInstallment, error) {
+	now := time.Now()
+
+	// First payment is due when the seat hold runs out
+	firstDue := now.Add(time.Duration(DefaultPaymentHoldHours) * time.Hour)
+	if booking.ExpiresAt != nil {
+		firstDue = *booking.ExpiresAt
+	}
+
+	newInstallment := func(seq int, kind entity.InstallmentKind, amount money.Money, due time.Time) entity.BookingInstallment {
+		return entity.BookingInstallment{
+			ID:        uuid.New(),
+			BookingID: booking.ID,
+			Sequence:  seq,
+			Kind:      kind,
+			Amount:    amount,
+			DueDate:   due,
+			Status:    entity.InstallmentUnpaid,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+	}
+
+	if req.Installments == 0 {
+		return []entity.BookingInstallment{
+			newInstallment(1, entity.KindFullPayment, booking.TotalPrice, firstDue),
+		}, nil
+	}
+
+	downPayment := money.New(req.DownPayment, booking.TotalPrice.Currency)
+	if !downPayment.IsPositive() || downPayment.Amount >= booking.TotalPrice.Amount {
+		return nil, errors.New("down_payment must be greater than 0 and less than the total price")
+	}
+
+	deadline := pkg.DepartureDate.AddDate(0, 0, -finalPaymentDaysBeforeDeparture)
+	lastDue := firstDue.AddDate(0, req.Installments, 0)
+	if lastDue.After(deadline) {
+		return nil, fmt.Errorf("too many installments: final payment must be before %s", deadline.Format("2006-01-02"))
+	}
+
+	plan := []entity.BookingInstallment{
+		newInstallment(1, entity.KindDownPayment, downPayment, firstDue),
+	}
+
+	// Equal monthly parts; the last one absorbs the remainder
+	remaining, err := booking.TotalPrice.Sub(downPayment)
+	if err != nil {
+		return nil, err
+	}
+	for i, part := range remaining.Split(req.Installments) {
+		plan = append(plan, newInstallment(i+2, entity.KindInstallment, part, firstDue.AddDate(0, i+1, 0)))
+	}
+
+	return plan, nil
+}
+
+func (s *packageService) GetBalance(ctx context.Context, bookingID, userID, role string) (*entity.BookingBalance, error) {
+	booking, err := s.repo.FindBookingByID(ctx, bookingID)
+	if err != nil {
+		return nil, ErrBookingNotFound
+	}
+	if !canAccessBooking(booking, userID, role) {
+		return nil, errors.New("unauthorized")
+	}
+
+	installments, err := s.repo.GetInstallments(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+
+	return computeBalance(booking, installments), nil
+}
+
+// UploadInstallmentProof attaches a transfer proof to one installment.
+// A proof may be replaced until an admin has verified it.
+func (s *packageService) UploadInstallmentProof(ctx context.Context, bookingID, installmentID, userID, imageURL string) error {
+	return s.repo.WithTx(ctx, func(tx repository.PackageRepository) error {
+		booking, err := tx.FindBookingByID(ctx, bookingID)
+		if err != nil {
+			return ErrBookingNotFound
+		}
+		if booking.UserID.String() != userID {
+			return errors.New("unauthorized")
+		}
+		if booking.Status != entity.BookingPending && booking.Status != entity.BookingPaid {
+			return fmt.Errorf("booking is %s, payments are closed", booking.Status)
+		}
+
+		installment, err := tx.FindInstallmentByID(ctx, installmentID)
+		if err != nil || installment.BookingID != booking.ID {
+			return ErrInstallmentNotFound
+		}
+		if installment.Status == entity.InstallmentPaid {
+			return errors.New("installment is already paid")
+		}
+
+		installment.ProofImage = imageURL
+		installment.Status = entity.InstallmentSubmitted
+		installment.UpdatedAt = time.Now()
+
+		return tx.UpdateInstallment(ctx, installment)
+	})
+}
+
+// VerifyInstallment marks a submitted installment as paid. The first verified
+// payment moves the booking to PAID (the seat is no longer at risk of expiry);
+// once nothing is outstanding the booking is CONFIRMED, provided the
+// passenger manifest is complete. Otherwise it stays PAID for admin approval.
+func (s *packageService) VerifyInstallment(ctx context.Context, installmentID, adminID string) (*entity.BookingBalance, error) {
+	adminUUID, err := uuid.Parse(adminID)
+	if err != nil {
+		return nil, errors.New("invalid admin ID")
+	}
+	balance, after, err := s.settleInstallment(ctx, s.repo, installmentID, &adminUUID)
+	if err != nil {
+		return nil, err
+	}
+	after()
+	return balance, nil
+}
+
+// SettleInstallmentPayment is called when a payment gateway reports the
+// installment as paid. No proof is needed; an installment that is already
+// paid is refused with ErrAlreadyPaid.
+func (s *packageService) SettleInstallmentPayment(ctx context.Context, tx repository.PackageRepository, installmentID string) (func(), error) {
+	_, after, err := s.settleInstallment(ctx, tx, installmentID, nil)
+	return after, err
+}
+
+// RefundInstallmentPayment marks one gateway-paid installment REFUNDED.
+// A booking whose money goes back cannot keep its seats: the first refund
+// cancels it, and it becomes REFUNDED once none of its installments is
+// paid any more. The other installments are refunded one by one.
+func (s *packageService) RefundInstallmentPayment(ctx context.Context, tx repository.PackageRepository, installmentID string) (func(), error) {
+	// Lock order is booking -> installment everywhere, so look up the booking first.
+	ref, err := s.repo.FindInstallmentByID(ctx, installmentID)
+	if err != nil {
+		return nil, ErrInstallmentNotFound
+	}
+
+	var booking *entity.Booking
+	var from entity.BookingStatus
+	err = tx.WithTx(ctx, func(tx repository.PackageRepository) error {
+		var err error
+		booking, err = tx.FindBookingByID(ctx, ref.BookingID.String())
+		if err != nil {
+			return ErrBookingNotFound
+		}
+		from = booking.Status
+
+		installment, err := tx.FindInstallmentByID(ctx, installmentID)
+		if err != nil {
+			return ErrInstallmentNotFound
+		}
+		if installment.Status != entity.InstallmentPaid {
+			return fmt.Errorf("installment is %s, only PAID installments can be refunded", installment.Status)
+		}
+		installment.Status = entity.InstallmentRefunded
+		installment.UpdatedAt = time.Now()
+		if err := tx.UpdateInstallment(ctx, installment); err != nil {
+			return err
+		}
+
+		if booking.Status.HoldsSeats() {
+			if err := s.applyTransition(ctx, tx, booking, entity.BookingCancelled); err != nil {
+				return err
+			}
+		}
+
+		installments, err := tx.GetInstallments(ctx, booking.ID.String())
+		if err != nil {
+			return err
+		}
+		for _, inst := range installments {
+			if inst.Status == entity.InstallmentPaid {
+				return nil
+			}
+		}
+		if booking.Status == entity.BookingCancelled {
+			return s.applyTransition(ctx, tx, booking, entity.BookingRefunded)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return func() { s.afterReleased(ctx, booking, from) }, nil
+}
+
+// settleInstallment marks one installment PAID and moves the booking along.
+// verifiedBy is the admin for manual proofs, nil for gateway payments.
+// Runs in a transaction of repo (a savepoint when repo is already inside
+// one); the returned func issues the receipt and enrolls a confirmed
+// booking, and must only be called once everything has committed.
+func (s *packageService) settleInstallment(ctx context.Context, repo repository.PackageRepository, installmentID string, verifiedBy *uuid.UUID) (*entity.BookingBalance, func(), error) {
+	// Lock order is booking -> installment everywhere, so look up the booking first.
+	ref, err := s.repo.FindInstallmentByID(ctx, installmentID)
+	if err != nil {
+		return nil, nil, ErrInstallmentNotFound
+	}
+
+	var balance *entity.BookingBalance
+	var confirmed *entity.Booking
+	settled := false
+
+	err = repo.WithTx(ctx, func(tx repository.PackageRepository) error {
+		booking, err := tx.FindBookingByID(ctx, ref.BookingID.String())
+		if err != nil {
+			return ErrBookingNotFound
+		}
+
+		installment, err := tx.FindInstallmentByID(ctx, installmentID)
+		if err != nil {
+			return ErrInstallmentNotFound
+		}
+
+		// Redelivered callbacks never get here (the transaction is already
+		// PAID), so a paid installment was paid by another charge or proof.
+		if verifiedBy == nil && installment.Status == entity.InstallmentPaid {
+			return ErrAlreadyPaid
+		}
+		if verifiedBy != nil && installment.Status != entity.InstallmentSubmitted {
+			return fmt.Errorf("installment is %s, only SUBMITTED proofs can be verified", installment.Status)
+		}
+		if installment.Status == entity.InstallmentPaid {
+			return errors.New("installment is already paid")
+		}
+		if booking.Status != entity.BookingPending && booking.Status != entity.BookingPaid {
+			return fmt.Errorf("booking is %s, payments are closed", booking.Status)
+		}
+
+		now := time.Now()
+		installment.Status = entity.InstallmentPaid
+		installment.PaidAt = &now
+		installment.VerifiedBy = verifiedBy
+		installment.UpdatedAt = now
+		if err := tx.UpdateInstallment(ctx, installment); err != nil {
+			return err
+		}
+		settled = true
+
+		if booking.Status == entity.BookingPending {
+			if err := s.applyTransition(ctx, tx, booking, entity.BookingPaid); err != nil {
+				return err
+			}
+		}
+
+		installments, err := tx.GetInstallments(ctx, booking.ID.String())
+		if err != nil {
+			return err
+		}
+		balance = computeBalance(booking, installments)
+
+		if !balance.Outstanding.IsPositive() && booking.Status == entity.BookingPaid {
+			passengers, err := tx.GetPassengers(ctx, booking.ID.String())
+			if err != nil {
+				return err
+			}
+			if len(passengers) == booking.PaxCount {
+				if err := s.applyTransition(ctx, tx, booking, entity.BookingConfirmed); err != nil {
+					return err
+				}
+				balance.Status = booking.Status
+				confirmed = booking
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return balance, func() {
+		if settled {
+			issueDocument(ctx, s.docs.IssueInstallmentReceipt, installmentID)
+		}
+		if confirmed != nil {
+			s.afterConfirmed(ctx, confirmed)
+		}
+	}, nil
+}
+
+func computeBalance(booking *entity.Booking, installments []entity.BookingInstallment) *entity.BookingBalance {
+	balance := &entity.BookingBalance{
+		BookingID:    booking.ID,
+		Status:       booking.Status,
+		TotalPrice:   booking.TotalPrice,
+		Paid:         money.Zero(booking.TotalPrice.Currency),
+		Installments: installments,
+	}
+
+	// Installments are always created in the booking currency
+	for i, inst := range installments {
+		if inst.Status == entity.InstallmentPaid {
+			balance.Paid.Amount += inst.Amount.Amount
+			continue
+		}
+		if balance.NextDue == nil {
+			balance.NextDue = &installments[i]
+		}
+	}
+
+	balance.Outstanding = money.New(booking.TotalPrice.Amount-balance.Paid.Amount, booking.TotalPrice.Currency)
+	return balance
+}
+
+func (s *packageService) ListBookings(ctx context.Context, filter entity.BookingFilter) ([]entity.Booking, int64, error) {
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	return s.repo.ListBookings(ctx, filter)
+}
+
+// ApproveBooking confirms a fully paid booking with a complete manifest.
+// A booking on a payment plan must have every installment paid.
+// A PENDING booking is walked through PAID first, so the state machine is
+// never bypassed.
+func (s *packageService) ApproveBooking(ctx context.Context, bookingID string) (*entity.Booking, error) {
+	var result *entity.Booking
+
+	err := s.repo.WithTx(ctx, func(tx repository.PackageRepository) error {
+		booking, err := tx.FindBookingByID(ctx, bookingID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrBookingNotFound
+			}
+			return err
+		}
+
+		// Visa processing needs the full manifest before a seat is final.
+		passengers, err := tx.GetPassengers(ctx, bookingID)
+		if err != nil {
+			return err
+		}
+		if len(passengers) != booking.PaxCount {
+			return fmt.Errorf("passenger manifest incomplete: %d of %d passengers filled", len(passengers), booking.PaxCount)
+		}
+
+		installments, err := tx.GetInstallments(ctx, bookingID)
+		if err != nil {
+			return err
+		}
+		// Without a payment plan the admin approving is the payment check
+		// (full transfer verified by hand, the original flow).
+		if len(installments) > 0 {
+			if balance := computeBalance(booking, installments); balance.Outstanding.IsPositive() {
+				return fmt.Errorf("booking still has an outstanding balance of %s", balance.Outstanding)
+			}
+		}
+
+		if booking.Status == entity.BookingPending {
+			if err := s.applyTransition(ctx, tx, booking, entity.BookingPaid); err != nil {
+				return err
+			}
+		}
+		if err := s.applyTransition(ctx, tx, booking, entity.BookingConfirmed); err != nil {
+			return err
+		}
+
+		result = booking
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.afterConfirmed(ctx, result)
+	return result, nil
+}
+
+// afterConfirmed runs side effects of a committed confirmation. They are
+// best-effort: a failure here must not undo the confirmation itself.
+func (s *packageService) afterConfirmed(ctx context.Context, booking *entity.Booking) {
+	if err := s.groups.EnrollPackageMember(ctx, booking.PackageID.String(), booking.UserID.String()); err != nil {
+		log.Printf("Failed to enroll booking %s into package group: %v", booking.ID, err)
+	}
+	if booking.AgentID != nil {
+		if err := s.agents.RecordCommissions(ctx, booking.ID.String()); err != nil {
+			log.Printf("Failed to record commissions for booking %s: %v", booking.ID, err)
+		}
+	}
+}
+
+func (s *packageService) AdminCancelBooking(ctx context.Context, bookingID, reason string) (*entity.Booking, error) {
+	var result *entity.Booking
+	var from entity.BookingStatus
+
+	err := s.repo.WithTx(ctx, func(tx repository.PackageRepository) error {
+		booking, err := tx.FindBookingByID(ctx, bookingID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrBookingNotFound
+			}
+			return err
+		}
+
+		if reason != "" {
+			booking.Notes = strings.TrimSpace(booking.Notes + "\n[Cancelled by admin] " + reason)
+		}
+		from = booking.Status
+		if err := s.applyTransition(ctx, tx, booking, entity.BookingCancelled); err != nil {
+			return err
+		}
+
+		result = booking
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.afterReleased(ctx, result, from)
+	return result, nil
+}
+
+func (s *packageService) GetManifest(ctx context.Context, packageID string) ([]entity.Booking, error) {
+	if _, err := s.repo.FindPackageByID(ctx, packageID); err != nil {
+		return nil, errors.New("package not found")
+	}
+	return s.repo.GetManifest(ctx, packageID)
+}
+
+// applyTransition must be called inside WithTx with a locked booking row.
+func (s *packageService) applyTransition(ctx context.Context, tx repository.PackageRepository, booking *entity.Booking, next entity.BookingStatus) error {
+	if !booking.Status.CanTransitionTo(next) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidBookingTransition, booking.Status, next)
+	}
+
+	// Seats go back only once: when leaving the last seat-holding status.
+	if booking.Status.HoldsSeats() && !next.HoldsSeats() {
+		if err := tx.IncreaseQuota(ctx, booking.PackageID.String(), booking.PaxCount); err != nil {
+			return fmt.Errorf("failed to release seats: %v", err)
+		}
+	}
+
+	now := time.Now()
+	if next == entity.BookingPaid && booking.PaidAt == nil {
+		booking.PaidAt = &now
+	}
+	booking.Status = next
+	booking.UpdatedAt = now
+
+	return tx.UpdateBooking(ctx, booking)
+}
+
+// --- WAITLIST ---
+
+func (s *packageService) JoinWaitlist(ctx context.Context, userID string, req entity.JoinWaitlistDTO) (*entity.WaitlistEntry, error) {
+	pkg, err := s.repo.FindPackageByID(ctx, req.PackageID)
+	if err != nil || !pkg.IsActive {
+		return nil, ErrPackageNotFound
+	}
+	now := time.Now()
+	if !pkg.DepartureDate.After(now) {
+		return nil, errors.New("package has already departed")
+	}
+	if _, err := bookingPrice(pkg, req.RoomType, req.PaxCount); err != nil {
+		return nil, err
+	}
+	if req.PaxCount > pkg.Quota {
+		return nil, fmt.Errorf("pax_count exceeds the package quota of %d", pkg.Quota)
+	}
+	// Free seats held back for the people waiting do not count
+	waiting, err := s.repo.CountWaiting(ctx, req.PackageID)
+	if err != nil {
+		return nil, err
+	}
+	if waiting == 0 && pkg.Available >= req.PaxCount {
+		return nil, errors.New("seats are still available, book the package directly")
+	}
+
+	existing, err := s.repo.FindActiveWaitlistEntry(ctx, req.PackageID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrAlreadyOnWaitlist
+	}
+
+	entry := &entity.WaitlistEntry{
+		ID:        uuid.New(),
+		PackageID: pkg.ID,
+		UserID:    uuid.MustParse(userID),
+		RoomType:  req.RoomType,
+		PaxCount:  req.PaxCount,
+		Status:    entity.WaitlistWaiting,
+		JoinedAt:  now,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.repo.CreateWaitlistEntry(ctx, entry); err != nil {
+		// Lost a race with a concurrent join (idx_waitlist_active)
+		if errors.Is(err, gorm.ErrDuplicatedKey) || strings.Contains(err.Error(), "duplicate key") {
+			return nil, ErrAlreadyOnWaitlist
+		}
+		return nil, err
+	}
+	if err := s.setWaitlistPosition(ctx, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// LeaveWaitlist takes the user off the waitlist. Seats held by an open
+// offer go to the next in line.
+func (s *packageService) LeaveWaitlist(ctx context.Context, entryID, userID string) (*entity.WaitlistEntry, error) {
+	var result *entity.WaitlistEntry
+	var offered []uuid.UUID
+
+	err := s.repo.WithTx(ctx, func(tx repository.PackageRepository) error {
+		entry, err := tx.FindWaitlistEntryByID(ctx, entryID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrWaitlistEntryNotFound
+			}
+			return err
+		}
+		if entry.UserID.String() != userID {
+			return errors.New("unauthorized")
+		}
+		if entry.Status != entity.WaitlistWaiting && entry.Status != entity.WaitlistOffered {
+			return fmt.Errorf("waitlist entry is already %s", entry.Status)
+		}
+
+		held := entry.Status == entity.WaitlistOffered
+		entry.Status = entity.WaitlistCancelled
+		entry.UpdatedAt = time.Now()
+		if err := tx.UpdateWaitlistEntry(ctx, entry); err != nil {
+			return err
+		}
+		result = entry
+
+		if !held {
+			return nil
+		}
+		if err := tx.IncreaseQuota(ctx, entry.PackageID.String(), entry.PaxCount); err != nil {
+			return fmt.Errorf("failed to release seats: %v", err)
+		}
+		offered, err = s.offerSeats(ctx, tx, entry.PackageID.String())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifyOffers(ctx, offered)
+	return result, nil
+}
+
+// ClaimWaitlistOffer books the seats held by an open offer.
+func (s *packageService) ClaimWaitlistOffer(ctx context.Context, entryID, userID string, req entity.ClaimWaitlistDTO) (*entity.Booking, error) {
+	entry, err := s.repo.FindWaitlistEntryByID(ctx, entryID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWaitlistEntryNotFound
+		}
+		return nil, err
+	}
+	if entry.UserID.String() != userID {
+		return nil, errors.New("unauthorized")
+	}
+	if entry.Status != entity.WaitlistOffered {
+		return nil, fmt.Errorf("%w (entry is %s)", ErrNoWaitlistOffer, entry.Status)
+	}
+
+	return s.placeBooking(ctx, userID, entity.BookPackageDTO{
+		PackageID:    entry.PackageID.String(),
+		RoomType:     entry.RoomType,
+		PaxCount:     entry.PaxCount,
+		PromoCode:    req.PromoCode,
+		ReferralCode: req.ReferralCode,
+	}, entry.ID.String())
+}
+
+// claimOffer marks the offer as claimed by the booking, re-checked under
+// the row lock so an offer running out meanwhile cannot be claimed.
+func claimOffer(ctx context.Context, tx repository.PackageRepository, entryID string, booking *entity.Booking) error {
+	entry, err := tx.FindWaitlistEntryByID(ctx, entryID)
+	if err != nil {
+		return err
+	}
+	if entry.Status != entity.WaitlistOffered || entry.OfferExpiresAt == nil || !entry.OfferExpiresAt.After(booking.CreatedAt) {
+		return ErrNoWaitlistOffer
+	}
+
+	entry.Status = entity.WaitlistClaimed
+	entry.ClaimedAt = &booking.CreatedAt
+	entry.BookingID = &booking.ID
+	entry.UpdatedAt = booking.CreatedAt
+	return tx.UpdateWaitlistEntry(ctx, entry)
+}
+
+func (s *packageService) GetMyWaitlist(ctx context.Context, userID string) ([]entity.WaitlistEntry, error) {
+	entries, err := s.repo.GetUserWaitlist(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		if err := s.setWaitlistPosition(ctx, &entries[i]); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+func (s *packageService) GetWaitlist(ctx context.Context, packageID string) ([]entity.WaitlistEntry, error) {
+	if _, err := s.repo.FindPackageByID(ctx, packageID); err != nil {
+		return nil, ErrPackageNotFound
+	}
+	entries, err := s.repo.GetWaitlist(ctx, packageID)
+	if err != nil {
+		return nil, err
+	}
+	position := 0
+	for i := range entries {
+		if entries[i].Status == entity.WaitlistWaiting {
+			position++
+			entries[i].Position = position
+		}
+	}
+	return entries, nil
+}
+
+func (s *packageService) setWaitlistPosition(ctx context.Context, entry *entity.WaitlistEntry) error {
+	if entry.Status != entity.WaitlistWaiting {
+		return nil
+	}
+	ahead, err := s.repo.CountWaitingAhead(ctx, entry)
+	if err != nil {
+		return err
+	}
+	entry.Position = int(ahead) + 1
+	return nil
+}
+
+// ExpireWaitlistOffers passes the seats of unclaimed offers on to the next
+// in line, then offers any free seats that are still unoffered (e.g. when
+// an offer hook failed after a cancellation).
+func (s *packageService) ExpireWaitlistOffers(ctx context.Context) (int, error) {
+	now := time.Now()
+	ids, err := s.repo.FindExpiredOfferIDs(ctx, now, expireBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	var lastErr error
+	for _, id := range ids {
+		var offered []uuid.UUID
+		released := false
+		err := s.repo.WithTx(ctx, func(tx repository.PackageRepository) error {
+			entry, err := tx.FindWaitlistEntryByID(ctx, id)
+			if err != nil {
+				return err
+			}
+
+			// Re-check under the row lock: the offer may have been claimed meanwhile.
+			if entry.Status != entity.WaitlistOffered || entry.OfferExpiresAt == nil || entry.OfferExpiresAt.After(now) {
+				return nil
+			}
+
+			entry.Status = entity.WaitlistExpired
+			entry.UpdatedAt = now
+			if err := tx.UpdateWaitlistEntry(ctx, entry); err != nil {
+				return err
+			}
+			if err := tx.IncreaseQuota(ctx, entry.PackageID.String(), entry.PaxCount); err != nil {
+				return fmt.Errorf("failed to release seats: %v", err)
+			}
+			released = true
+			offered, err = s.offerSeats(ctx, tx, entry.PackageID.String())
+			return err
+		})
+		if err != nil {
+			lastErr = fmt.Errorf("failed to expire waitlist offer %s: %v", id, err)
+			continue
+		}
+		if released {
+			expired++ // Counted once committed
+		}
+		s.notifyOffers(ctx, offered)
+	}
+
+	packageIDs, err := s.repo.FindPackagesToOffer(ctx)
+	if err != nil {
+		return expired, err
+	}
+	for _, id := range packageIDs {
+		s.offerWaitlist(ctx, id)
+	}
+
+	return expired, lastErr
+}
+
+// offerWaitlist offers a package's free seats in its own transaction and
+// notifies the people offered. Best-effort: the sweeper catches up.
+func (s *packageService) offerWaitlist(ctx context.Context, packageID string) {
+	var offered []uuid.UUID
+	err := s.repo.WithTx(ctx, func(tx repository.PackageRepository) error {
+		var err error
+		offered, err = s.offerSeats(ctx, tx, packageID)
+		return err
+	})
+	if err != nil {
+		log.Printf("Failed to offer seats of package %s to the waitlist: %v", packageID, err)
+		return
+	}
+	s.notifyOffers(ctx, offered)
+}
+
+// offerSeats holds free seats for waiting entries in strict join order: it
+// stops at the first party that does not fit the free seats, so nobody
+// behind it is served first. Direct bookings are refused while anyone
+// waits, so the free seats build up until the head party fits.
+func (s *packageService) offerSeats(ctx context.Context, tx repository.PackageRepository, packageID string) ([]uuid.UUID, error) {
+	pkg, err := tx.FindPackageByID(ctx, packageID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if pkg.Available <= 0 || !pkg.DepartureDate.After(now) {
+		return nil, nil
+	}
+
+	entries, err := tx.GetWaitingEntries(ctx, packageID)
+	if err != nil {
+		return nil, err
+	}
+
+	claimHours := pkg.WaitlistClaimHours
+	if claimHours <= 0 {
+		claimHours = DefaultWaitlistClaimHours
+	}
+	expiresAt := now.Add(time.Duration(claimHours) * time.Hour)
+
+	available := pkg.Available
+	var offered []uuid.UUID
+	for i := range entries {
+		entry := &entries[i]
+		if entry.PaxCount > available {
+			break
+		}
+		if err := tx.DecreaseQuota(ctx, packageID, entry.PaxCount); err != nil {
+			break // Seats were booked meanwhile
+		}
+		available -= entry.PaxCount
+
+		entry.Status = entity.WaitlistOffered
+		entry.OfferedAt = &now
+		entry.OfferExpiresAt = &expiresAt
+		entry.UpdatedAt = now
+		if err := tx.UpdateWaitlistEntry(ctx, entry); err != nil {
+			return nil, err
+		}
+		offered = append(offered, entry.ID)
+		if available == 0 {
+			break
+		}
+	}
+	return offered, nil
+}
+
+// notifyOffers tells the people offered that seats are held for them
+// (best-effort, call it after the transaction committed).
+func (s *packageService) notifyOffers(ctx context.Context, ids []uuid.UUID) {
+	if len(ids) == 0 || s.fcm == nil {
+		return
+	}
+	entries, err := s.repo.FindWaitlistEntriesByIDs(ctx, ids)
+	if err != nil {
+		log.Printf("Failed to load waitlist offers for notification: %v", err)
+		return
+	}
+	for _, entry := range entries {
+		if entry.User == nil || entry.User.FCMToken == "" || entry.Package == nil || entry.OfferExpiresAt == nil {
+			continue
+		}
+		go s.fcm.SendPush([]string{entry.User.FCMToken}, "Seats available",
+			fmt.Sprintf("%d seat(s) on %s are held for you until %s. Claim them before they go to the next person.",
+				entry.PaxCount, entry.Package.Name, entry.OfferExpiresAt.Format("02 Jan 15:04 MST")),
+			map[string]string{"type": "WAITLIST_OFFER", "waitlist_id": entry.ID.String(), "package_id": entry.PackageID.String()},
+		)
+	}
 }
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"umrah-backend/internal/entity"
+	"umrah-backend/pkg/money"
+)
+
+// A tier sent without a currency keeps the package currency: on a SAR
+// package, {"amount": ...} is a SAR amount, not an IDR one.
+func TestUpdatePackagePrices(t *testing.T) {
+	sar := &entity.TravelPackage{
+		PriceQuad:   money.New(900000, money.SAR),
+		PriceTriple: money.New(950000, money.SAR),
+		PriceDouble: money.New(1000000, money.SAR),
+	}
+
+	tests := []struct {
+		name    string
+		body    string
+		want    [3]money.Money
+		wantErr error
+	}{
+		{"amount only keeps the currency", `{"price_quad":{"amount":920000}}`,
+			[3]money.Money{money.New(920000, money.SAR), money.New(950000, money.SAR), money.New(1000000, money.SAR)}, nil},
+		{"same currency sent", `{"price_triple":{"amount":960000,"currency":"sar"}}`,
+			[3]money.Money{money.New(900000, money.SAR), money.New(960000, money.SAR), money.New(1000000, money.SAR)}, nil},
+		{"nothing sent", `{"name":"Umrah Syawal"}`,
+			[3]money.Money{money.New(900000, money.SAR), money.New(950000, money.SAR), money.New(1000000, money.SAR)}, nil},
+		{"one tier in another currency", `{"price_double":{"amount":3600000000,"currency":"IDR"}}`,
+			[3]money.Money{}, money.ErrCurrencyMismatch},
+		{"every tier moved to IDR", `{"price_quad":{"amount":3250000000,"currency":"IDR"},"price_triple":{"amount":3400000000,"currency":"IDR"},"price_double":{"amount":3600000000,"currency":"IDR"}}`,
+			[3]money.Money{money.New(3250000000, money.IDR), money.New(3400000000, money.IDR), money.New(3600000000, money.IDR)}, nil},
+		{"unknown currency", `{"price_quad":{"amount":100,"currency":"EUR"}}`,
+			[3]money.Money{}, money.ErrInvalidCurrency},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req entity.UpdatePackageDTO
+			if err := json.Unmarshal([]byte(tt.body), &req); err != nil {
+				t.Fatal(err)
+			}
+
+			prices := *sar
+			prices.PriceQuad = updatedPrice(prices.PriceQuad, req.PriceQuad)
+			prices.PriceTriple = updatedPrice(prices.PriceTriple, req.PriceTriple)
+			prices.PriceDouble = updatedPrice(prices.PriceDouble, req.PriceDouble)
+			err := validatePackagePrices(&prices)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := [3]money.Money{prices.PriceQuad, prices.PriceTriple, prices.PriceDouble}; got != tt.want {
+				t.Errorf("prices = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
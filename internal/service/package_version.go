@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+	"umrah-backend/internal/entity"
+	"umrah-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// Reasons a package version is recorded
+const (
+	versionCreated        = "created"
+	versionCloned         = "cloned"
+	versionUpdated        = "package updated"
+	versionHotelsUpdated  = "hotels updated"
+	versionFlightsUpdated = "flights updated"
+	versionAtBooking      = "detected at booking" // Terms changed without going through the API
+)
+
+var (
+	ErrPackageVersionNotFound = errors.New("package version not found")
+	ErrPackageTermsChanged    = errors.New("package terms changed while booking, please review the package and book again")
+)
+
+// GetPackageVersions returns the version history of a package, oldest
+// first, each with what changed since the version before it.
+func (s *packageService) GetPackageVersions(ctx context.Context, packageID string) ([]entity.PackageVersion, error) {
+	if _, err := s.repo.FindPackageByID(ctx, packageID); err != nil {
+		return nil, ErrPackageNotFound
+	}
+	versions, err := s.repo.GetPackageVersions(ctx, packageID)
+	if err != nil {
+		return nil, err
+	}
+	for i := 1; i < len(versions); i++ {
+		versions[i].Changes = versionChanges(versions[i-1].PackageTerms, versions[i].PackageTerms)
+	}
+	return versions, nil
+}
+
+// GetBookingTerms returns the package version a booking was sold under.
+func (s *packageService) GetBookingTerms(ctx context.Context, bookingID, userID, role string) (*entity.PackageVersion, error) {
+	booking, err := s.repo.FindBookingByID(ctx, bookingID)
+	if err != nil {
+		return nil, ErrBookingNotFound
+	}
+	if !canAccessBooking(booking, userID, role) {
+		return nil, errors.New("unauthorized")
+	}
+	if booking.PackageVersionID == nil {
+		return nil, fmt.Errorf("%w: the booking predates package versioning", ErrPackageVersionNotFound)
+	}
+	version, err := s.repo.FindPackageVersionByID(ctx, booking.PackageVersionID.String())
+	if err != nil {
+		return nil, ErrPackageVersionNotFound
+	}
+	return version, nil
+}
+
+// recordVersion snapshots the current terms of a package as a new version,
+// unless they are the same as the latest version, which is then returned.
+// Must run inside a transaction: the package row stays locked until commit.
+func recordVersion(ctx context.Context, tx repository.PackageRepository, packageID, reason string) (*entity.PackageVersion, error) {
+	pkg, err := tx.LockPackageTerms(ctx, packageID)
+	if err != nil {
+		return nil, err
+	}
+	terms := packageTerms(pkg)
+	hash, err := termsHash(terms)
+	if err != nil {
+		return nil, err
+	}
+
+	latest, err := tx.FindLatestPackageVersion(ctx, packageID)
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil && latest.TermsHash == hash {
+		return latest, nil
+	}
+
+	version := &entity.PackageVersion{
+		ID:           uuid.New(),
+		PackageID:    pkg.ID,
+		Version:      1,
+		PackageTerms: terms,
+		TermsHash:    hash,
+		Reason:       reason,
+		CreatedAt:    time.Now(),
+	}
+	if latest != nil {
+		version.Version = latest.Version + 1
+	}
+	if err := tx.CreatePackageVersion(ctx, version); err != nil {
+		return nil, err
+	}
+	return version, nil
+}
+
+// packageTerms copies the sold terms of a package. Times are normalized
+// to UTC so that the hash does not depend on the session time zone.
+func packageTerms(pkg *entity.TravelPackage) entity.PackageTerms {
+	terms := entity.PackageTerms{
+		Name:          pkg.Name,
+		Category:      pkg.Category,
+		SubCategory:   pkg.SubCategory,
+		HotelMakkah:   pkg.HotelMakkah,
+		RatingMakkah:  pkg.RatingMakkah,
+		HotelMadinah:  pkg.HotelMadinah,
+		RatingMadinah: pkg.RatingMadinah,
+		AirlineName:   pkg.AirlineName,
+		AirlineClass:  pkg.AirlineClass,
+		PriceQuad:     pkg.PriceQuad,
+		PriceTriple:   pkg.PriceTriple,
+		PriceDouble:   pkg.PriceDouble,
+		DurationDays:  pkg.DurationDays,
+		DepartureDate: pkg.DepartureDate.UTC(),
+		ReturnDate:    pkg.ReturnDate.UTC(),
+		DepartureCity: pkg.DepartureCity,
+		Hotels:        entity.VersionHotels{},
+		Flights:       entity.VersionFlights{},
+	}
+	for _, h := range pkg.Hotels {
+		terms.Hotels = append(terms.Hotels, entity.VersionHotel{
+			City:     h.City,
+			Name:     h.Name,
+			Rating:   h.Rating,
+			CheckIn:  h.CheckIn.UTC(),
+			CheckOut: h.CheckOut.UTC(),
+			Nights:   h.Nights,
+		})
+	}
+	for _, f := range pkg.Flights {
+		terms.Flights = append(terms.Flights, entity.VersionFlight{
+			Direction:    f.Direction,
+			Airline:      f.Airline,
+			FlightNumber: f.FlightNumber,
+			FromAirport:  f.FromAirport,
+			ToAirport:    f.ToAirport,
+			DepartAt:     f.DepartAt.UTC(),
+			ArriveAt:     f.ArriveAt.UTC(),
+		})
+	}
+	return terms
+}
+
+func termsHash(terms entity.PackageTerms) (string, error) {
+	b, err := json.Marshal(terms)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// pricedUnder reports whether a booking priced from pkg matches the
+// version's prices and dates.
+func pricedUnder(pkg *entity.TravelPackage, version *entity.PackageVersion) bool {
+	return pkg.PriceQuad == version.PriceQuad &&
+		pkg.PriceTriple == version.PriceTriple &&
+		pkg.PriceDouble == version.PriceDouble &&
+		pkg.DepartureDate.Equal(version.DepartureDate) &&
+		pkg.ReturnDate.Equal(version.ReturnDate)
+}
+
+// versionChanges lists what changed between two versions, e.g.
+// "price_quad: IDR 32.500.000 -> IDR 34.000.000".
+func versionChanges(prev, cur entity.PackageTerms) []string {
+	var changes []string
+	diff := func(field, from, to string) {
+		if from != to {
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s", field, from, to))
+		}
+	}
+	date := func(t time.Time) string { return t.Format("2006-01-02") }
+
+	diff("name", prev.Name, cur.Name)
+	diff("category", string(prev.Category), string(cur.Category))
+	diff("sub_category", prev.SubCategory, cur.SubCategory)
+	diff("hotel_makkah", prev.HotelMakkah, cur.HotelMakkah)
+	diff("rating_makkah", strconv.Itoa(prev.RatingMakkah), strconv.Itoa(cur.RatingMakkah))
+	diff("hotel_madinah", prev.HotelMadinah, cur.HotelMadinah)
+	diff("rating_madinah", strconv.Itoa(prev.RatingMadinah), strconv.Itoa(cur.RatingMadinah))
+	diff("airline_name", prev.AirlineName, cur.AirlineName)
+	diff("airline_class", string(prev.AirlineClass), string(cur.AirlineClass))
+	diff("price_quad", prev.PriceQuad.String(), cur.PriceQuad.String())
+	diff("price_triple", prev.PriceTriple.String(), cur.PriceTriple.String())
+	diff("price_double", prev.PriceDouble.String(), cur.PriceDouble.String())
+	diff("duration_days", strconv.Itoa(prev.DurationDays), strconv.Itoa(cur.DurationDays))
+	diff("departure_date", date(prev.DepartureDate), date(cur.DepartureDate))
+	diff("return_date", date(prev.ReturnDate), date(cur.ReturnDate))
+	diff("departure_city", prev.DepartureCity, cur.DepartureCity)
+
+	if !sameJSON(prev.Hotels, cur.Hotels) {
+		changes = append(changes, "hotels changed")
+	}
+	if !sameJSON(prev.Flights, cur.Flights) {
+		changes = append(changes, "flights changed")
+	}
+	return changes
+}
+
+func sameJSON(a, b interface{}) bool {
+	x, errA := json.Marshal(a)
+	y, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(x) == string(y)
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+	"umrah-backend/internal/entity"
+	"umrah-backend/internal/repository"
+	"umrah-backend/pkg/money"
+
+	"github.com/google/uuid"
+)
+
+func testPackage() *entity.TravelPackage {
+	departure := time.Date(2026, 11, 1, 10, 30, 0, 0, time.UTC)
+	return &entity.TravelPackage{
+		ID:            uuid.MustParse("5a6b7c8d-9e0f-4a1b-8c2d-3e4f5a6b7c8d"),
+		Name:          "Umrah Reguler November",
+		Category:      entity.CatUmrahReguler,
+		HotelMakkah:   "Hilton Suites",
+		RatingMakkah:  5,
+		HotelMadinah:  "Anwar Al Madinah",
+		RatingMadinah: 4,
+		AirlineName:   "Saudia",
+		AirlineClass:  entity.ClassEconomy,
+		PriceQuad:     money.New(3250000000, money.IDR),
+		PriceTriple:   money.New(3400000000, money.IDR),
+		PriceDouble:   money.New(3600000000, money.IDR),
+		DurationDays:  12,
+		DepartureDate: departure,
+		ReturnDate:    departure.AddDate(0, 0, 11),
+		DepartureCity: "Jakarta",
+		Hotels: []entity.PackageHotel{
+			{City: "Makkah", Name: "Hilton Suites", Rating: 5, CheckIn: departure, CheckOut: departure.AddDate(0, 0, 6), Nights: 6},
+		},
+		Flights: []entity.PackageFlight{
+			{Direction: entity.FlightDeparture, Airline: "Saudia", FlightNumber: "SV817", FromAirport: "CGK", ToAirport: "JED",
+				DepartAt: departure, ArriveAt: departure.Add(9 * time.Hour)},
+		},
+	}
+}
+
+func TestVersionChanges(t *testing.T) {
+	base := packageTerms(testPackage())
+
+	tests := []struct {
+		name   string
+		change func(terms *entity.PackageTerms)
+		want   []string
+	}{
+		{"nothing", func(*entity.PackageTerms) {}, nil},
+		{"price", func(terms *entity.PackageTerms) { terms.PriceQuad = money.New(3400000000, money.IDR) },
+			[]string{"price_quad: IDR 32,500,000.00 -> IDR 34,000,000.00"}},
+		{"price currency", func(terms *entity.PackageTerms) { terms.PriceDouble = money.New(900000, money.SAR) },
+			[]string{"price_double: IDR 36,000,000.00 -> SAR 9,000.00"}},
+		{"departure moves the return too", func(terms *entity.PackageTerms) {
+			terms.DepartureDate = terms.DepartureDate.AddDate(0, 0, 7)
+			terms.ReturnDate = terms.ReturnDate.AddDate(0, 0, 7)
+		}, []string{"departure_date: 2026-11-01 -> 2026-11-08", "return_date: 2026-11-12 -> 2026-11-19"}},
+		{"same day, other time", func(terms *entity.PackageTerms) { terms.DepartureDate = terms.DepartureDate.Add(time.Hour) }, nil},
+		{"hotel rating and name", func(terms *entity.PackageTerms) {
+			terms.HotelMakkah = "Swissotel"
+			terms.RatingMakkah = 4
+		}, []string{"hotel_makkah: Hilton Suites -> Swissotel", "rating_makkah: 5 -> 4"}},
+		{"hotel stays", func(terms *entity.PackageTerms) {
+			terms.Hotels = append(entity.VersionHotels{}, terms.Hotels...)
+			terms.Hotels[0].Nights = 5
+		}, []string{"hotels changed"}},
+		{"flights", func(terms *entity.PackageTerms) { terms.Flights = entity.VersionFlights{} }, []string{"flights changed"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cur := base
+			tt.change(&cur)
+			if got := versionChanges(base, cur); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("versionChanges = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// The booking is priced from the package it read first; the version is
+// recorded under the package lock later. Any price or date change in
+// between means the customer was quoted other terms.
+func TestPricedUnder(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(pkg *entity.TravelPackage)
+		want   bool
+	}{
+		{"unchanged", func(*entity.TravelPackage) {}, true},
+		{"quad price raised", func(pkg *entity.TravelPackage) { pkg.PriceQuad = money.New(3400000000, money.IDR) }, false},
+		{"triple price lowered", func(pkg *entity.TravelPackage) { pkg.PriceTriple = money.New(3300000000, money.IDR) }, false},
+		{"double repriced in another currency", func(pkg *entity.TravelPackage) { pkg.PriceDouble = money.New(3600000000, money.SAR) }, false},
+		{"departure moved", func(pkg *entity.TravelPackage) { pkg.DepartureDate = pkg.DepartureDate.AddDate(0, 0, 1) }, false},
+		{"return moved", func(pkg *entity.TravelPackage) { pkg.ReturnDate = pkg.ReturnDate.AddDate(0, 0, 1) }, false},
+		{"same instant in another zone", func(pkg *entity.TravelPackage) {
+			pkg.DepartureDate = pkg.DepartureDate.In(time.FixedZone("WIB", 7*60*60))
+		}, true},
+		{"hotel renamed only", func(pkg *entity.TravelPackage) { pkg.HotelMakkah = "Swissotel" }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quoted := testPackage()
+			locked := testPackage()
+			tt.change(locked)
+			version := &entity.PackageVersion{PackageTerms: packageTerms(locked)}
+			if got := pricedUnder(quoted, version); got != tt.want {
+				t.Errorf("pricedUnder = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// versionRepo serves one package and stores the versions recorded for it.
+type versionRepo struct {
+	repository.PackageRepository
+	pkg      *entity.TravelPackage
+	versions []entity.PackageVersion
+}
+
+func (r *versionRepo) LockPackageTerms(ctx context.Context, id string) (*entity.TravelPackage, error) {
+	return r.pkg, nil
+}
+
+func (r *versionRepo) FindLatestPackageVersion(ctx context.Context, packageID string) (*entity.PackageVersion, error) {
+	if len(r.versions) == 0 {
+		return nil, nil
+	}
+	latest := r.versions[len(r.versions)-1]
+	return &latest, nil
+}
+
+func (r *versionRepo) CreatePackageVersion(ctx context.Context, version *entity.PackageVersion) error {
+	r.versions = append(r.versions, *version)
+	return nil
+}
+
+func TestRecordVersion(t *testing.T) {
+	ctx := context.Background()
+	repo := &versionRepo{pkg: testPackage()}
+	id := repo.pkg.ID.String()
+
+	first, err := recordVersion(ctx, repo, id, versionCreated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Version != 1 || first.Reason != versionCreated {
+		t.Fatalf("first version = %d %q, want 1 %q", first.Version, first.Reason, versionCreated)
+	}
+
+	// Same terms, read back in another time zone: no new version
+	repo.pkg.DepartureDate = repo.pkg.DepartureDate.In(time.FixedZone("WIB", 7*60*60))
+	again, err := recordVersion(ctx, repo, id, versionAtBooking)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.ID != first.ID || len(repo.versions) != 1 {
+		t.Fatalf("unchanged terms recorded version %d, want version 1 reused", again.Version)
+	}
+
+	// A price change is a new version
+	repo.pkg.PriceQuad = money.New(3400000000, money.IDR)
+	second, err := recordVersion(ctx, repo, id, versionUpdated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Version != 2 || len(repo.versions) != 2 {
+		t.Fatalf("after a price change got version %d of %d, want 2 of 2", second.Version, len(repo.versions))
+	}
+	if second.TermsHash == first.TermsHash {
+		t.Error("a price change kept the same terms hash")
+	}
+	if got := versionChanges(first.PackageTerms, second.PackageTerms); len(got) != 1 {
+		t.Errorf("changes = %q, want only the quad price", got)
+	}
+}
@@ -0,0 +1,459 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	"umrah-backend/internal/entity"
+	"umrah-backend/internal/repository"
+	"umrah-backend/pkg/money"
+	"umrah-backend/pkg/payment"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Gateway charges stay payable this long (capped by the booking hold)
+const chargeValidity = 24 * time.Hour
+
+var (
+	ErrUnknownProvider     = errors.New("unknown payment provider")
+	ErrTransactionNotFound = errors.New("payment transaction not found")
+	ErrAmountMismatch      = errors.New("paid amount does not match the charge")
+	ErrAlreadyPaid         = errors.New("already paid by another payment")
+)
+
+type PaymentService interface {
+	// Open a VA / QRIS charge at the default provider
+	CreateOrderCharge(ctx context.Context, userID, orderID string, req entity.CreateChargeDTO) (*entity.PaymentTransaction, error)
+	CreateInstallmentCharge(ctx context.Context, userID, bookingID, installmentID string, req entity.CreateChargeDTO) (*entity.PaymentTransaction, error)
+	GetTransaction(ctx context.Context, userID, role, transactionID string) (*entity.PaymentTransaction, error)
+
+	// HandleWebhook verifies and applies a provider callback. Safe to call
+	// any number of times with the same callback.
+	HandleWebhook(ctx context.Context, providerName string, headers map[string]string, body []byte) error
+
+	Refund(ctx context.Context, adminID, transactionID, reason string) (*entity.PaymentTransaction, error) // Admin only
+	GetTransactions(ctx context.Context, status string) ([]entity.PaymentTransaction, error)               // Admin only
+}
+
+type paymentService struct {
+	repo         repository.PaymentRepository
+	commerceRepo repository.CommerceRepository
+	pkgRepo      repository.PackageRepository
+	commerceSvc  CommerceService
+	pkgSvc       PackageService
+
+	providers       map[string]payment.Provider
+	defaultProvider string
+}
+
+func NewPaymentService(
+	repo repository.PaymentRepository,
+	commerceRepo repository.CommerceRepository,
+	pkgRepo repository.PackageRepository,
+	commerceSvc CommerceService,
+	pkgSvc PackageService,
+	defaultProvider string,
+	providers ...payment.Provider,
+) PaymentService {
+	registry := make(map[string]payment.Provider)
+	for _, p := range providers {
+		registry[p.Name()] = p
+	}
+	return &paymentService{
+		repo:            repo,
+		commerceRepo:    commerceRepo,
+		pkgRepo:         pkgRepo,
+		commerceSvc:     commerceSvc,
+		pkgSvc:          pkgSvc,
+		providers:       registry,
+		defaultProvider: defaultProvider,
+	}
+}
+
+func (s *paymentService) CreateOrderCharge(ctx context.Context, userID, orderID string, req entity.CreateChargeDTO) (*entity.PaymentTransaction, error) {
+	order, err := s.commerceRepo.FindOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, ErrOrderNotFound
+	}
+	if order.UserID.String() != userID {
+		return nil, errors.New("unauthorized")
+	}
+	if order.Status != entity.OrderPending {
+		return nil, fmt.Errorf("order is %s, nothing to pay", order.Status)
+	}
+
+	// The VA / QR must not outlive the stock reservation
+	expiresAt := time.Now().Add(chargeValidity)
+	if order.ExpiresAt != nil {
+		if !order.ExpiresAt.After(time.Now()) {
+			return nil, ErrOrderExpired
+		}
+		if order.ExpiresAt.Before(expiresAt) {
+			expiresAt = *order.ExpiresAt
+		}
+	}
+
+	var name, phone string
+	if order.User != nil {
+		name, phone = order.User.FullName, order.User.PhoneNumber
+	}
+
+	return s.openCharge(ctx, order.UserID, entity.RefOrder, order.ID, order.Amount, expiresAt, name, phone, req)
+}
+
+func (s *paymentService) CreateInstallmentCharge(ctx context.Context, userID, bookingID, installmentID string, req entity.CreateChargeDTO) (*entity.PaymentTransaction, error) {
+	booking, err := s.pkgRepo.FindBookingByID(ctx, bookingID)
+	if err != nil {
+		return nil, ErrBookingNotFound
+	}
+	if booking.UserID.String() != userID {
+		return nil, errors.New("unauthorized")
+	}
+	if booking.Status != entity.BookingPending && booking.Status != entity.BookingPaid {
+		return nil, fmt.Errorf("booking is %s, payments are closed", booking.Status)
+	}
+
+	installment, err := s.pkgRepo.FindInstallmentByID(ctx, installmentID)
+	if err != nil || installment.BookingID != booking.ID {
+		return nil, ErrInstallmentNotFound
+	}
+	if installment.Status == entity.InstallmentPaid {
+		return nil, errors.New("installment is already paid")
+	}
+
+	// An unpaid PENDING booking loses its seats at ExpiresAt, the charge must not outlive it
+	expiresAt := time.Now().Add(chargeValidity)
+	if booking.Status == entity.BookingPending && booking.ExpiresAt != nil && booking.ExpiresAt.Before(expiresAt) {
+		expiresAt = *booking.ExpiresAt
+	}
+
+	return s.openCharge(ctx, booking.UserID, entity.RefInstallment, installment.ID, installment.Amount, expiresAt, "", "", req)
+}
+
+// openCharge reuses a still-open charge for the same reference, so a
+// double tap on "Pay" does not create two VA numbers. Switching method
+// cancels the open charge first: only one charge is payable at a time.
+func (s *paymentService) openCharge(ctx context.Context, userID uuid.UUID, refType entity.PaymentReference, refID uuid.UUID, amount money.Money, expiresAt time.Time, name, phone string, req entity.CreateChargeDTO) (*entity.PaymentTransaction, error) {
+	now := time.Now()
+	if !expiresAt.After(now) {
+		return nil, errors.New("payment window has already closed")
+	}
+
+	provider, ok := s.providers[s.defaultProvider]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	existing, err := s.repo.FindOpenTransaction(ctx, refType, refID.String(), now)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		if existing.Method == req.Method {
+			return existing, nil
+		}
+		if err := s.cancelCharge(ctx, existing); err != nil {
+			return nil, err
+		}
+	}
+
+	trx := &entity.PaymentTransaction{
+		ID:            uuid.New(),
+		UserID:        userID,
+		Provider:      provider.Name(),
+		ReferenceType: refType,
+		ReferenceID:   refID,
+		Method:        req.Method,
+		Amount:        amount,
+		Status:        entity.PaymentPending,
+		ExpiresAt:     expiresAt,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	charge, err := provider.CreateCharge(ctx, payment.ChargeRequest{
+		ReferenceID:   trx.ID.String(),
+		Amount:        amount.Amount,
+		Currency:      string(amount.Currency),
+		Method:        payment.Method(req.Method),
+		Bank:          req.Bank,
+		CustomerName:  name,
+		CustomerPhone: phone,
+		ExpiresAt:     expiresAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("payment provider error: %v", err)
+	}
+
+	trx.ExternalID = charge.ExternalID
+	trx.Bank = charge.Bank
+	trx.VANumber = charge.VANumber
+	trx.QRString = charge.QRString
+
+	if err := s.repo.CreateTransaction(ctx, trx); err != nil {
+		return nil, err
+	}
+	return trx, nil
+}
+
+// cancelCharge closes an unpaid charge at its provider and marks it
+// CANCELLED. The row stays locked meanwhile, so a callback for it waits and
+// then sees the cancellation; a payment that still lands is flagged by
+// HandleWebhook instead of being applied twice.
+func (s *paymentService) cancelCharge(ctx context.Context, open *entity.PaymentTransaction) error {
+	provider, ok := s.providers[open.Provider]
+	if !ok {
+		return ErrUnknownProvider
+	}
+
+	return s.repo.WithTx(ctx, func(tx repository.PaymentRepository) error {
+		trx, err := tx.FindTransactionByExternalID(ctx, open.Provider, open.ExternalID)
+		if err != nil {
+			return ErrTransactionNotFound
+		}
+		if trx.Status != entity.PaymentPending {
+			return fmt.Errorf("the open charge is now %s, refresh the payment status", trx.Status)
+		}
+
+		if err := provider.CancelCharge(ctx, trx.ExternalID); err != nil {
+			return fmt.Errorf("payment provider error: %v", err)
+		}
+
+		trx.Status = entity.PaymentCancelled
+		trx.UpdatedAt = time.Now()
+		return tx.UpdateTransaction(ctx, trx)
+	})
+}
+
+func (s *paymentService) GetTransaction(ctx context.Context, userID, role, transactionID string) (*entity.PaymentTransaction, error) {
+	trx, err := s.repo.FindTransactionByID(ctx, transactionID)
+	if err != nil {
+		return nil, ErrTransactionNotFound
+	}
+	if trx.UserID.String() != userID && role != entity.RoleAdmin {
+		return nil, errors.New("unauthorized")
+	}
+	return trx, nil
+}
+
+// HandleWebhook verifies a callback, then settles the order/installment,
+// marks the transaction and records the event in one database transaction:
+// a redelivered callback finds either all of it applied or none of it.
+// A callback whose amount does not match the charge is recorded as
+// rejected and never applied. Money that arrives for something already
+// paid or closed is kept as NEEDS_REFUND for an admin to return.
+func (s *paymentService) HandleWebhook(ctx context.Context, providerName string, headers map[string]string, body []byte) error {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return ErrUnknownProvider
+	}
+
+	event, err := provider.ParseWebhook(headers, body)
+	if err != nil {
+		return err
+	}
+
+	seen, err := s.repo.IsEventProcessed(ctx, providerName, event.EventID)
+	if err != nil {
+		return err
+	}
+	if seen {
+		return nil
+	}
+
+	var rejected error
+	var after func()
+	err = s.repo.WithTx(ctx, func(tx repository.PaymentRepository) error {
+		locked, err := tx.FindTransactionByExternalID(ctx, providerName, event.ExternalID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrTransactionNotFound
+			}
+			return err
+		}
+		// A concurrent delivery of the same event may have committed while we waited
+		seen, err := tx.IsEventProcessed(ctx, providerName, event.EventID)
+		if err != nil || seen {
+			return err
+		}
+
+		now := time.Now()
+		record := &entity.PaymentWebhookEvent{
+			ID:         uuid.New(),
+			Provider:   providerName,
+			EventID:    event.EventID,
+			ExternalID: event.ExternalID,
+			Status:     string(event.Status),
+			Payload:    string(event.Payload),
+			ReceivedAt: now,
+		}
+
+		switch event.Status {
+		case payment.EventPaid:
+			paid := money.New(event.Amount, money.Currency(event.Currency))
+			if paid != locked.Amount {
+				rejected = fmt.Errorf("%w for %s: expected %s, got %s", ErrAmountMismatch, locked.ExternalID, locked.Amount, paid)
+				record.Rejection = rejected.Error()
+				return tx.RecordEvent(ctx, record)
+			}
+			// A redelivery finds its own transaction already PAID; any other
+			// state means this charge's money has not been applied yet.
+			switch locked.Status {
+			case entity.PaymentPending, entity.PaymentExpired, entity.PaymentCancelled, entity.PaymentFailed:
+				locked.PaidAt = &now
+				after, err = s.settle(ctx, tx, locked)
+				if err != nil {
+					// Money is in, but the order/booking can no longer take it
+					// (paid by another charge, or closed meanwhile)
+					log.Printf("⚠️ Payment %s received but not applied, needs refund: %v", locked.ID, err)
+					locked.Status = entity.PaymentNeedsRefund
+					locked.ReviewNote = err.Error()
+				} else {
+					locked.Status = entity.PaymentPaid
+				}
+			}
+		case payment.EventExpired:
+			if locked.Status == entity.PaymentPending {
+				locked.Status = entity.PaymentExpired
+			}
+		case payment.EventFailed:
+			if locked.Status == entity.PaymentPending {
+				locked.Status = entity.PaymentFailed
+			}
+		case payment.EventRefunded:
+			// Refunded at the provider's side (e.g. from its dashboard)
+			if locked.Status == entity.PaymentPaid {
+				after, err = s.applyRefund(ctx, tx, "", locked, "refunded by the payment provider")
+				if err != nil {
+					log.Printf("⚠️ Payment %s refunded by the provider but not applied: %v", locked.ID, err)
+					locked.ReviewNote = "refunded by the provider but not applied: " + err.Error()
+				}
+			}
+			if locked.Status == entity.PaymentPaid || locked.Status == entity.PaymentNeedsRefund {
+				locked.Status = entity.PaymentRefunded
+				if locked.RefundedAt == nil {
+					locked.RefundedAt = &now
+				}
+			}
+		}
+		locked.UpdatedAt = now
+
+		if err := tx.UpdateTransaction(ctx, locked); err != nil {
+			return err
+		}
+		return tx.RecordEvent(ctx, record)
+	})
+	if err != nil {
+		return err
+	}
+
+	if after != nil {
+		after()
+	}
+	return rejected
+}
+
+// settle applies a payment to what it pays for, inside tx. The returned
+// func runs the side effects (receipts, enrollment) after commit.
+func (s *paymentService) settle(ctx context.Context, tx repository.PaymentRepository, trx *entity.PaymentTransaction) (func(), error) {
+	switch trx.ReferenceType {
+	case entity.RefOrder:
+		return s.commerceSvc.SettleOrderPayment(ctx, tx.Commerce(), trx.ReferenceID.String())
+	case entity.RefInstallment:
+		return s.pkgSvc.SettleInstallmentPayment(ctx, tx.Packages(), trx.ReferenceID.String())
+	default:
+		return nil, fmt.Errorf("unknown payment reference %s", trx.ReferenceType)
+	}
+}
+
+// applyRefund moves what a PAID transaction settled back out: the order
+// becomes REFUNDED, the installment REFUNDED. Empty adminID means the
+// provider refunded on its own.
+func (s *paymentService) applyRefund(ctx context.Context, tx repository.PaymentRepository, adminID string, trx *entity.PaymentTransaction, note string) (func(), error) {
+	switch trx.ReferenceType {
+	case entity.RefOrder:
+		return s.commerceSvc.RefundOrderPayment(ctx, tx.Commerce(), adminID, trx.ReferenceID.String(), note)
+	case entity.RefInstallment:
+		return s.pkgSvc.RefundInstallmentPayment(ctx, tx.Packages(), trx.ReferenceID.String())
+	default:
+		return nil, fmt.Errorf("unknown payment reference %s", trx.ReferenceType)
+	}
+}
+
+// Refund asks the provider to return a paid charge and moves the order or
+// installment it paid for to REFUNDED, all in one transaction: if the
+// provider refuses, nothing changes. A NEEDS_REFUND charge was never
+// applied, so only the money goes back.
+func (s *paymentService) Refund(ctx context.Context, adminID, transactionID, reason string) (*entity.PaymentTransaction, error) {
+	trx, err := s.repo.FindTransactionByID(ctx, transactionID)
+	if err != nil {
+		return nil, ErrTransactionNotFound
+	}
+	provider, ok := s.providers[trx.Provider]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	note := "refunded via payment gateway"
+	if reason != "" {
+		note += ": " + reason
+	}
+
+	after := func() {}
+	err = s.repo.WithTx(ctx, func(tx repository.PaymentRepository) error {
+		var err error
+		trx, err = tx.FindTransactionByExternalID(ctx, trx.Provider, trx.ExternalID)
+		if err != nil {
+			return ErrTransactionNotFound
+		}
+
+		switch trx.Status {
+		case entity.PaymentPaid:
+			after, err = s.applyRefund(ctx, tx, adminID, trx, note)
+			if err != nil {
+				return err
+			}
+		case entity.PaymentNeedsRefund:
+			// Never applied, only the money goes back
+		default:
+			return fmt.Errorf("transaction is %s, only PAID or NEEDS_REFUND transactions can be refunded", trx.Status)
+		}
+
+		refund, err := provider.Refund(ctx, payment.RefundRequest{
+			ExternalID: trx.ExternalID,
+			Amount:     trx.Amount.Amount,
+			Currency:   string(trx.Amount.Currency),
+			Reason:     reason,
+		})
+		if err != nil {
+			return fmt.Errorf("payment provider error: %v", err)
+		}
+
+		now := time.Now()
+		trx.Status = entity.PaymentRefunded
+		trx.RefundID = refund.RefundID
+		trx.RefundedAt = &now
+		trx.UpdatedAt = now
+		if err := tx.UpdateTransaction(ctx, trx); err != nil {
+			log.Printf("⚠️ Payment %s refunded at the provider (%s) but not recorded: %v", trx.ID, refund.RefundID, err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	after()
+	return trx, nil
+}
+
+// GetTransactions lists charges for admins, e.g. ?status=NEEDS_REFUND for
+// payments that arrived but were never applied.
+func (s *paymentService) GetTransactions(ctx context.Context, status string) ([]entity.PaymentTransaction, error) {
+	return s.repo.GetTransactions(ctx, status)
+}
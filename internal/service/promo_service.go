@@ -0,0 +1,468 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"umrah-backend/internal/entity"
+	"umrah-backend/internal/repository"
+	"umrah-backend/pkg/money"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// A reservation is attached or released within the request that made it;
+// one still RESERVED after this long was orphaned by a crash in between.
+const promoReservationTTL = 15 * time.Minute
+
+var (
+	ErrPromoNotFound = errors.New("promo code not found")
+	ErrInvalidPromo  = errors.New("promo code cannot be used")
+)
+
+// PromoService owns promo codes and their usage counters. Orders and
+// bookings use it in three steps around their own transaction:
+// Reserve (counts the use), then Attach on success or Release on failure.
+// A code whose order/booking later dies unpaid is released again.
+type PromoService interface {
+	// Admin
+	CreatePromo(ctx context.Context, req entity.PromoCodeDTO) (*entity.PromoCode, error)
+	UpdatePromo(ctx context.Context, id string, req entity.PromoCodeDTO) (*entity.PromoCode, error)
+	GetPromos(ctx context.Context, activeOnly bool) ([]entity.PromoCode, error)
+	GetPromo(ctx context.Context, id string) (*entity.PromoCode, error)
+	GetRedemptions(ctx context.Context, id string) ([]entity.PromoRedemption, error)
+
+	// Checkout
+	Check(ctx context.Context, userID, code string, target entity.PromoTarget) (*entity.PromoCode, error) // Preview, nothing reserved
+	Reserve(ctx context.Context, userID, code string, target entity.PromoTarget) (*entity.PromoCode, *entity.PromoRedemption, error)
+	Attach(ctx context.Context, redemptionID uuid.UUID, refType entity.DocumentReference, refID uuid.UUID, discount money.Money) error
+	Release(ctx context.Context, redemptionID uuid.UUID) error
+	ReleaseFor(ctx context.Context, refType entity.DocumentReference, refID uuid.UUID) error
+
+	ExpireReservations(ctx context.Context) (int, error) // Background sweeper
+}
+
+type promoService struct {
+	repo repository.PromoRepository
+}
+
+func NewPromoService(repo repository.PromoRepository) PromoService {
+	return &promoService{repo: repo}
+}
+
+func normalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func (s *promoService) CreatePromo(ctx context.Context, req entity.PromoCodeDTO) (*entity.PromoCode, error) {
+	promo := &entity.PromoCode{ID: uuid.New()}
+	if err := applyPromoDTO(promo, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreatePromo(ctx, promo); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) || strings.Contains(err.Error(), "duplicate key") {
+			return nil, fmt.Errorf("promo code %s already exists", promo.Code)
+		}
+		return nil, err
+	}
+	return promo, nil
+}
+
+func (s *promoService) UpdatePromo(ctx context.Context, id string, req entity.PromoCodeDTO) (*entity.PromoCode, error) {
+	promo, err := s.GetPromo(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyPromoDTO(promo, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdatePromo(ctx, promo); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) || strings.Contains(err.Error(), "duplicate key") {
+			return nil, fmt.Errorf("promo code %s already exists", promo.Code)
+		}
+		return nil, err
+	}
+	return promo, nil
+}
+
+// applyPromoDTO validates req and copies it onto promo (UsedCount is kept).
+func applyPromoDTO(promo *entity.PromoCode, req entity.PromoCodeDTO) error {
+	promo.Code = normalizePromoCode(req.Code)
+	promo.Description = req.Description
+	promo.Type = req.Type
+	promo.Target = req.Target
+	if promo.Target == "" {
+		promo.Target = entity.PromoForAll
+	}
+	promo.MaxUses = req.MaxUses
+	promo.MaxUsesPerUser = req.MaxUsesPerUser
+	promo.IsActive = req.IsActive == nil || *req.IsActive
+
+	promo.PercentBP = 0
+	promo.Amount = money.Zero(money.Default)
+	promo.MaxDiscount = money.Zero(money.Default)
+	switch req.Type {
+	case entity.PromoPercent:
+		if req.PercentBP <= 0 || req.PercentBP > 10000 {
+			return errors.New("percent_bp must be between 1 and 10000")
+		}
+		promo.PercentBP = req.PercentBP
+		if req.MaxDiscount != nil {
+			if err := req.MaxDiscount.Validate(); err != nil {
+				return fmt.Errorf("max_discount: %w", err)
+			}
+			promo.MaxDiscount = *req.MaxDiscount
+		}
+	case entity.PromoFixed:
+		if req.Amount == nil || !req.Amount.IsPositive() {
+			return errors.New("amount must be greater than zero")
+		}
+		if err := req.Amount.Validate(); err != nil {
+			return fmt.Errorf("amount: %w", err)
+		}
+		promo.Amount = *req.Amount
+	default:
+		return errors.New("type must be PERCENT or FIXED")
+	}
+
+	var err error
+	if promo.StartsAt, err = parseOptionalTime(req.StartsAt, "starts_at"); err != nil {
+		return err
+	}
+	if promo.EndsAt, err = parseOptionalTime(req.EndsAt, "ends_at"); err != nil {
+		return err
+	}
+	if promo.StartsAt != nil && promo.EndsAt != nil && !promo.EndsAt.After(*promo.StartsAt) {
+		return errors.New("ends_at must be after starts_at")
+	}
+
+	promo.Restrictions = make([]entity.PromoRestriction, 0, len(req.Restrictions))
+	for _, r := range req.Restrictions {
+		value := strings.TrimSpace(r.Value)
+		restricts := entity.PromoForOrders
+		switch r.Kind {
+		case entity.RestrictProduct, entity.RestrictProductCategory, entity.RestrictPackage:
+			if _, err := uuid.Parse(value); err != nil {
+				return fmt.Errorf("restriction %s: value must be an id", r.Kind)
+			}
+			if r.Kind == entity.RestrictPackage {
+				restricts = entity.PromoForBookings
+			}
+		case entity.RestrictPackageCategory:
+			value = strings.ToUpper(value)
+			restricts = entity.PromoForBookings
+		default:
+			return fmt.Errorf("unknown restriction kind %q", r.Kind)
+		}
+		if !promo.AppliesTo(restricts) {
+			return fmt.Errorf("restriction %s does not match target %s", r.Kind, promo.Target)
+		}
+		promo.Restrictions = append(promo.Restrictions, entity.PromoRestriction{
+			ID:      uuid.New(),
+			PromoID: promo.ID,
+			Kind:    r.Kind,
+			Value:   value,
+		})
+	}
+	return nil
+}
+
+func (s *promoService) GetPromos(ctx context.Context, activeOnly bool) ([]entity.PromoCode, error) {
+	return s.repo.GetPromos(ctx, activeOnly)
+}
+
+func (s *promoService) GetPromo(ctx context.Context, id string) (*entity.PromoCode, error) {
+	promo, err := s.repo.FindPromoByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPromoNotFound
+		}
+		return nil, err
+	}
+	return promo, nil
+}
+
+func (s *promoService) GetRedemptions(ctx context.Context, id string) ([]entity.PromoRedemption, error) {
+	if _, err := s.GetPromo(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.repo.GetRedemptions(ctx, id)
+}
+
+func (s *promoService) Check(ctx context.Context, userID, code string, target entity.PromoTarget) (*entity.PromoCode, error) {
+	promo, err := s.findUsable(ctx, s.repo, userID, code, target)
+	if err != nil {
+		return nil, err
+	}
+	if promo.MaxUses > 0 && promo.UsedCount >= promo.MaxUses {
+		return nil, fmt.Errorf("%w: usage limit reached", ErrInvalidPromo)
+	}
+	return promo, nil
+}
+
+func (s *promoService) Reserve(ctx context.Context, userID, code string, target entity.PromoTarget) (*entity.PromoCode, *entity.PromoRedemption, error) {
+	var promo *entity.PromoCode
+	var redemption *entity.PromoRedemption
+
+	// The promo row stays locked until commit, so concurrent uses of one
+	// code are counted one after the other (global and per-user limits).
+	err := s.repo.WithTx(ctx, func(repo repository.PromoRepository) error {
+		var err error
+		promo, err = s.findUsable(ctx, repo, userID, code, target)
+		if err != nil {
+			return err
+		}
+
+		if err := repo.IncrementUsage(ctx, promo.ID.String(), promo.MaxUses); err != nil {
+			if errors.Is(err, repository.ErrPromoExhausted) {
+				return fmt.Errorf("%w: usage limit reached", ErrInvalidPromo)
+			}
+			return err
+		}
+		promo.UsedCount++
+
+		redemption = &entity.PromoRedemption{
+			ID:      uuid.New(),
+			PromoID: promo.ID,
+			UserID:  uuid.MustParse(userID),
+			Status:  entity.RedemptionReserved,
+		}
+		return repo.CreateRedemption(ctx, redemption)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return promo, redemption, nil
+}
+
+// findUsable loads a code and checks everything except the global limit.
+func (s *promoService) findUsable(ctx context.Context, repo repository.PromoRepository, userID, code string, target entity.PromoTarget) (*entity.PromoCode, error) {
+	promo, err := repo.FindPromoByCode(ctx, normalizePromoCode(code))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPromoNotFound
+		}
+		return nil, err
+	}
+
+	if !promo.IsRunning(time.Now()) {
+		return nil, fmt.Errorf("%w: not active or outside its validity period", ErrInvalidPromo)
+	}
+	if !promo.AppliesTo(target) {
+		return nil, fmt.Errorf("%w: not valid for %s", ErrInvalidPromo, strings.ToLower(string(target)))
+	}
+	if promo.MaxUsesPerUser > 0 {
+		used, err := repo.CountUserRedemptions(ctx, promo.ID.String(), userID)
+		if err != nil {
+			return nil, err
+		}
+		if used >= int64(promo.MaxUsesPerUser) {
+			return nil, fmt.Errorf("%w: you have already used this code", ErrInvalidPromo)
+		}
+	}
+	return promo, nil
+}
+
+func (s *promoService) Attach(ctx context.Context, redemptionID uuid.UUID, refType entity.DocumentReference, refID uuid.UUID, discount money.Money) error {
+	return s.repo.WithTx(ctx, func(repo repository.PromoRepository) error {
+		redemption, err := repo.FindRedemptionByID(ctx, redemptionID.String())
+		if err != nil {
+			return err
+		}
+		// Swept as orphaned meanwhile: the use is no longer counted
+		if redemption.Status != entity.RedemptionReserved {
+			return fmt.Errorf("promo redemption is %s, not RESERVED", redemption.Status)
+		}
+		redemption.ReferenceType = refType
+		redemption.ReferenceID = &refID
+		redemption.Discount = discount
+		redemption.Status = entity.RedemptionApplied
+		return repo.UpdateRedemption(ctx, redemption)
+	})
+}
+
+func (s *promoService) Release(ctx context.Context, redemptionID uuid.UUID) error {
+	return s.repo.WithTx(ctx, func(repo repository.PromoRepository) error {
+		redemption, err := repo.FindRedemptionByID(ctx, redemptionID.String())
+		if err != nil {
+			return err
+		}
+		// Released once only, so the counter is not decremented twice
+		if redemption.Status == entity.RedemptionReleased {
+			return nil
+		}
+		redemption.Status = entity.RedemptionReleased
+		if err := repo.UpdateRedemption(ctx, redemption); err != nil {
+			return err
+		}
+		return repo.DecrementUsage(ctx, redemption.PromoID.String())
+	})
+}
+
+func (s *promoService) ReleaseFor(ctx context.Context, refType entity.DocumentReference, refID uuid.UUID) error {
+	redemption, err := s.repo.FindRedemptionByReference(ctx, refType, refID.String())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	return s.Release(ctx, redemption.ID)
+}
+
+// ExpireReservations gives back uses reserved by a checkout that never
+// attached or released them (the process died in between).
+func (s *promoService) ExpireReservations(ctx context.Context) (int, error) {
+	stale, err := s.repo.GetStaleReservations(ctx, time.Now().Add(-promoReservationTTL))
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, r := range stale {
+		released := false
+		err := s.repo.WithTx(ctx, func(repo repository.PromoRepository) error {
+			redemption, err := repo.FindRedemptionByID(ctx, r.ID.String())
+			if err != nil {
+				return err
+			}
+			// Attached or released since the list was read
+			if redemption.Status != entity.RedemptionReserved {
+				return nil
+			}
+			redemption.Status = entity.RedemptionReleased
+			if err := repo.UpdateRedemption(ctx, redemption); err != nil {
+				return err
+			}
+			released = true
+			return repo.DecrementUsage(ctx, redemption.PromoID.String())
+		})
+		if err != nil {
+			log.Printf("Failed to expire promo reservation %s: %v", r.ID, err)
+			continue
+		}
+		if released {
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+// computeDiscount returns what promo takes off the lines it covers. Lines
+// outside its restrictions are paid in full.
+func computeDiscount(promo *entity.PromoCode, lines []entity.PromoLine) (money.Money, error) {
+	var eligible money.Money
+	covered := false
+	for _, line := range lines {
+		if !promoCovers(promo, line) {
+			continue
+		}
+		if !covered {
+			eligible = money.Zero(line.Amount.Currency)
+			covered = true
+		}
+		var err error
+		if eligible, err = eligible.Add(line.Amount); err != nil {
+			return money.Money{}, err
+		}
+	}
+	if !covered || !eligible.IsPositive() {
+		return money.Money{}, fmt.Errorf("%w: not valid for these items", ErrInvalidPromo)
+	}
+
+	switch promo.Type {
+	case entity.PromoPercent:
+		discount := eligible.Percent(promo.PercentBP)
+		if promo.MaxDiscount.IsPositive() {
+			if !promo.MaxDiscount.SameCurrency(discount) {
+				return money.Money{}, fmt.Errorf("%w: only valid for prices in %s", ErrInvalidPromo, promo.MaxDiscount.Currency)
+			}
+			if discount.Amount > promo.MaxDiscount.Amount {
+				discount = promo.MaxDiscount
+			}
+		}
+		return discount, nil
+	case entity.PromoFixed:
+		if !promo.Amount.SameCurrency(eligible) {
+			return money.Money{}, fmt.Errorf("%w: only valid for prices in %s", ErrInvalidPromo, promo.Amount.Currency)
+		}
+		if promo.Amount.Amount > eligible.Amount {
+			return eligible, nil
+		}
+		return promo.Amount, nil
+	default:
+		return money.Money{}, fmt.Errorf("%w: unknown type %s", ErrInvalidPromo, promo.Type)
+	}
+}
+
+// promoCovers: without restrictions a code covers every line; otherwise a
+// line needs to match at least one restriction.
+func promoCovers(promo *entity.PromoCode, line entity.PromoLine) bool {
+	if len(promo.Restrictions) == 0 {
+		return true
+	}
+	for _, r := range promo.Restrictions {
+		switch r.Kind {
+		case entity.RestrictProduct:
+			if line.ProductID != nil && line.ProductID.String() == r.Value {
+				return true
+			}
+		case entity.RestrictProductCategory:
+			if line.ProductCategoryID != nil && line.ProductCategoryID.String() == r.Value {
+				return true
+			}
+		case entity.RestrictPackage:
+			if line.PackageID != nil && line.PackageID.String() == r.Value {
+				return true
+			}
+		case entity.RestrictPackageCategory:
+			if line.PackageCategory != "" && string(line.PackageCategory) == r.Value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// releasePromo gives a use back after its order/booking died unpaid. Like
+// issueDocument it runs after the caller's commit and only logs failures.
+func releasePromo(ctx context.Context, promos PromoService, refType entity.DocumentReference, refID uuid.UUID) {
+	if err := promos.ReleaseFor(ctx, refType, refID); err != nil {
+		log.Printf("Failed to release promo code of %s %s: %v", refType, refID, err)
+	}
+}
+
+// redeemPromo runs place with the code reserved (promo is nil when no code
+// was given). The use is attached to what place created, or given back when
+// place fails, since the order/booking lives in another transaction. A
+// reservation orphaned by a crash in between is swept by ExpireReservations.
+func redeemPromo(ctx context.Context, promos PromoService, userID, code string, target entity.PromoTarget, refType entity.DocumentReference, place func(promo *entity.PromoCode) (uuid.UUID, money.Money, error)) error {
+	if strings.TrimSpace(code) == "" {
+		_, _, err := place(nil)
+		return err
+	}
+
+	promo, redemption, err := promos.Reserve(ctx, userID, code, target)
+	if err != nil {
+		return err
+	}
+
+	refID, discount, err := place(promo)
+	if err != nil {
+		if relErr := promos.Release(ctx, redemption.ID); relErr != nil {
+			log.Printf("Failed to release promo redemption %s: %v", redemption.ID, relErr)
+		}
+		return err
+	}
+
+	if err := promos.Attach(ctx, redemption.ID, refType, refID, discount); err != nil {
+		log.Printf("Failed to attach promo redemption %s to %s %s: %v", redemption.ID, refType, refID, err)
+	}
+	return nil
+}
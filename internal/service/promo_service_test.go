@@ -0,0 +1,71 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"umrah-backend/internal/entity"
+	"umrah-backend/pkg/money"
+
+	"github.com/google/uuid"
+)
+
+func TestComputeDiscount(t *testing.T) {
+	simCard := uuid.MustParse("0d2c3b4a-5f6e-4d1c-9b8a-7e6f5d4c3b2a")
+	ihram := uuid.MustParse("1e3d4c5b-6a7f-4e2d-8c9b-0a1b2c3d4e5f")
+	perlengkapan := uuid.MustParse("2f4e5d6c-7b8a-4f3e-9d0c-1b2a3c4d5e6f")
+	idr := func(amount int64) money.Money { return money.New(amount, money.IDR) }
+
+	lines := []entity.PromoLine{
+		{ProductID: &simCard, Amount: idr(15000000)},                                 // 150,000.00
+		{ProductID: &ihram, ProductCategoryID: &perlengkapan, Amount: idr(35000050)}, // 350,000.50
+	}
+
+	percent := func(bp int64, maxDiscount money.Money) *entity.PromoCode {
+		return &entity.PromoCode{Type: entity.PromoPercent, PercentBP: bp, MaxDiscount: maxDiscount}
+	}
+	fixed := func(amount money.Money) *entity.PromoCode {
+		return &entity.PromoCode{Type: entity.PromoFixed, Amount: amount}
+	}
+	restricted := func(promo *entity.PromoCode, kind entity.PromoRestrictionKind, value string) *entity.PromoCode {
+		promo.Restrictions = []entity.PromoRestriction{{Kind: kind, Value: value}}
+		return promo
+	}
+
+	tests := []struct {
+		name    string
+		promo   *entity.PromoCode
+		lines   []entity.PromoLine
+		want    money.Money
+		wantErr error
+	}{
+		{"percent of every line", percent(1000, idr(0)), lines, idr(5000005), nil},
+		{"percent rounds half up", percent(1000, idr(0)), []entity.PromoLine{{Amount: idr(1005)}}, idr(101), nil},
+		{"percent below half rounds down", percent(1000, idr(0)), []entity.PromoLine{{Amount: idr(1004)}}, idr(100), nil},
+		{"percent under the cap", percent(1000, idr(10000000)), lines, idr(5000005), nil},
+		{"percent capped", percent(5000, idr(10000000)), lines, idr(10000000), nil},
+		{"percent cap in another currency", percent(1000, money.New(500, money.USD)), lines, money.Money{}, ErrInvalidPromo},
+		{"fixed", fixed(idr(2500000)), lines, idr(2500000), nil},
+		{"fixed above the eligible amount", fixed(idr(100000000)), lines, idr(50000050), nil},
+		{"fixed in another currency", fixed(money.New(500, money.SAR)), lines, money.Money{}, ErrInvalidPromo},
+		{"restricted to a product", restricted(percent(1000, idr(0)), entity.RestrictProduct, simCard.String()), lines, idr(1500000), nil},
+		{"restricted to a category", restricted(fixed(idr(100000000)), entity.RestrictProductCategory, perlengkapan.String()), lines, idr(35000050), nil},
+		{"restriction matches nothing", restricted(fixed(idr(100)), entity.RestrictProduct, uuid.NewString()), lines, money.Money{}, ErrInvalidPromo},
+		{"package category", restricted(percent(500, idr(0)), entity.RestrictPackageCategory, "UMRAH_PLUS"),
+			[]entity.PromoLine{{PackageCategory: "UMRAH_PLUS", Amount: idr(3500000000)}}, idr(175000000), nil},
+		{"nothing to discount", percent(1000, idr(0)), []entity.PromoLine{{Amount: idr(0)}}, money.Money{}, ErrInvalidPromo},
+		{"no lines", fixed(idr(100)), nil, money.Money{}, ErrInvalidPromo},
+		{"lines in two currencies", percent(1000, idr(0)), []entity.PromoLine{{Amount: idr(100)}, {Amount: money.New(100, money.USD)}}, money.Money{}, money.ErrCurrencyMismatch},
+		{"unknown type", &entity.PromoCode{Type: "BOGO"}, lines, money.Money{}, ErrInvalidPromo},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := computeDiscount(tt.promo, tt.lines)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("discount = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
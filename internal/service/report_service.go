@@ -0,0 +1,346 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+	"umrah-backend/internal/entity"
+	"umrah-backend/internal/repository"
+	"umrah-backend/pkg/money"
+)
+
+// Longest range a daily revenue report may span
+const maxDailyReportDays = 366
+
+var ErrInvalidReportRange = errors.New("invalid report range")
+
+type ReportService interface {
+	Revenue(ctx context.Context, filter entity.ReportFilter) (*entity.RevenueReport, error)
+	RevenueByProduct(ctx context.Context, filter entity.ReportFilter) ([]entity.ProductRevenue, error)
+	RevenueByCategory(ctx context.Context, filter entity.ReportFilter) ([]entity.CategoryRevenue, error)
+	Occupancy(ctx context.Context, departureFrom, departureTo *time.Time) ([]entity.PackageOccupancy, error)
+	Outstanding(ctx context.Context) (*entity.OutstandingReport, error)
+}
+
+type reportService struct {
+	repo     repository.ReportRepository
+	location *time.Location
+}
+
+// NewReportService: days and months are cut in the agency timezone.
+func NewReportService(repo repository.ReportRepository, location *time.Location) ReportService {
+	if location == nil {
+		location = time.Local
+	}
+	return &reportService{repo: repo, location: location}
+}
+
+// resolveRange turns the filter dates (parsed as UTC midnights) into local
+// midnights and applies defaults: the last 30 days, or the last 12 months.
+func (s *reportService) resolveRange(filter entity.ReportFilter) (time.Time, time.Time, error) {
+	localDate := func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, s.location)
+	}
+
+	now := time.Now().In(s.location)
+	to := localDate(now).AddDate(0, 0, 1)
+	if filter.To != nil {
+		to = localDate(*filter.To)
+	}
+
+	var from time.Time
+	switch {
+	case filter.From != nil:
+		from = localDate(*filter.From)
+	case filter.Granularity == entity.GranularityMonth:
+		from = time.Date(to.Year(), to.Month(), 1, 0, 0, 0, 0, s.location).AddDate(0, -11, 0)
+	default:
+		from = to.AddDate(0, 0, -30)
+	}
+
+	if !from.Before(to) {
+		return from, to, fmt.Errorf("%w: from must be before to", ErrInvalidReportRange)
+	}
+	if filter.Granularity == entity.GranularityDay && to.Sub(from) > maxDailyReportDays*24*time.Hour {
+		return from, to, fmt.Errorf("%w: daily reports cover at most %d days, use granularity=month", ErrInvalidReportRange, maxDailyReportDays)
+	}
+	return from, to, nil
+}
+
+// utcOffset of the agency timezone. Fixed for the whole report, which is
+// exact for Indonesia (no daylight saving time).
+func (s *reportService) utcOffset(at time.Time) int {
+	_, offset := at.In(s.location).Zone()
+	return offset
+}
+
+func (s *reportService) Revenue(ctx context.Context, filter entity.ReportFilter) (*entity.RevenueReport, error) {
+	if filter.Granularity == "" {
+		filter.Granularity = entity.GranularityDay
+	}
+	if filter.Granularity != entity.GranularityDay && filter.Granularity != entity.GranularityMonth {
+		return nil, fmt.Errorf("%w: granularity must be day or month", ErrInvalidReportRange)
+	}
+	from, to, err := s.resolveRange(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	trunc, offset := string(filter.Granularity), s.utcOffset(from)
+	orders, err := s.repo.OrderRevenue(ctx, from, to, trunc, offset)
+	if err != nil {
+		return nil, err
+	}
+	bookings, err := s.repo.BookingRevenue(ctx, from, to, trunc, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	layout := "2006-01-02"
+	if filter.Granularity == entity.GranularityMonth {
+		layout = "2006-01"
+	}
+
+	// (period, currency) -> point
+	type key struct{ period, currency string }
+	points := make(map[key]*entity.RevenuePoint)
+	currencies := make(map[string]bool)
+	point := func(period time.Time, currency string) *entity.RevenuePoint {
+		k := key{period.Format(layout), currency}
+		if p, ok := points[k]; ok {
+			return p
+		}
+		cur := money.Currency(currency)
+		p := &entity.RevenuePoint{Period: k.period, Orders: money.Zero(cur), Bookings: money.Zero(cur), Total: money.Zero(cur)}
+		points[k] = p
+		currencies[currency] = true
+		return p
+	}
+	for _, row := range orders {
+		p := point(row.Period, row.Currency)
+		p.Orders.Amount += row.Amount
+		p.OrderCount += row.Count
+	}
+	for _, row := range bookings {
+		p := point(row.Period, row.Currency)
+		p.Bookings.Amount += row.Amount
+		p.PaymentCount += row.Count
+	}
+
+	// Every period is listed (zero when nothing was sold) so charts have no gaps
+	report := &entity.RevenueReport{From: from, To: to, Granularity: filter.Granularity, Points: []entity.RevenuePoint{}, Totals: []money.Money{}}
+	for _, currency := range sortedKeys(currencies) {
+		total := money.Zero(money.Currency(currency))
+		for t := from; t.Before(to); t = nextPeriod(t, filter.Granularity) {
+			p := point(t, currency)
+			p.Total.Amount = p.Orders.Amount + p.Bookings.Amount
+			total.Amount += p.Total.Amount
+			report.Points = append(report.Points, *p)
+		}
+		report.Totals = append(report.Totals, total)
+	}
+	return report, nil
+}
+
+func nextPeriod(t time.Time, granularity entity.ReportGranularity) time.Time {
+	if granularity == entity.GranularityMonth {
+		return time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+	}
+	return t.AddDate(0, 0, 1)
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *reportService) RevenueByProduct(ctx context.Context, filter entity.ReportFilter) ([]entity.ProductRevenue, error) {
+	from, to, err := s.resolveRange(filter)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.repo.ProductRevenue(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]entity.ProductRevenue, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, entity.ProductRevenue{
+			ProductID:   row.ProductID,
+			ProductName: row.ProductName,
+			Revenue:     money.New(row.Amount, money.Currency(row.Currency)),
+			Quantity:    row.Quantity,
+			Orders:      row.Orders,
+		})
+	}
+	return result, nil
+}
+
+func (s *reportService) RevenueByCategory(ctx context.Context, filter entity.ReportFilter) ([]entity.CategoryRevenue, error) {
+	from, to, err := s.resolveRange(filter)
+	if err != nil {
+		return nil, err
+	}
+	received, err := s.repo.CategoryRevenue(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	booked, err := s.repo.CategoryBookings(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	type key struct{ category, currency string }
+	byKey := make(map[key]*entity.CategoryRevenue)
+	var order []key
+	entry := func(category, currency string) *entity.CategoryRevenue {
+		k := key{category, currency}
+		if e, ok := byKey[k]; ok {
+			return e
+		}
+		cur := money.Currency(currency)
+		e := &entity.CategoryRevenue{Category: entity.PackageCategory(category), Revenue: money.Zero(cur), BookedValue: money.Zero(cur)}
+		byKey[k] = e
+		order = append(order, k)
+		return e
+	}
+	for _, row := range received {
+		e := entry(row.Category, row.Currency)
+		e.Revenue.Amount += row.Amount
+		e.Payments += row.Count
+	}
+	for _, row := range booked {
+		e := entry(row.Category, row.Currency)
+		e.BookedValue.Amount += row.Amount
+		e.Bookings += row.Count
+		e.Pax += row.Pax
+	}
+
+	sort.Slice(order, func(i, j int) bool {
+		if order[i].category != order[j].category {
+			return order[i].category < order[j].category
+		}
+		return order[i].currency < order[j].currency
+	})
+	result := make([]entity.CategoryRevenue, 0, len(order))
+	for _, k := range order {
+		result = append(result, *byKey[k])
+	}
+	return result, nil
+}
+
+// Occupancy defaults to packages departing from today on.
+func (s *reportService) Occupancy(ctx context.Context, departureFrom, departureTo *time.Time) ([]entity.PackageOccupancy, error) {
+	if departureFrom == nil && departureTo == nil {
+		now := time.Now().In(s.location)
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.location)
+		departureFrom = &today
+	}
+	rows, err := s.repo.PackageOccupancy(ctx, departureFrom, departureTo)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]entity.PackageOccupancy, 0, len(rows))
+	for _, row := range rows {
+		o := entity.PackageOccupancy{
+			PackageID:     row.ID,
+			Name:          row.Name,
+			Category:      entity.PackageCategory(row.Category),
+			DepartureDate: row.DepartureDate,
+			Quota:         row.Quota,
+			Available:     row.Available,
+			Booked:        row.Quota - row.Available,
+			PendingPax:    row.PendingPax,
+			PaidPax:       row.PaidPax,
+			ConfirmedPax:  row.ConfirmedPax,
+		}
+		if row.Quota > 0 {
+			// One decimal, e.g. 87.5
+			o.Occupancy = float64(o.Booked*1000/row.Quota) / 10
+		}
+		result = append(result, o)
+	}
+	return result, nil
+}
+
+func (s *reportService) Outstanding(ctx context.Context) (*entity.OutstandingReport, error) {
+	orders, err := s.repo.OutstandingOrders(ctx)
+	if err != nil {
+		return nil, err
+	}
+	bookings, err := s.repo.OutstandingBookings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	report := &entity.OutstandingReport{Items: make([]entity.OutstandingItem, 0, len(orders)+len(bookings))}
+
+	for _, row := range orders {
+		cur := money.Currency(row.Currency)
+		report.Items = append(report.Items, entity.OutstandingItem{
+			Source:      "ORDER",
+			ID:          row.ID,
+			Customer:    row.Customer,
+			Phone:       row.Phone,
+			Description: fmt.Sprintf("Order #%s (%d items)", shortID(row.ID), row.Items),
+			Status:      row.Status,
+			Total:       money.New(row.Amount, cur),
+			Paid:        money.Zero(cur),
+			Outstanding: money.New(row.Amount, cur),
+			DueDate:     row.ExpiresAt,
+			Overdue:     row.ExpiresAt != nil && row.ExpiresAt.Before(now),
+		})
+	}
+
+	for _, row := range bookings {
+		cur := money.Currency(row.Currency)
+		// Without a payment plan the booking hold is the deadline
+		due := row.NextDue
+		if due == nil {
+			due = row.ExpiresAt
+		}
+		report.Items = append(report.Items, entity.OutstandingItem{
+			Source:      "BOOKING",
+			ID:          row.ID,
+			Customer:    row.Customer,
+			Phone:       row.Phone,
+			Description: fmt.Sprintf("%s (departure %s)", row.PackageName, row.DepartureDate.Format("2006-01-02")),
+			Status:      row.Status,
+			Total:       money.New(row.Total, cur),
+			Paid:        money.New(row.Paid, cur),
+			Outstanding: money.New(row.Total-row.Paid, cur),
+			DueDate:     due,
+			Overdue:     due != nil && due.Before(now),
+		})
+	}
+
+	// Totals per (source, currency), in order of first appearance
+	type key struct{ source, currency string }
+	index := make(map[key]int)
+	for _, item := range report.Items {
+		k := key{item.Source, string(item.Outstanding.Currency)}
+		i, ok := index[k]
+		if !ok {
+			i = len(report.Totals)
+			index[k] = i
+			cur := item.Outstanding.Currency
+			report.Totals = append(report.Totals, entity.OutstandingTotal{Source: item.Source, Amount: money.Zero(cur), Overdue: money.Zero(cur)})
+		}
+		t := &report.Totals[i]
+		t.Count++
+		t.Amount.Amount += item.Outstanding.Amount
+		if item.Overdue {
+			t.Overdue.Amount += item.Outstanding.Amount
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,380 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+	"umrah-backend/internal/entity"
+	"umrah-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPassengerNotFound      = errors.New("passenger not found")
+	ErrRoomNotFound           = errors.New("room not found")
+	ErrRoomAssignmentNotFound = errors.New("passenger has no room assignment")
+)
+
+type RoomingService interface {
+	// GenerateRooming (re)builds the rooming list of a package.
+	// Manual assignments made by an admin are kept as they are.
+	GenerateRooming(ctx context.Context, packageID string) (*entity.RoomingList, error)
+	GetRooming(ctx context.Context, packageID string) (*entity.RoomingList, error)
+
+	// Admin Overrides
+	CreateRoom(ctx context.Context, packageID string, req entity.CreateRoomDTO) (*entity.Room, error)
+	AssignRoom(ctx context.Context, req entity.AssignRoomDTO) (*entity.RoomAssignment, error)
+	UnassignRoom(ctx context.Context, passengerID string) error
+}
+
+type roomingService struct {
+	repo    repository.RoomingRepository
+	pkgRepo repository.PackageRepository
+}
+
+func NewRoomingService(repo repository.RoomingRepository, pkgRepo repository.PackageRepository) RoomingService {
+	return &roomingService{repo: repo, pkgRepo: pkgRepo}
+}
+
+// roomSlot tracks free beds of a room while the allocator runs.
+type roomSlot struct {
+	room *entity.Room
+	used int
+}
+
+func (r *roomSlot) free() int { return r.room.Capacity - r.used }
+
+// roomAllocator packs passengers into rooms of one package.
+type roomAllocator struct {
+	packageID   uuid.UUID
+	slots       []*roomSlot
+	newRooms    []*entity.Room
+	assignments []entity.RoomAssignment
+	counters    map[string]int // room type -> last label number
+}
+
+func (s *roomingService) GenerateRooming(ctx context.Context, packageID string) (*entity.RoomingList, error) {
+	pkg, err := s.pkgRepo.FindPackageByID(ctx, packageID)
+	if err != nil {
+		return nil, errors.New("package not found")
+	}
+
+	err = s.repo.WithTx(ctx, func(tx repository.RoomingRepository) error {
+		bookings, err := tx.GetRoomingBookings(ctx, packageID)
+		if err != nil {
+			return err
+		}
+
+		// 1. Clean up: drop automatic placements and anyone no longer travelling
+		var keep []string
+		for _, b := range bookings {
+			for _, p := range b.Passengers {
+				keep = append(keep, p.ID.String())
+			}
+		}
+		if err := tx.DeleteStaleAssignments(ctx, packageID, keep); err != nil {
+			return err
+		}
+		if err := tx.DeleteAutoAssignments(ctx, packageID); err != nil {
+			return err
+		}
+		if err := tx.DeleteEmptyGeneratedRooms(ctx, packageID); err != nil {
+			return err
+		}
+
+		// 2. Rooms that survive hold manual assignments or were created by an admin
+		rooms, err := tx.GetRooms(ctx, packageID)
+		if err != nil {
+			return err
+		}
+		alloc := newRoomAllocator(pkg.ID, rooms)
+
+		placed := make(map[uuid.UUID]bool)
+		for _, room := range rooms {
+			for _, o := range room.Occupants {
+				placed[o.PassengerID] = true
+			}
+		}
+
+		// 3. Place every remaining passenger, booking by booking
+		for _, b := range bookings {
+			var pending []entity.BookingPassenger
+			for _, p := range b.Passengers {
+				if !placed[p.ID] {
+					pending = append(pending, p)
+				}
+			}
+			if len(pending) == 0 {
+				continue
+			}
+			if err := alloc.placeBooking(b, pending); err != nil {
+				return err
+			}
+		}
+
+		// 4. Persist
+		for _, room := range alloc.newRooms {
+			if err := tx.CreateRoom(ctx, room); err != nil {
+				return err
+			}
+		}
+		return tx.CreateAssignments(ctx, alloc.assignments)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetRooming(ctx, packageID)
+}
+
+func (s *roomingService) GetRooming(ctx context.Context, packageID string) (*entity.RoomingList, error) {
+	pkg, err := s.pkgRepo.FindPackageByID(ctx, packageID)
+	if err != nil {
+		return nil, errors.New("package not found")
+	}
+
+	rooms, err := s.repo.GetRooms(ctx, packageID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.RoomingList{Package: pkg, Rooms: rooms}, nil
+}
+
+func (s *roomingService) CreateRoom(ctx context.Context, packageID string, req entity.CreateRoomDTO) (*entity.Room, error) {
+	pkg, err := s.pkgRepo.FindPackageByID(ctx, packageID)
+	if err != nil {
+		return nil, errors.New("package not found")
+	}
+
+	room := &entity.Room{
+		ID:        uuid.New(),
+		PackageID: pkg.ID,
+		Label:     req.Label,
+		RoomType:  req.RoomType,
+		Capacity:  entity.RoomCapacity(req.RoomType),
+		Gender:    req.Gender,
+		CreatedAt: time.Now(),
+	}
+	if err := s.repo.CreateRoom(ctx, room); err != nil {
+		return nil, err
+	}
+	return room, nil
+}
+
+// AssignRoom moves a passenger into a room chosen by an admin. Capacity is
+// still enforced; the gender/family rule is deliberately not, since the
+// admin override exists for the cases the allocator cannot know about.
+func (s *roomingService) AssignRoom(ctx context.Context, req entity.AssignRoomDTO) (*entity.RoomAssignment, error) {
+	var result *entity.RoomAssignment
+
+	err := s.repo.WithTx(ctx, func(tx repository.RoomingRepository) error {
+		passenger, err := tx.FindPassengerByID(ctx, req.PassengerID)
+		if err != nil {
+			return ErrPassengerNotFound
+		}
+
+		room, err := tx.FindRoomByID(ctx, req.RoomID)
+		if err != nil {
+			return ErrRoomNotFound
+		}
+
+		booking, err := tx.FindBookingByID(ctx, passenger.BookingID.String())
+		if err != nil {
+			return ErrBookingNotFound
+		}
+		if booking.Status != entity.BookingPaid && booking.Status != entity.BookingConfirmed {
+			return fmt.Errorf("only passengers of paid or confirmed bookings get a room (booking is %s)", booking.Status)
+		}
+		if booking.PackageID != room.PackageID {
+			return errors.New("room belongs to a different package")
+		}
+
+		existing, err := tx.FindAssignmentByPassenger(ctx, req.PassengerID)
+		if err != nil {
+			return err
+		}
+		if existing != nil && existing.RoomID == room.ID {
+			existing.IsManual = true
+			result = existing
+			return tx.SaveAssignment(ctx, existing)
+		}
+
+		if len(room.Occupants) >= room.Capacity {
+			return fmt.Errorf("room %s is full (%d beds)", room.Label, room.Capacity)
+		}
+
+		if existing == nil {
+			existing = &entity.RoomAssignment{
+				ID:          uuid.New(),
+				PassengerID: passenger.ID,
+				CreatedAt:   time.Now(),
+			}
+		}
+		existing.RoomID = room.ID
+		existing.IsManual = true
+
+		result = existing
+		return tx.SaveAssignment(ctx, existing)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (s *roomingService) UnassignRoom(ctx context.Context, passengerID string) error {
+	if err := s.repo.DeleteAssignment(ctx, passengerID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRoomAssignmentNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// -----------------------------------------------------------
+// Allocator
+// -----------------------------------------------------------
+
+func newRoomAllocator(packageID uuid.UUID, rooms []entity.Room) *roomAllocator {
+	a := &roomAllocator{packageID: packageID, counters: make(map[string]int)}
+	for i := range rooms {
+		a.slots = append(a.slots, &roomSlot{room: &rooms[i], used: len(rooms[i].Occupants)})
+		var n int
+		if _, err := fmt.Sscanf(rooms[i].Label, labelPrefix(rooms[i].RoomType)+"-%d", &n); err == nil && n > a.counters[rooms[i].RoomType] {
+			a.counters[rooms[i].RoomType] = n
+		}
+	}
+	return a
+}
+
+// placeBooking keeps a family (any declared mahram + mixed genders) in
+// private FAMILY rooms. Everyone else is split by gender and may share a
+// room with pilgrims of other bookings on the same room type.
+func (a *roomAllocator) placeBooking(b entity.Booking, passengers []entity.BookingPassenger) error {
+	capacity := entity.RoomCapacity(b.RoomType)
+	if capacity == 0 {
+		return fmt.Errorf("booking %s has invalid room type %s", b.ID, b.RoomType)
+	}
+
+	if isFamilyUnit(passengers) {
+		for start := 0; start < len(passengers); start += capacity {
+			end := start + capacity
+			if end > len(passengers) {
+				end = len(passengers)
+			}
+			slot := a.openRoom(b.RoomType, entity.RoomFamily)
+			for _, p := range passengers[start:end] {
+				a.assign(slot, p)
+			}
+		}
+		return nil
+	}
+
+	byGender := map[string][]entity.BookingPassenger{}
+	for _, p := range passengers {
+		g := string(p.Gender)
+		byGender[g] = append(byGender[g], p)
+	}
+
+	genders := make([]string, 0, len(byGender))
+	for g := range byGender {
+		genders = append(genders, g)
+	}
+	sort.Strings(genders)
+
+	for _, g := range genders {
+		a.placeShared(b.RoomType, g, byGender[g])
+	}
+	return nil
+}
+
+// placeShared keeps a party together: it looks for a room with enough free
+// beds for the whole party (or a full room's worth), else opens a new one.
+// Small parties and singles naturally fill the gaps left behind.
+func (a *roomAllocator) placeShared(roomType, gender string, party []entity.BookingPassenger) {
+	capacity := entity.RoomCapacity(roomType)
+	for len(party) > 0 {
+		need := len(party)
+		if need > capacity {
+			need = capacity
+		}
+
+		slot := a.findSlot(roomType, gender, need)
+		if slot == nil {
+			slot = a.openRoom(roomType, gender)
+		}
+
+		for _, p := range party[:need] {
+			a.assign(slot, p)
+		}
+		party = party[need:]
+	}
+}
+
+func (a *roomAllocator) findSlot(roomType, gender string, need int) *roomSlot {
+	for _, slot := range a.slots {
+		if slot.room.RoomType == roomType && slot.room.Gender == gender && slot.free() >= need {
+			return slot
+		}
+	}
+	return nil
+}
+
+func (a *roomAllocator) openRoom(roomType, gender string) *roomSlot {
+	a.counters[roomType]++
+	room := &entity.Room{
+		ID:        uuid.New(),
+		PackageID: a.packageID,
+		Label:     fmt.Sprintf("%s-%02d", labelPrefix(roomType), a.counters[roomType]),
+		RoomType:  roomType,
+		Capacity:  entity.RoomCapacity(roomType),
+		Gender:    gender,
+
+		AutoGenerated: true,
+		CreatedAt:     time.Now(),
+	}
+	slot := &roomSlot{room: room}
+	a.slots = append(a.slots, slot)
+	a.newRooms = append(a.newRooms, room)
+	return slot
+}
+
+func (a *roomAllocator) assign(slot *roomSlot, p entity.BookingPassenger) {
+	slot.used++
+	a.assignments = append(a.assignments, entity.RoomAssignment{
+		ID:          uuid.New(),
+		RoomID:      slot.room.ID,
+		PassengerID: p.ID,
+		CreatedAt:   time.Now(),
+	})
+}
+
+func isFamilyUnit(passengers []entity.BookingPassenger) bool {
+	hasMale, hasFemale, hasMahram := false, false, false
+	for _, p := range passengers {
+		switch p.Gender {
+		case entity.GenderMale:
+			hasMale = true
+		case entity.GenderFemale:
+			hasFemale = true
+		}
+		if p.MahramRelation != "" {
+			hasMahram = true
+		}
+	}
+	return hasMale && hasFemale && hasMahram
+}
+
+func labelPrefix(roomType string) string {
+	if roomType == "" {
+		return "R"
+	}
+	return roomType[:1]
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+	"umrah-backend/internal/entity"
+	"umrah-backend/internal/repository"
+	"umrah-backend/pkg/money"
+
+	"github.com/google/uuid"
+)
+
+// waitlistRepo keeps one package and its waitlist in memory. It implements
+// only what booking and the waitlist touch; any other call panics on the
+// nil embedded interface.
+type waitlistRepo struct {
+	repository.PackageRepository
+	pkg     entity.TravelPackage
+	entries []entity.WaitlistEntry
+}
+
+func (r *waitlistRepo) WithTx(ctx context.Context, fn func(repo repository.PackageRepository) error) error {
+	return fn(r)
+}
+
+func (r *waitlistRepo) FindPackageByID(ctx context.Context, id string) (*entity.TravelPackage, error) {
+	pkg := r.pkg
+	return &pkg, nil
+}
+
+func (r *waitlistRepo) DecreaseQuota(ctx context.Context, packageID string, count int) error {
+	if r.pkg.Available < count {
+		return errors.New("quota insufficient or package not found")
+	}
+	r.pkg.Available -= count
+	return nil
+}
+
+func (r *waitlistRepo) IncreaseQuota(ctx context.Context, packageID string, count int) error {
+	r.pkg.Available += count
+	return nil
+}
+
+func (r *waitlistRepo) GetWaitingEntries(ctx context.Context, packageID string) ([]entity.WaitlistEntry, error) {
+	var waiting []entity.WaitlistEntry
+	for _, e := range r.entries {
+		if e.Status == entity.WaitlistWaiting {
+			waiting = append(waiting, e)
+		}
+	}
+	sort.Slice(waiting, func(i, j int) bool { return waiting[i].JoinedAt.Before(waiting[j].JoinedAt) })
+	return waiting, nil
+}
+
+func (r *waitlistRepo) CountWaiting(ctx context.Context, packageID string) (int64, error) {
+	waiting, _ := r.GetWaitingEntries(ctx, packageID)
+	return int64(len(waiting)), nil
+}
+
+func (r *waitlistRepo) CountWaitingAhead(ctx context.Context, entry *entity.WaitlistEntry) (int64, error) {
+	var ahead int64
+	for _, e := range r.entries {
+		if e.Status == entity.WaitlistWaiting && e.JoinedAt.Before(entry.JoinedAt) {
+			ahead++
+		}
+	}
+	return ahead, nil
+}
+
+func (r *waitlistRepo) FindActiveWaitlistEntry(ctx context.Context, packageID, userID string) (*entity.WaitlistEntry, error) {
+	for _, e := range r.entries {
+		if e.UserID.String() == userID && (e.Status == entity.WaitlistWaiting || e.Status == entity.WaitlistOffered) {
+			return &e, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *waitlistRepo) CreateWaitlistEntry(ctx context.Context, entry *entity.WaitlistEntry) error {
+	r.entries = append(r.entries, *entry)
+	return nil
+}
+
+func (r *waitlistRepo) UpdateWaitlistEntry(ctx context.Context, entry *entity.WaitlistEntry) error {
+	for i := range r.entries {
+		if r.entries[i].ID == entry.ID {
+			r.entries[i] = *entry
+			return nil
+		}
+	}
+	return errors.New("waitlist entry not found")
+}
+
+func (r *waitlistRepo) status(id uuid.UUID) entity.WaitlistStatus {
+	for _, e := range r.entries {
+		if e.ID == id {
+			return e.Status
+		}
+	}
+	return ""
+}
+
+// Seats freed for a party at the head of the queue that does not fit yet
+// stay held for it: nobody behind it, and no newcomer booking directly,
+// may take them first.
+func TestWaitlistSeatsGoToTheHeadOfTheQueue(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	pkg := entity.TravelPackage{
+		ID:            uuid.New(),
+		IsActive:      true,
+		Quota:         10,
+		Available:     0,
+		DepartureDate: now.AddDate(0, 2, 0),
+		PriceQuad:     money.New(3500000000, money.IDR),
+	}
+	family := entity.WaitlistEntry{ID: uuid.New(), PackageID: pkg.ID, UserID: uuid.New(), RoomType: "QUAD", PaxCount: 3,
+		Status: entity.WaitlistWaiting, JoinedAt: now.Add(-2 * time.Hour)}
+	single := entity.WaitlistEntry{ID: uuid.New(), PackageID: pkg.ID, UserID: uuid.New(), RoomType: "QUAD", PaxCount: 1,
+		Status: entity.WaitlistWaiting, JoinedAt: now.Add(-time.Hour)}
+
+	repo := &waitlistRepo{pkg: pkg, entries: []entity.WaitlistEntry{family, single}}
+	svc := &packageService{repo: repo}
+
+	// Two seats come back: the family of three does not fit, and the
+	// single traveller behind it must not be served first
+	repo.IncreaseQuota(ctx, pkg.ID.String(), 2)
+	offered, err := svc.offerSeats(ctx, repo, pkg.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(offered) != 0 {
+		t.Fatalf("offered %v while the head of the queue does not fit", offered)
+	}
+	if repo.pkg.Available != 2 {
+		t.Fatalf("available = %d, want the 2 freed seats kept", repo.pkg.Available)
+	}
+
+	// A newcomer cannot book the held seats directly...
+	newcomer := uuid.NewString()
+	_, err = svc.BookPackage(ctx, newcomer, entity.BookPackageDTO{PackageID: pkg.ID.String(), RoomType: "QUAD", PaxCount: 1})
+	if !errors.Is(err, ErrNotEnoughSeats) {
+		t.Fatalf("direct booking error = %v, want %v", err, ErrNotEnoughSeats)
+	}
+	if repo.pkg.Available != 2 {
+		t.Fatalf("available = %d after a refused booking, want 2", repo.pkg.Available)
+	}
+
+	// ...but may join the queue, behind everyone already waiting
+	entry, err := svc.JoinWaitlist(ctx, newcomer, entity.JoinWaitlistDTO{PackageID: pkg.ID.String(), RoomType: "QUAD", PaxCount: 1})
+	if err != nil {
+		t.Fatalf("joining the waitlist while seats are held: %v", err)
+	}
+	if entry.Position != 3 {
+		t.Errorf("newcomer position = %d, want 3", entry.Position)
+	}
+
+	// One more seat: now the family fits and is offered first
+	repo.IncreaseQuota(ctx, pkg.ID.String(), 1)
+	offered, err = svc.offerSeats(ctx, repo, pkg.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(offered) != 1 || offered[0] != family.ID {
+		t.Fatalf("offered %v, want only the family %s", offered, family.ID)
+	}
+	if got := repo.status(single.ID); got != entity.WaitlistWaiting {
+		t.Errorf("single traveller is %s, want still WAITING", got)
+	}
+	if repo.pkg.Available != 0 {
+		t.Errorf("available = %d, want 0", repo.pkg.Available)
+	}
+}
@@ -0,0 +1,77 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+	"umrah-backend/internal/service"
+)
+
+// ExpiryWorker periodically releases seats held by unpaid bookings and
+// unclaimed waitlist offers, stock reserved by unpaid orders, and promo
+// uses reserved by checkouts that never finished. It also applies the
+// scheduled publish/unpublish times of packages.
+type ExpiryWorker struct {
+	pkgSvc      service.PackageService
+	commerceSvc service.CommerceService
+	promoSvc    service.PromoService
+	interval    time.Duration
+}
+
+func NewExpiryWorker(pkgSvc service.PackageService, commerceSvc service.CommerceService, promoSvc service.PromoService, interval time.Duration) *ExpiryWorker {
+	return &ExpiryWorker{pkgSvc: pkgSvc, commerceSvc: commerceSvc, promoSvc: promoSvc, interval: interval}
+}
+
+func (w *ExpiryWorker) Start() {
+	go func() {
+		log.Println("⏳ Expiry Worker Started")
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			w.sweep()
+		}
+	}()
+}
+
+func (w *ExpiryWorker) sweep() {
+	n, err := w.pkgSvc.ExpireBookings(context.Background())
+	if err != nil {
+		log.Printf("Booking expiry error: %v", err)
+	}
+	if n > 0 {
+		log.Printf("Expired %d unpaid bookings", n)
+	}
+
+	n, err = w.pkgSvc.ExpireWaitlistOffers(context.Background())
+	if err != nil {
+		log.Printf("Waitlist offer expiry error: %v", err)
+	}
+	if n > 0 {
+		log.Printf("Expired %d unclaimed waitlist offers", n)
+	}
+
+	n, err = w.pkgSvc.PublishScheduledPackages(context.Background())
+	if err != nil {
+		log.Printf("Package schedule error: %v", err)
+	}
+	if n > 0 {
+		log.Printf("Applied %d scheduled package listing changes", n)
+	}
+
+	n, err = w.commerceSvc.ExpireOrders(context.Background())
+	if err != nil {
+		log.Printf("Order expiry error: %v", err)
+	}
+	if n > 0 {
+		log.Printf("Expired %d unpaid orders", n)
+	}
+
+	n, err = w.promoSvc.ExpireReservations(context.Background())
+	if err != nil {
+		log.Printf("Promo reservation expiry error: %v", err)
+	}
+	if n > 0 {
+		log.Printf("Released %d orphaned promo reservations", n)
+	}
+}
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"fmt"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// legacyMoneyColumns: float columns replaced by <prefix>minor + <prefix>currency.
+var legacyMoneyColumns = []struct {
+	Table  string
+	Column string
+	Prefix string
+}{
+	{"products", "price", "price_"},
+	{"orders", "amount", "amount_"},
+	{"travel_packages", "price_quad", "price_quad_"},
+	{"travel_packages", "price_triple", "price_triple_"},
+	{"travel_packages", "price_double", "price_double_"},
+	{"bookings", "total_price", "total_price_"},
+	{"booking_installments", "amount", "amount_"},
+	{"payment_transactions", "amount", "amount_"},
+}
+
+// MigrateLegacyMoneyColumns copies old float amounts (always Rupiah) into the
+// integer minor-unit columns, then drops the float column. Safe to run on
+// every boot: columns that are already gone are skipped.
+// Must run after AutoMigrate so the new columns exist.
+func MigrateLegacyMoneyColumns(db *gorm.DB) error {
+	migrator := db.Migrator()
+
+	for _, col := range legacyMoneyColumns {
+		if !migrator.HasTable(col.Table) || !migrator.HasColumn(col.Table, col.Column) {
+			continue
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			backfill := fmt.Sprintf(
+				"UPDATE %s SET %sminor = ROUND(%s::numeric * 100), %scurrency = 'IDR' WHERE %s IS NOT NULL",
+				col.Table, col.Prefix, col.Column, col.Prefix, col.Column,
+			)
+			if err := tx.Exec(backfill).Error; err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(col.Table, col.Column)
+		})
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", col.Table, col.Column, err)
+		}
+		log.Printf("Migrated %s.%s to %sminor", col.Table, col.Column, col.Prefix)
+	}
+	return nil
+}
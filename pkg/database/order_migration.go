@@ -0,0 +1,59 @@
+package database
+
+import (
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// MigrateLegacyOrderItems moves single-product orders (orders.product_id)
+// into one order_items line each, then drops the old column. Safe to run on
+// every boot. Must run after MigrateLegacyMoneyColumns (uses amount_minor).
+func MigrateLegacyOrderItems(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable("orders") || !migrator.HasColumn("orders", "product_id") {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Exec(`
+			INSERT INTO order_items (id, order_id, product_id, product_name, quantity,
+				unit_price_minor, unit_price_currency, subtotal_minor, subtotal_currency, created_at)
+			SELECT gen_random_uuid(), o.id, o.product_id, COALESCE(p.name, ''), 1,
+				o.amount_minor, o.amount_currency, o.amount_minor, o.amount_currency, o.created_at
+			FROM orders o
+			LEFT JOIN products p ON p.id = o.product_id
+			WHERE o.product_id IS NOT NULL
+			  AND NOT EXISTS (SELECT 1 FROM order_items i WHERE i.order_id = o.id)`)
+		if res.Error != nil {
+			return res.Error
+		}
+		log.Printf("Migrated %d legacy orders to order_items", res.RowsAffected)
+
+		return tx.Migrator().DropColumn("orders", "product_id")
+	})
+}
+
+// DropLegacyCartIndex removes the (user_id, product_id) unique index from
+// before product variants; it is replaced by idx_cart_user_item, which also
+// covers variant_id so one cart can hold two sizes of the same product.
+func DropLegacyCartIndex(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasIndex("cart_items", "idx_cart_user_product") {
+		return nil
+	}
+	return migrator.DropIndex("cart_items", "idx_cart_user_product")
+}
+
+// MigrateLegacyOrderProofs seeds the proof history with the single
+// proof_image stored on orders before the history table existed.
+func MigrateLegacyOrderProofs(db *gorm.DB) error {
+	return db.Exec(`
+		INSERT INTO order_proofs (id, order_id, image_url, status, created_at)
+		SELECT gen_random_uuid(), o.id, o.proof_image,
+			CASE WHEN o.status = 'COMPLETED' THEN 'ACCEPTED' ELSE 'SUBMITTED' END,
+			o.updated_at
+		FROM orders o
+		WHERE o.proof_image <> ''
+		  AND NOT EXISTS (SELECT 1 FROM order_proofs p WHERE p.order_id = o.id)`).Error
+}
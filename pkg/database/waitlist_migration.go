@@ -0,0 +1,29 @@
+package database
+
+import "gorm.io/gorm"
+
+// CreateWaitlistActiveIndex allows one WAITING or OFFERED entry per user and
+// package, so two concurrent joins cannot both get in. GORM tags cannot
+// express the partial index, hence the raw SQL. Duplicate WAITING entries
+// left by that race are cancelled first, keeping the oldest (they hold no
+// seats). Safe to run on every boot.
+func CreateWaitlistActiveIndex(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`
+			UPDATE waitlist_entries w SET status = 'CANCELLED', updated_at = NOW()
+			WHERE w.status = 'WAITING'
+			  AND EXISTS (
+				SELECT 1 FROM waitlist_entries o
+				WHERE o.package_id = w.package_id AND o.user_id = w.user_id
+				  AND o.status IN ('WAITING', 'OFFERED') AND o.id <> w.id
+				  AND (o.status = 'OFFERED' OR (o.joined_at, o.id) < (w.joined_at, w.id))
+			  )`).Error
+		if err != nil {
+			return err
+		}
+		return tx.Exec(`
+			CREATE UNIQUE INDEX IF NOT EXISTS idx_waitlist_active
+			ON waitlist_entries (package_id, user_id)
+			WHERE status IN ('WAITING', 'OFFERED')`).Error
+	})
+}
@@ -0,0 +1,262 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+type Currency string
+
+const (
+	IDR Currency = "IDR"
+	SAR Currency = "SAR"
+	USD Currency = "USD"
+)
+
+// Default currency for legacy data and prices without an explicit currency
+const Default = IDR
+
+var (
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	ErrInvalidCurrency  = errors.New("invalid currency")
+	ErrInvalidAmount    = errors.New("amount must not be negative")
+	ErrInvalidRate      = errors.New("invalid exchange rate")
+)
+
+// exponents: number of minor-unit digits per currency (ISO 4217)
+var exponents = map[Currency]int{
+	IDR: 2,
+	SAR: 2,
+	USD: 2,
+}
+
+func (c Currency) Valid() bool {
+	_, ok := exponents[c]
+	return ok
+}
+
+func (c Currency) Exponent() int {
+	return exponents[c]
+}
+
+// ParseCurrency normalises user input ("usd" -> USD).
+func ParseCurrency(s string) (Currency, error) {
+	c := Currency(strings.ToUpper(strings.TrimSpace(s)))
+	if !c.Valid() {
+		return "", fmt.Errorf("%w: %q", ErrInvalidCurrency, s)
+	}
+	return c, nil
+}
+
+// Money is an amount in integer minor units (sen/halala/cent).
+// Embed it in entities with `gorm:"embedded;embeddedPrefix:price_"`,
+// which maps to the columns price_minor and price_currency.
+type Money struct {
+	Amount   int64    `gorm:"column:minor;not null;default:0" json:"amount"`
+	Currency Currency `gorm:"column:currency;type:varchar(3);not null;default:'IDR'" json:"currency"`
+}
+
+func New(amount int64, currency Currency) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+func Zero(currency Currency) Money {
+	return Money{Currency: currency}
+}
+
+func (m Money) IsZero() bool     { return m.Amount == 0 }
+func (m Money) IsPositive() bool { return m.Amount > 0 }
+func (m Money) IsNegative() bool { return m.Amount < 0 }
+
+// Validate checks the currency is supported and the amount is not negative.
+func (m Money) Validate() error {
+	if !m.Currency.Valid() {
+		return fmt.Errorf("%w: %q", ErrInvalidCurrency, m.Currency)
+	}
+	if m.Amount < 0 {
+		return ErrInvalidAmount
+	}
+	return nil
+}
+
+func (m Money) SameCurrency(o Money) bool {
+	return m.Currency == o.Currency
+}
+
+func (m Money) Add(o Money) (Money, error) {
+	if !m.SameCurrency(o) {
+		return Money{}, fmt.Errorf("%w: %s + %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	return Money{Amount: m.Amount + o.Amount, Currency: m.Currency}, nil
+}
+
+func (m Money) Sub(o Money) (Money, error) {
+	if !m.SameCurrency(o) {
+		return Money{}, fmt.Errorf("%w: %s - %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	return Money{Amount: m.Amount - o.Amount, Currency: m.Currency}, nil
+}
+
+// Mul multiplies by a whole quantity (pax, item count).
+func (m Money) Mul(n int64) Money {
+	return Money{Amount: m.Amount * n, Currency: m.Currency}
+}
+
+// Cmp returns -1, 0 or +1. Both sides must share a currency.
+func (m Money) Cmp(o Money) (int, error) {
+	if !m.SameCurrency(o) {
+		return 0, fmt.Errorf("%w: %s vs %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	switch {
+	case m.Amount < o.Amount:
+		return -1, nil
+	case m.Amount > o.Amount:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
+// Split divides m into n parts whose sum is exactly m; the last part
+// carries the remainder.
+func (m Money) Split(n int) []Money {
+	if n <= 0 {
+		return nil
+	}
+	part := m.Amount / int64(n)
+	parts := make([]Money, n)
+	for i := range parts {
+		parts[i] = Money{Amount: part, Currency: m.Currency}
+	}
+	parts[n-1].Amount += m.Amount - part*int64(n)
+	return parts
+}
+
+// Percent returns basisPoints/10000 of m, rounded half up
+// (e.g. 1250 = 12.5%).
+func (m Money) Percent(basisPoints int64) Money {
+	r := new(big.Rat).SetFrac64(m.Amount*basisPoints, 10000)
+	return Money{Amount: roundRat(r), Currency: m.Currency}
+}
+
+// Convert applies rate (units of `to` per 1 unit of m.Currency), rounding
+// half up to the minor unit of the target currency.
+func (m Money) Convert(to Currency, rate *big.Rat) Money {
+	if m.Currency == to {
+		return m
+	}
+	r := new(big.Rat).Mul(new(big.Rat).SetInt64(m.Amount), rate)
+	// Adjust for different minor-unit exponents
+	if diff := to.Exponent() - m.Currency.Exponent(); diff != 0 {
+		scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(diff))), nil))
+		if diff > 0 {
+			r.Mul(r, scale)
+		} else {
+			r.Quo(r, scale)
+		}
+	}
+	return Money{Amount: roundRat(r), Currency: to}
+}
+
+// Major formats the amount in major units without grouping, e.g. "35000000.00".
+func (m Money) Major() string {
+	exp := m.Currency.Exponent()
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	if exp == 0 {
+		return sign + strconv.FormatInt(amount, 10)
+	}
+	div := int64(1)
+	for i := 0; i < exp; i++ {
+		div *= 10
+	}
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/div, exp, amount%div)
+}
+
+// String formats for humans, e.g. "IDR 35,000,000.00".
+func (m Money) String() string {
+	major := m.Major()
+	sign := ""
+	if strings.HasPrefix(major, "-") {
+		sign, major = "-", major[1:]
+	}
+	intPart, frac := major, ""
+	if i := strings.IndexByte(major, '.'); i >= 0 {
+		intPart, frac = major[:i], major[i:]
+	}
+
+	var b strings.Builder
+	for i, d := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(d)
+	}
+	return fmt.Sprintf("%s %s%s%s", m.Currency, sign, b.String(), frac)
+}
+
+// MarshalJSON adds a human readable "formatted" field for clients.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount    int64    `json:"amount"`
+		Currency  Currency `json:"currency"`
+		Formatted string   `json:"formatted"`
+	}{m.Amount, m.Currency, m.String()})
+}
+
+// UnmarshalJSON accepts {"amount": <minor units>, "currency": "IDR"}.
+// A missing currency falls back to Default.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Amount   int64    `json:"amount"`
+		Currency Currency `json:"currency"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if raw.Currency == "" {
+		raw.Currency = Default
+	}
+	m.Amount, m.Currency = raw.Amount, Currency(strings.ToUpper(string(raw.Currency)))
+	return nil
+}
+
+// ParseRate parses a decimal exchange rate such as "4330.25".
+func ParseRate(s string) (*big.Rat, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok || r.Sign() <= 0 {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidRate, s)
+	}
+	return r, nil
+}
+
+func roundRat(r *big.Rat) int64 {
+	num := new(big.Int).Set(r.Num())
+	den := r.Denom()
+	neg := num.Sign() < 0
+	if neg {
+		num.Neg(num)
+	}
+	// floor((2*num + den) / (2*den)) = round half up
+	num.Mul(num, big.NewInt(2))
+	num.Add(num, den)
+	q := new(big.Int).Quo(num, new(big.Int).Mul(den, big.NewInt(2)))
+	if neg {
+		q.Neg(q)
+	}
+	return q.Int64()
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,270 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestAddSub(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    Money
+		sum     Money
+		diff    Money
+		wantErr error
+	}{
+		{"same currency", New(150000, IDR), New(2550, IDR), New(152550, IDR), New(147450, IDR), nil},
+		{"zero", New(99, USD), Zero(USD), New(99, USD), New(99, USD), nil},
+		{"goes negative", New(100, SAR), New(250, SAR), New(350, SAR), New(-150, SAR), nil},
+		{"currency mismatch", New(100, IDR), New(100, USD), Money{}, Money{}, ErrCurrencyMismatch},
+		{"missing currency", New(100, IDR), New(100, ""), Money{}, Money{}, ErrCurrencyMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sum, err := tt.a.Add(tt.b)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Add error = %v, want %v", err, tt.wantErr)
+			}
+			if sum != tt.sum {
+				t.Errorf("Add = %v, want %v", sum, tt.sum)
+			}
+
+			diff, err := tt.a.Sub(tt.b)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Sub error = %v, want %v", err, tt.wantErr)
+			}
+			if diff != tt.diff {
+				t.Errorf("Sub = %v, want %v", diff, tt.diff)
+			}
+		})
+	}
+}
+
+func TestCmp(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    Money
+		want    int
+		wantErr error
+	}{
+		{"less", New(1, IDR), New(2, IDR), -1, nil},
+		{"equal", New(2, IDR), New(2, IDR), 0, nil},
+		{"greater", New(3, IDR), New(2, IDR), 1, nil},
+		{"currency mismatch", New(3, IDR), New(2, SAR), 0, ErrCurrencyMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.a.Cmp(tt.b)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Cmp error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Cmp = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMul(t *testing.T) {
+	tests := []struct {
+		m    Money
+		n    int64
+		want Money
+	}{
+		{New(3250000000, IDR), 4, New(13000000000, IDR)},
+		{New(199, USD), 0, New(0, USD)},
+		{New(-50, SAR), 3, New(-150, SAR)},
+	}
+	for _, tt := range tests {
+		if got := tt.m.Mul(tt.n); got != tt.want {
+			t.Errorf("%v.Mul(%d) = %v, want %v", tt.m, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name string
+		m    Money
+		n    int
+		want []int64
+	}{
+		{"even", New(900, IDR), 3, []int64{300, 300, 300}},
+		{"remainder on the last part", New(1000, IDR), 3, []int64{333, 333, 334}},
+		{"single part", New(1001, USD), 1, []int64{1001}},
+		{"more parts than minor units", New(2, USD), 3, []int64{0, 0, 2}},
+		{"no parts", New(1000, IDR), 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts := tt.m.Split(tt.n)
+			var got []int64
+			total := Zero(tt.m.Currency)
+			for _, p := range parts {
+				if p.Currency != tt.m.Currency {
+					t.Fatalf("part currency = %s, want %s", p.Currency, tt.m.Currency)
+				}
+				got = append(got, p.Amount)
+				total, _ = total.Add(p)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Split = %v, want %v", got, tt.want)
+			}
+			if tt.n > 0 && total != tt.m {
+				t.Errorf("parts add up to %v, want %v", total, tt.m)
+			}
+		})
+	}
+}
+
+func TestPercent(t *testing.T) {
+	tests := []struct {
+		name        string
+		m           Money
+		basisPoints int64
+		want        int64
+	}{
+		{"whole percent", New(3500000000, IDR), 1000, 350000000},
+		{"fractional percent", New(10000, USD), 1250, 1250},
+		{"rounds half up", New(1005, IDR), 5000, 503},
+		{"rounds down below half", New(1004, IDR), 5000, 502},
+		{"negative rounds away from zero", New(-1005, IDR), 5000, -503},
+		{"zero", New(1005, IDR), 0, 0},
+		{"hundred percent", New(1005, IDR), 10000, 1005},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.m.Percent(tt.basisPoints)
+			if got != New(tt.want, tt.m.Currency) {
+				t.Errorf("Percent(%d) = %v, want %d %s", tt.basisPoints, got, tt.want, tt.m.Currency)
+			}
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name string
+		m    Money
+		to   Currency
+		rate string
+		want Money
+	}{
+		{"usd to idr", New(100, USD), IDR, "16250", New(1625000, IDR)},
+		{"sar to idr with decimals", New(100, SAR), IDR, "4330.25", New(433025, IDR)},
+		{"rounds half up", New(3, IDR), USD, "0.5", New(2, USD)},
+		{"rounds down below half", New(1, USD), IDR, "4330.249", New(4330, IDR)},
+		{"same currency is unchanged", New(123, IDR), IDR, "2", New(123, IDR)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate, err := ParseRate(tt.rate)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := tt.m.Convert(tt.to, rate); got != tt.want {
+				t.Errorf("Convert = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	for _, s := range []string{"", "abc", "0", "-1.5"} {
+		if _, err := ParseRate(s); !errors.Is(err, ErrInvalidRate) {
+			t.Errorf("ParseRate(%q) error = %v, want %v", s, err, ErrInvalidRate)
+		}
+	}
+	if _, err := ParseRate(" 16250.75 "); err != nil {
+		t.Errorf("ParseRate with spaces: %v", err)
+	}
+}
+
+func TestParseCurrency(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Currency
+		wantErr error
+	}{
+		{"IDR", IDR, nil},
+		{" usd ", USD, nil},
+		{"Sar", SAR, nil},
+		{"EUR", "", ErrInvalidCurrency},
+		{"", "", ErrInvalidCurrency},
+	}
+	for _, tt := range tests {
+		got, err := ParseCurrency(tt.in)
+		if !errors.Is(err, tt.wantErr) || got != tt.want {
+			t.Errorf("ParseCurrency(%q) = %q, %v; want %q, %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		m       Money
+		wantErr error
+	}{
+		{New(0, IDR), nil},
+		{New(100, USD), nil},
+		{New(-1, IDR), ErrInvalidAmount},
+		{New(100, "EUR"), ErrInvalidCurrency},
+	}
+	for _, tt := range tests {
+		if err := tt.m.Validate(); !errors.Is(err, tt.wantErr) {
+			t.Errorf("%#v.Validate() = %v, want %v", tt.m, err, tt.wantErr)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		m      Money
+		major  string
+		string string
+	}{
+		{New(3500000000, IDR), "35000000.00", "IDR 35,000,000.00"},
+		{New(5, USD), "0.05", "USD 0.05"},
+		{New(-1234567, SAR), "-12345.67", "SAR -12,345.67"},
+		{New(100000, IDR), "1000.00", "IDR 1,000.00"},
+		{Zero(IDR), "0.00", "IDR 0.00"},
+	}
+	for _, tt := range tests {
+		if got := tt.m.Major(); got != tt.major {
+			t.Errorf("Major(%d %s) = %q, want %q", tt.m.Amount, tt.m.Currency, got, tt.major)
+		}
+		if got := tt.m.String(); got != tt.string {
+			t.Errorf("String(%d %s) = %q, want %q", tt.m.Amount, tt.m.Currency, got, tt.string)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	b, err := json.Marshal(New(150050, USD))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"amount":150050,"currency":"USD","formatted":"USD 1,500.50"}`; string(b) != want {
+		t.Errorf("Marshal = %s, want %s", b, want)
+	}
+
+	tests := []struct {
+		in   string
+		want Money
+	}{
+		{`{"amount":150050,"currency":"USD","formatted":"ignored"}`, New(150050, USD)},
+		{`{"amount":100,"currency":"sar"}`, New(100, SAR)},
+		{`{"amount":100}`, New(100, Default)},
+	}
+	for _, tt := range tests {
+		var got Money
+		if err := json.Unmarshal([]byte(tt.in), &got); err != nil {
+			t.Fatalf("Unmarshal(%s): %v", tt.in, err)
+		}
+		if got != tt.want {
+			t.Errorf("Unmarshal(%s) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+type Method string
+type EventStatus string
+
+const (
+	MethodVirtualAccount Method = "VA"
+	MethodQRIS           Method = "QRIS"
+
+	EventPaid     EventStatus = "PAID"
+	EventExpired  EventStatus = "EXPIRED"
+	EventFailed   EventStatus = "FAILED"
+	EventRefunded EventStatus = "REFUNDED"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrChargeNotFound   = errors.New("charge not found")
+)
+
+// ChargeRequest asks the provider to open a payment for one order/installment.
+type ChargeRequest struct {
+	ReferenceID   string // Our transaction ID, echoed back by the provider
+	Amount        int64  // Minor units
+	Currency      string
+	Method        Method
+	Bank          string // VA only, e.g. "BSI", "BCA", "MANDIRI"
+	CustomerName  string
+	CustomerPhone string
+	ExpiresAt     time.Time
+}
+
+// Charge is what the customer needs to pay: a VA number or a QRIS payload.
+type Charge struct {
+	ExternalID string
+	Method     Method
+	Bank       string
+	VANumber   string
+	QRString   string
+	Amount     int64
+	Currency   string
+	ExpiresAt  time.Time
+}
+
+// WebhookEvent is a verified, provider-agnostic payment callback.
+type WebhookEvent struct {
+	EventID    string // Unique per callback, used for idempotency
+	ExternalID string
+	Status     EventStatus
+	Amount     int64 // Minor units
+	Currency   string
+	OccurredAt time.Time
+	Payload    []byte
+}
+
+type RefundRequest struct {
+	ExternalID string
+	Amount     int64
+	Currency   string
+	Reason     string
+}
+
+type Refund struct {
+	RefundID string
+	Status   string
+}
+
+// Provider is implemented by every payment gateway integration.
+type Provider interface {
+	Name() string
+	CreateCharge(ctx context.Context, req ChargeRequest) (*Charge, error)
+	// CancelCharge closes an unpaid charge so it can no longer be paid.
+	CancelCharge(ctx context.Context, externalID string) error
+	// ParseWebhook verifies the callback signature before decoding it.
+	ParseWebhook(headers map[string]string, body []byte) (*WebhookEvent, error)
+	Refund(ctx context.Context, req RefundRequest) (*Refund, error)
+}
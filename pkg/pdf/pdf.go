@@ -0,0 +1,248 @@
+// Package pdf is a small PDF 1.4 writer for generated business documents
+// (invoices, receipts). It only supports what those need: A4 pages, the
+// built-in Helvetica fonts, text, lines and filled rectangles.
+//
+// Coordinates are in points with the origin at the TOP-left corner of the
+// page, y growing downwards (PDF itself counts from the bottom).
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A4 in points
+const (
+	PageWidth  = 595.28
+	PageHeight = 841.89
+)
+
+type Font int
+
+const (
+	Regular Font = iota
+	Bold
+)
+
+// resource names and base fonts, indexed by Font
+var fontNames = [...]string{"F1", "F2"}
+var baseFonts = [...]string{"Helvetica", "Helvetica-Bold"}
+
+type Color struct{ R, G, B uint8 }
+
+var (
+	Black = Color{0, 0, 0}
+	White = Color{255, 255, 255}
+	Gray  = Color{110, 110, 110}
+)
+
+func (c Color) String() string {
+	return fmt.Sprintf("%.3f %.3f %.3f", float64(c.R)/255, float64(c.G)/255, float64(c.B)/255)
+}
+
+// ParseHexColor parses "#RRGGBB" (or "RRGGBB").
+func ParseHexColor(s string) (Color, error) {
+	var c Color
+	s = strings.TrimPrefix(s, "#")
+	if len(s) != 6 {
+		return c, fmt.Errorf("invalid color %q", s)
+	}
+	if _, err := fmt.Sscanf(s, "%02x%02x%02x", &c.R, &c.G, &c.B); err != nil {
+		return c, fmt.Errorf("invalid color %q", s)
+	}
+	return c, nil
+}
+
+type Document struct {
+	title string
+	pages []*Page
+}
+
+func New(title string) *Document {
+	return &Document{title: title}
+}
+
+// AddPage appends an empty A4 page with Helvetica 10pt in black.
+func (d *Document) AddPage() *Page {
+	p := &Page{font: Regular, size: 10, color: Black}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+type Page struct {
+	content bytes.Buffer
+	font    Font
+	size    float64
+	color   Color
+}
+
+func (p *Page) SetFont(f Font, size float64) {
+	p.font, p.size = f, size
+}
+
+// SetColor sets the color used by Text, Line and Rect.
+func (p *Page) SetColor(c Color) {
+	p.color = c
+}
+
+// Text draws s with its baseline at y.
+func (p *Page) Text(x, y float64, s string) {
+	fmt.Fprintf(&p.content, "BT %s rg /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n",
+		p.color, fontNames[p.font], p.size, x, PageHeight-y, escape(encode(s)))
+}
+
+// TextRight draws s so that it ends at x (for amount columns).
+func (p *Page) TextRight(x, y float64, s string) {
+	p.Text(x-TextWidth(p.font, p.size, s), y, s)
+}
+
+// Line draws a straight line of the given width.
+func (p *Page) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(&p.content, "%s RG %.2f w %.2f %.2f m %.2f %.2f l S\n",
+		p.color, width, x1, PageHeight-y1, x2, PageHeight-y2)
+}
+
+// Rect fills a rectangle whose top-left corner is (x, y).
+func (p *Page) Rect(x, y, w, h float64) {
+	fmt.Fprintf(&p.content, "%s rg %.2f %.2f %.2f %.2f re f\n",
+		p.color, x, PageHeight-y-h, w, h)
+}
+
+// Wrap splits s into lines no wider than width at the page's current font.
+func (p *Page) Wrap(s string, width float64) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(s) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if line != "" && TextWidth(p.font, p.size, candidate) > width {
+			lines = append(lines, line)
+			candidate = word
+		}
+		line = candidate
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// Bytes renders the document.
+func (d *Document) Bytes() []byte {
+	var buf bytes.Buffer
+	d.WriteTo(&buf)
+	return buf.Bytes()
+}
+
+// WriteTo renders the document. Object layout:
+// 1 catalog, 2 page tree, 3 info, 4..5 fonts, then (page, content) pairs.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	var offsets []int
+
+	obj := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	pages := d.pages
+	if len(pages) == 0 {
+		pages = []*Page{{}}
+	}
+	firstPage := 4 + len(fontNames)
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+
+	obj(fmt.Sprintf("<< /Title (%s) /Producer (umrah-backend) >>", escape(encode(d.title))))
+
+	fonts := make([]string, len(fontNames))
+	for i, base := range baseFonts {
+		obj(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", base))
+		fonts[i] = fmt.Sprintf("/%s %d 0 R", fontNames[i], 4+i)
+	}
+
+	for i, p := range pages {
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << %s >> >> /Contents %d 0 R >>",
+			PageWidth, PageHeight, strings.Join(fonts, " "), firstPage+2*i+1))
+		obj(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", p.content.Len(), p.content.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Info 3 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	n, err := w.Write(buf.Bytes())
+	return int64(n), err
+}
+
+// encode maps s to WinAnsi (Latin-1 range); other characters become '?'.
+func encode(s string) string {
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r >= 32 && r <= 126, r >= 160 && r <= 255:
+			b = append(b, byte(r))
+		case r == '\t' || r == '\n':
+			b = append(b, ' ')
+		default:
+			b = append(b, '?')
+		}
+	}
+	return string(b)
+}
+
+func escape(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`)
+	return r.Replace(s)
+}
+
+// TextWidth returns the width of s in points.
+func TextWidth(f Font, size float64, s string) float64 {
+	widths := &helveticaWidths
+	if f == Bold {
+		widths = &helveticaBoldWidths
+	}
+	total := 0
+	for _, c := range []byte(encode(s)) {
+		if c >= 32 && c <= 126 {
+			total += widths[c-32]
+		} else {
+			total += 556
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// Glyph widths (1/1000 em) for characters 32..126, from the Adobe AFM files.
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
@@ -0,0 +1,128 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+// parseXref follows startxref to the cross-reference table and returns the
+// offset of every in-use object, indexed by object number.
+func parseXref(t *testing.T, doc []byte) map[int]int {
+	t.Helper()
+
+	if !bytes.HasPrefix(doc, []byte("%PDF-1.4\n")) {
+		t.Fatalf("missing %%PDF-1.4 header: %q", doc[:min(len(doc), 16)])
+	}
+	m := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(doc)
+	if m == nil {
+		t.Fatal("document does not end with startxref <offset> and the EOF marker")
+	}
+	start, _ := strconv.Atoi(string(m[1]))
+	if start <= 0 || start >= len(doc) {
+		t.Fatalf("startxref %d is outside the document", start)
+	}
+
+	rest := doc[start:]
+	var count int
+	if _, err := fmt.Sscanf(string(rest), "xref\n0 %d\n", &count); err != nil {
+		t.Fatalf("startxref does not point at an xref table: %q", rest[:min(len(rest), 20)])
+	}
+	rest = rest[bytes.IndexByte(rest[len("xref\n"):], '\n')+len("xref\n")+1:]
+
+	// Every entry is exactly 20 bytes: "nnnnnnnnnn ggggg x \n"
+	entry := regexp.MustCompile(`^(\d{10}) (\d{5}) ([fn]) \n$`)
+	offsets := make(map[int]int)
+	for i := 0; i < count; i++ {
+		if len(rest) < 20 {
+			t.Fatalf("xref table ends after %d of %d entries", i, count)
+		}
+		e := entry.FindSubmatch(rest[:20])
+		if e == nil {
+			t.Fatalf("xref entry %d is malformed: %q", i, rest[:20])
+		}
+		if i == 0 {
+			if string(e[3]) != "f" || string(e[2]) != "65535" {
+				t.Errorf("entry 0 = %q, want the free list head", rest[:20])
+			}
+		} else {
+			if string(e[3]) != "n" {
+				t.Errorf("entry %d is not in use", i)
+			}
+			offsets[i], _ = strconv.Atoi(string(e[1]))
+		}
+		rest = rest[20:]
+	}
+
+	m = regexp.MustCompile(`^trailer\n<< /Size (\d+) /Root 1 0 R /Info 3 0 R >>\n`).FindSubmatch(rest)
+	if m == nil {
+		t.Fatalf("no trailer after the xref table: %q", rest[:min(len(rest), 40)])
+	}
+	if size, _ := strconv.Atoi(string(m[1])); size != count {
+		t.Errorf("trailer /Size = %d, xref has %d entries", size, count)
+	}
+	return offsets
+}
+
+func TestXrefTable(t *testing.T) {
+	tests := []struct {
+		name  string
+		pages int
+	}{
+		{"empty document", 0},
+		{"one page", 1},
+		{"several pages", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := New("Invoice (INV/2024/0001) – café")
+			for i := 0; i < tt.pages; i++ {
+				p := d.AddPage()
+				p.SetFont(Bold, 14)
+				p.Text(40, 60, fmt.Sprintf("Page %d (of %d) \\ Rp 35.000.000 – ünïcode", i+1, tt.pages))
+				p.Line(40, 70, 555, 70, 0.5)
+				p.Rect(40, 80, 100, 20)
+			}
+			doc := d.Bytes()
+
+			offsets := parseXref(t, doc)
+
+			// Catalog, page tree, info, fonts, then a page + content per page
+			pages := max(tt.pages, 1)
+			if want := 3 + len(fontNames) + 2*pages; len(offsets) != want {
+				t.Fatalf("xref lists %d objects, want %d", len(offsets), want)
+			}
+			for num, off := range offsets {
+				if off <= 0 || off >= len(doc) {
+					t.Fatalf("object %d offset %d is outside the document", num, off)
+				}
+				header := fmt.Sprintf("%d 0 obj\n", num)
+				if !bytes.HasPrefix(doc[off:], []byte(header)) {
+					t.Errorf("object %d: offset %d points at %q", num, off, doc[off:min(len(doc), off+12)])
+				}
+			}
+		})
+	}
+}
+
+func TestStreamLength(t *testing.T) {
+	d := New("Receipt")
+	p := d.AddPage()
+	p.SetFont(Regular, 10)
+	p.Text(40, 40, "Paid (in full)")
+	p.TextRight(555, 40, "IDR 1,000.00")
+	doc := d.Bytes()
+
+	m := regexp.MustCompile(`<< /Length (\d+) >>\nstream\n`).FindSubmatchIndex(doc)
+	if m == nil {
+		t.Fatal("no content stream")
+	}
+	length, _ := strconv.Atoi(string(doc[m[2]:m[3]]))
+	body := doc[m[1]:]
+	end := bytes.Index(body, []byte("endstream"))
+	if end != length {
+		t.Errorf("/Length = %d, stream holds %d bytes", length, end)
+	}
+}